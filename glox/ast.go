@@ -20,6 +20,7 @@ type Visitor interface {
 	visitVariableExpr(expr VariableExpr) (interface{}, error)
 	visitAssignExpr(expr AssignExpr) (interface{}, error)
 	visitCallExpr(expr CallExpr) (interface{}, error)
+	visitGetExpr(expr GetExpr) (interface{}, error)
 }
 
 type Expr interface {
@@ -151,8 +152,20 @@ func NewUnaryExpr(operator Token, right Expr) UnaryExpr {
 	}
 }
 
+/*
+ * binding identifies a variable reference, for the Interpreter to record
+ * how far up the environment chain the Resolver found its variable. Nodes
+ * are values, so it is allocated once by the constructor and shared by all
+ * the copies of the node.
+ */
+type binding struct {
+	// pointers to distinct zero-size values may be equal.
+	_ byte
+}
+
 type VariableExpr struct {
-	Name Token
+	Name    Token
+	binding *binding
 }
 
 func (e VariableExpr) accept(visitor Visitor) (interface{}, error) {
@@ -165,13 +178,15 @@ func (e VariableExpr) getLine() int {
 
 func NewVariableExpr(name Token) VariableExpr {
 	return VariableExpr{
-		Name: name,
+		Name:    name,
+		binding: &binding{},
 	}
 }
 
 type AssignExpr struct {
-	Name  Token
-	Value Expr
+	Name    Token
+	Value   Expr
+	binding *binding
 }
 
 func (e AssignExpr) accept(visitor Visitor) (interface{}, error) {
@@ -184,8 +199,9 @@ func (e AssignExpr) getLine() int {
 
 func NewAssignExpr(name Token, value Expr) AssignExpr {
 	return AssignExpr{
-		Name:  name,
-		Value: value,
+		Name:    name,
+		Value:   value,
+		binding: &binding{},
 	}
 }
 
@@ -211,6 +227,26 @@ func NewCallExpr(callee Expr, paren Token, arguments []Expr) CallExpr {
 	}
 }
 
+type GetExpr struct {
+	Object Expr
+	Name   Token
+}
+
+func (e GetExpr) accept(visitor Visitor) (interface{}, error) {
+	return visitor.visitGetExpr(e)
+}
+
+func (e GetExpr) getLine() int {
+	return e.Name.Line
+}
+
+func NewGetExpr(object Expr, name Token) GetExpr {
+	return GetExpr{
+		Object: object,
+		Name:   name,
+	}
+}
+
 type Stmt interface {
 	accept(visitor Visitor) (interface{}, error)
 	getLine() int
@@ -0,0 +1,743 @@
+package glox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+ * The JSON form of a program parsed by glox, for tools written in other
+ * languages. A document is an object with the version of the schema and the
+ * statements of the program:
+ *
+ *   {"version": 2, "statements": [<stmt>, ...]}
+ *
+ * Every node is an object whose "node" member names its kind, with a member
+ * for each of its children, named after the fields of the Go node, and a
+ * "line" member with the line the node is reported at. "line" is only
+ * informative, and ignored when reading a document, but for literals, which
+ * have no token to take it from. Optional children are null when missing.
+ *
+ *   statements:
+ *   {"node": "Block", "statements": [<stmt>, ...]}
+ *   {"node": "Expression", "expression": <expr>}
+ *   {"node": "Print", "expression": <expr>}
+ *   {"node": "Var", "name": <token>, "initializer": <expr> | null}
+ *   {"node": "If", "condition": <expr>, "thenBranch": <stmt>, "elseBranch": <stmt> | null}
+ *   {"node": "While", "condition": <expr>, "body": <stmt>}
+ *   {"node": "Break", "token": <token>}
+ *   {"node": "Continue", "token": <token>}
+ *   {"node": "Function", "name": <token>, "params": [<token>, ...], "body": [<stmt>, ...]}
+ *   {"node": "Return", "keyword": <token>, "value": <expr> | null}
+ *
+ *   expressions:
+ *   {"node": "Binary", "left": <expr>, "operator": <token>, "right": <expr>}
+ *   {"node": "Conditional", "condition": <expr>, "left": <expr>, "right": <expr>}
+ *   {"node": "Grouping", "expression": <expr>}
+ *   {"node": "Literal", "value": <literal>, "line": <int>}
+ *   {"node": "Logical", "left": <expr>, "operator": <token>, "right": <expr>}
+ *   {"node": "Unary", "operator": <token>, "right": <expr>}
+ *   {"node": "Variable", "name": <token>}
+ *   {"node": "Assign", "name": <token>, "value": <expr>}
+ *   {"node": "Call", "callee": <expr>, "paren": <token>, "arguments": [<expr>, ...]}
+ *   {"node": "Get", "object": <expr>, "name": <token>}
+ *
+ * Tokens are objects with the name of their type, as in
+ * TOKEN_<type>, their lexeme, their literal, the line they end on and the
+ * column they start at, which is 0 unless the source was scanned with
+ * columns:
+ *
+ *   {"type": "IDENTIFIER", "lexeme": "x", "literal": "x", "line": 1, "column": 5}
+ *
+ * Literals are JSON numbers, strings, true, false or null. Ints are written
+ * as JSON integers and floats always with a fraction or an exponent, as in
+ * 1.0, so they read back as the same type. Documents of version 1, written
+ * before ints, are read with every number as a float.
+ */
+
+const AST_JSON_VERSION = 2
+
+// tokenTypeNames names token types in JSON documents, after their constants.
+var tokenTypeNames = map[int]string{
+	TOKEN_LEFT_PAREN:    "LEFT_PAREN",
+	TOKEN_RIGHT_PAREN:   "RIGHT_PAREN",
+	TOKEN_LEFT_BRACE:    "LEFT_BRACE",
+	TOKEN_RIGHT_BRACE:   "RIGHT_BRACE",
+	TOKEN_COMMA:         "COMMA",
+	TOKEN_DOT:           "DOT",
+	TOKEN_MINUS:         "MINUS",
+	TOKEN_PLUS:          "PLUS",
+	TOKEN_SEMICOLON:     "SEMICOLON",
+	TOKEN_SLASH:         "SLASH",
+	TOKEN_STAR:          "STAR",
+	TOKEN_QUESTION:      "QUESTION",
+	TOKEN_COLON:         "COLON",
+	TOKEN_PERCENT:       "PERCENT",
+	TOKEN_BANG:          "BANG",
+	TOKEN_BANG_EQUAL:    "BANG_EQUAL",
+	TOKEN_EQUAL:         "EQUAL",
+	TOKEN_EQUAL_EQUAL:   "EQUAL_EQUAL",
+	TOKEN_GREATER:       "GREATER",
+	TOKEN_GREATER_EQUAL: "GREATER_EQUAL",
+	TOKEN_LESS:          "LESS",
+	TOKEN_LESS_EQUAL:    "LESS_EQUAL",
+	TOKEN_STAR_STAR:     "STAR_STAR",
+	TOKEN_TILDE_SLASH:   "TILDE_SLASH",
+	TOKEN_IDENTIFIER:    "IDENTIFIER",
+	TOKEN_STRING:        "STRING",
+	TOKEN_NUMBER:        "NUMBER",
+	TOKEN_INTERPOLATION: "INTERPOLATION",
+	TOKEN_AND:           "AND",
+	TOKEN_CLASS:         "CLASS",
+	TOKEN_ELSE:          "ELSE",
+	TOKEN_FALSE:         "FALSE",
+	TOKEN_FUN:           "FUN",
+	TOKEN_FOR:           "FOR",
+	TOKEN_IF:            "IF",
+	TOKEN_NIL:           "NIL",
+	TOKEN_OR:            "OR",
+	TOKEN_PRINT:         "PRINT",
+	TOKEN_RETURN:        "RETURN",
+	TOKEN_SUPER:         "SUPER",
+	TOKEN_THIS:          "THIS",
+	TOKEN_TRUE:          "TRUE",
+	TOKEN_VAR:           "VAR",
+	TOKEN_WHILE:         "WHILE",
+	TOKEN_BREAK:         "BREAK",
+	TOKEN_CONTINUE:      "CONTINUE",
+	TOKEN_COMMENT:       "COMMENT",
+	TOKEN_EOF:           "EOF",
+}
+
+var tokenTypesByName = map[string]int{}
+
+func init() {
+	for tokenType, name := range tokenTypeNames {
+		tokenTypesByName[name] = tokenType
+	}
+}
+
+// AstToJson converts statements to the JSON document described above.
+func AstToJson(statements []Stmt) ([]byte, error) {
+	encoder := astJsonEncoder{}
+	document := map[string]interface{}{
+		"version":    AST_JSON_VERSION,
+		"statements": encoder.statements(statements),
+	}
+	return json.MarshalIndent(document, "", "  ")
+}
+
+type astJsonEncoder struct {
+}
+
+func (e astJsonEncoder) statements(statements []Stmt) []interface{} {
+	nodes := []interface{}{}
+	for _, stmt := range statements {
+		nodes = append(nodes, e.statement(stmt))
+	}
+	return nodes
+}
+
+func (e astJsonEncoder) statement(stmt Stmt) interface{} {
+	if stmt == nil {
+		return nil
+	}
+	node, _ := stmt.accept(e)
+	node.(map[string]interface{})["line"] = stmt.getLine()
+	return node
+}
+
+func (e astJsonEncoder) expressions(expressions []Expr) []interface{} {
+	nodes := []interface{}{}
+	for _, expr := range expressions {
+		nodes = append(nodes, e.expression(expr))
+	}
+	return nodes
+}
+
+func (e astJsonEncoder) expression(expr Expr) interface{} {
+	if expr == nil {
+		return nil
+	}
+	node, _ := expr.accept(e)
+	node.(map[string]interface{})["line"] = expr.getLine()
+	return node
+}
+
+func (e astJsonEncoder) token(token Token) interface{} {
+	return map[string]interface{}{
+		"type":    tokenTypeNames[token.Type],
+		"lexeme":  token.Lexeme,
+		"literal": e.literal(token.Literal),
+		"line":    token.Line,
+		"column":  token.Column,
+	}
+}
+
+func (e astJsonEncoder) tokens(tokens []Token) []interface{} {
+	nodes := []interface{}{}
+	for _, token := range tokens {
+		nodes = append(nodes, e.token(token))
+	}
+	return nodes
+}
+
+func (e astJsonEncoder) visitBlockStmt(stmt BlockStmt) (interface{}, error) {
+	return map[string]interface{}{"node": "Block", "statements": e.statements(stmt.Statements)}, nil
+}
+
+func (e astJsonEncoder) visitExpressionStmt(stmt ExpressionStmt) (interface{}, error) {
+	return map[string]interface{}{"node": "Expression", "expression": e.expression(stmt.Expression)}, nil
+}
+
+func (e astJsonEncoder) visitPrintStmt(stmt PrintStmt) (interface{}, error) {
+	return map[string]interface{}{"node": "Print", "expression": e.expression(stmt.Print)}, nil
+}
+
+func (e astJsonEncoder) visitVarStmt(stmt VarStmt) (interface{}, error) {
+	return map[string]interface{}{
+		"node":        "Var",
+		"name":        e.token(stmt.Name),
+		"initializer": e.expression(stmt.Initializer),
+	}, nil
+}
+
+func (e astJsonEncoder) visitIfStmt(stmt IfStmt) (interface{}, error) {
+	return map[string]interface{}{
+		"node":       "If",
+		"condition":  e.expression(stmt.Condition),
+		"thenBranch": e.statement(stmt.ThenBranch),
+		"elseBranch": e.statement(stmt.ElseBranch),
+	}, nil
+}
+
+func (e astJsonEncoder) visitWhileStmt(stmt WhileStmt) (interface{}, error) {
+	return map[string]interface{}{
+		"node":      "While",
+		"condition": e.expression(stmt.Condition),
+		"body":      e.statement(stmt.Body),
+	}, nil
+}
+
+func (e astJsonEncoder) visitBreakStmt(stmt BreakStmt) (interface{}, error) {
+	return map[string]interface{}{"node": "Break", "token": e.token(stmt.Token)}, nil
+}
+
+func (e astJsonEncoder) visitContinueStmt(stmt ContinueStmt) (interface{}, error) {
+	return map[string]interface{}{"node": "Continue", "token": e.token(stmt.Token)}, nil
+}
+
+func (e astJsonEncoder) visitFunctionStmt(stmt FunctionStmt) (interface{}, error) {
+	return map[string]interface{}{
+		"node":   "Function",
+		"name":   e.token(stmt.Name),
+		"params": e.tokens(stmt.Params),
+		"body":   e.statements(stmt.Body),
+	}, nil
+}
+
+func (e astJsonEncoder) visitReturnStmt(stmt ReturnStmt) (interface{}, error) {
+	return map[string]interface{}{
+		"node":    "Return",
+		"keyword": e.token(stmt.Keyword),
+		"value":   e.expression(stmt.Value),
+	}, nil
+}
+
+func (e astJsonEncoder) visitBinaryExpr(expr BinaryExpr) (interface{}, error) {
+	return map[string]interface{}{
+		"node":     "Binary",
+		"left":     e.expression(expr.Left),
+		"operator": e.token(expr.Operator),
+		"right":    e.expression(expr.Right),
+	}, nil
+}
+
+func (e astJsonEncoder) visitConditionalExpr(expr ConditionalExpr) (interface{}, error) {
+	return map[string]interface{}{
+		"node":      "Conditional",
+		"condition": e.expression(expr.Condition),
+		"left":      e.expression(expr.Left),
+		"right":     e.expression(expr.Right),
+	}, nil
+}
+
+func (e astJsonEncoder) visitGroupingExpr(expr GroupingExpr) (interface{}, error) {
+	return map[string]interface{}{"node": "Grouping", "expression": e.expression(expr.Expression)}, nil
+}
+
+func (e astJsonEncoder) visitLiteralExpr(expr LiteralExpr) (interface{}, error) {
+	return map[string]interface{}{"node": "Literal", "value": e.literal(expr.Value)}, nil
+}
+
+// literal writes floats with a fraction or an exponent, to tell them from
+// ints.
+func (e astJsonEncoder) literal(value interface{}) interface{} {
+	float, ok := value.(float64)
+	if !ok {
+		return value
+	}
+	text := strconv.FormatFloat(float, 'g', -1, 64)
+	if !strings.ContainsAny(text, ".eEIN") {
+		text += ".0"
+	}
+	return json.Number(text)
+}
+
+func (e astJsonEncoder) visitLogicalExpr(expr LogicalExpr) (interface{}, error) {
+	return map[string]interface{}{
+		"node":     "Logical",
+		"left":     e.expression(expr.Left),
+		"operator": e.token(expr.Operator),
+		"right":    e.expression(expr.Right),
+	}, nil
+}
+
+func (e astJsonEncoder) visitUnaryExpr(expr UnaryExpr) (interface{}, error) {
+	return map[string]interface{}{
+		"node":     "Unary",
+		"operator": e.token(expr.Operator),
+		"right":    e.expression(expr.Right),
+	}, nil
+}
+
+func (e astJsonEncoder) visitVariableExpr(expr VariableExpr) (interface{}, error) {
+	return map[string]interface{}{"node": "Variable", "name": e.token(expr.Name)}, nil
+}
+
+func (e astJsonEncoder) visitAssignExpr(expr AssignExpr) (interface{}, error) {
+	return map[string]interface{}{
+		"node":  "Assign",
+		"name":  e.token(expr.Name),
+		"value": e.expression(expr.Value),
+	}, nil
+}
+
+func (e astJsonEncoder) visitCallExpr(expr CallExpr) (interface{}, error) {
+	return map[string]interface{}{
+		"node":      "Call",
+		"callee":    e.expression(expr.Callee),
+		"paren":     e.token(expr.Paren),
+		"arguments": e.expressions(expr.Arguments),
+	}, nil
+}
+
+func (e astJsonEncoder) visitGetExpr(expr GetExpr) (interface{}, error) {
+	return map[string]interface{}{
+		"node":   "Get",
+		"object": e.expression(expr.Object),
+		"name":   e.token(expr.Name),
+	}, nil
+}
+
+// AstFromJson reads back the statements of a JSON document written by
+// AstToJson, or by tools following the same schema. Errors tell the path of
+// the offending member, as in statements[0].left.operator.
+func AstFromJson(data []byte) ([]Stmt, error) {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("AstFromJson: %w", err)
+	}
+	// decode again keeping numbers as written, to tell ints from floats.
+	numbers := json.NewDecoder(bytes.NewReader(data))
+	numbers.UseNumber()
+	numbers.Decode(&document)
+	decoder := astJsonDecoder{}
+	object, err := decoder.object(document, "document")
+	if err != nil {
+		return nil, err
+	}
+	version, err := decoder.int(object, "", "version")
+	if err != nil {
+		return nil, err
+	}
+	if version < 1 || version > AST_JSON_VERSION {
+		return nil, fmt.Errorf("AstFromJson: unsupported version %d", version)
+	}
+	decoder.version = version
+	return decoder.statementList(object, "", "statements")
+}
+
+type astJsonDecoder struct {
+	version int
+}
+
+func (d astJsonDecoder) errorf(path string, format string, arguments ...interface{}) error {
+	return fmt.Errorf("AstFromJson: %s: %s", path, fmt.Sprintf(format, arguments...))
+}
+
+func memberPath(path string, member string) string {
+	if path == "" {
+		return member
+	}
+	return path + "." + member
+}
+
+func (d astJsonDecoder) object(value interface{}, path string) (map[string]interface{}, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, d.errorf(path, "expected an object")
+	}
+	return object, nil
+}
+
+func (d astJsonDecoder) array(object map[string]interface{}, path string, member string) ([]interface{}, error) {
+	array, ok := object[member].([]interface{})
+	if !ok {
+		return nil, d.errorf(memberPath(path, member), "expected an array")
+	}
+	return array, nil
+}
+
+func (d astJsonDecoder) int(object map[string]interface{}, path string, member string) (int, error) {
+	number, ok := object[member].(json.Number)
+	if !ok {
+		return 0, d.errorf(memberPath(path, member), "expected an integer")
+	}
+	integer, err := strconv.Atoi(number.String())
+	if err != nil {
+		return 0, d.errorf(memberPath(path, member), "expected an integer")
+	}
+	return integer, nil
+}
+
+func (d astJsonDecoder) string(object map[string]interface{}, path string, member string) (string, error) {
+	text, ok := object[member].(string)
+	if !ok {
+		return "", d.errorf(memberPath(path, member), "expected a string")
+	}
+	return text, nil
+}
+
+func (d astJsonDecoder) literal(object map[string]interface{}, path string, member string) (interface{}, error) {
+	switch value := object[member].(type) {
+	case nil, bool, string:
+		return value, nil
+	case json.Number:
+		if d.version > 1 && !strings.ContainsAny(value.String(), ".eE") {
+			integer, err := value.Int64()
+			if err != nil {
+				return nil, d.errorf(memberPath(path, member), "integer out of range")
+			}
+			return integer, nil
+		}
+		float, err := value.Float64()
+		if err != nil {
+			return nil, d.errorf(memberPath(path, member), "number out of range")
+		}
+		return float, nil
+	}
+	return nil, d.errorf(memberPath(path, member), "expected a number, a string, a boolean or null")
+}
+
+func (d astJsonDecoder) token(value interface{}, path string) (Token, error) {
+	token, err := d.object(value, path)
+	if err != nil {
+		return Token{}, err
+	}
+	name, err := d.string(token, path, "type")
+	if err != nil {
+		return Token{}, err
+	}
+	tokenType, ok := tokenTypesByName[name]
+	if !ok {
+		return Token{}, d.errorf(memberPath(path, "type"), "unknown token type %q", name)
+	}
+	lexeme, err := d.string(token, path, "lexeme")
+	if err != nil {
+		return Token{}, err
+	}
+	literal, err := d.literal(token, path, "literal")
+	if err != nil {
+		return Token{}, err
+	}
+	line, err := d.int(token, path, "line")
+	if err != nil {
+		return Token{}, err
+	}
+	result := NewToken(tokenType, lexeme, literal, line)
+	if _, ok := token["column"]; ok {
+		if result.Column, err = d.int(token, path, "column"); err != nil {
+			return Token{}, err
+		}
+	}
+	return result, nil
+}
+
+func (d astJsonDecoder) tokenList(object map[string]interface{}, path string, member string) ([]Token, error) {
+	array, err := d.array(object, path, member)
+	if err != nil {
+		return nil, err
+	}
+	tokens := []Token{}
+	for i, element := range array {
+		token, err := d.token(element, fmt.Sprintf("%s[%d]", memberPath(path, member), i))
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// node returns the member of object at path and the kind of node it is.
+func (d astJsonDecoder) node(value interface{}, path string) (map[string]interface{}, string, error) {
+	object, err := d.object(value, path)
+	if err != nil {
+		return nil, "", err
+	}
+	kind, err := d.string(object, path, "node")
+	if err != nil {
+		return nil, "", err
+	}
+	return object, kind, nil
+}
+
+func (d astJsonDecoder) statementList(object map[string]interface{}, path string, member string) ([]Stmt, error) {
+	array, err := d.array(object, path, member)
+	if err != nil {
+		return nil, err
+	}
+	statements := []Stmt{}
+	for i, element := range array {
+		stmt, err := d.statement(element, fmt.Sprintf("%s[%d]", memberPath(path, member), i))
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, stmt)
+	}
+	return statements, nil
+}
+
+func (d astJsonDecoder) optionalStatement(object map[string]interface{}, path string, member string) (Stmt, error) {
+	if object[member] == nil {
+		return nil, nil
+	}
+	return d.statement(object[member], memberPath(path, member))
+}
+
+func (d astJsonDecoder) statement(value interface{}, path string) (Stmt, error) {
+	object, kind, err := d.node(value, path)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "Block":
+		statements, err := d.statementList(object, path, "statements")
+		if err != nil {
+			return nil, err
+		}
+		return NewBlockStmt(statements), nil
+	case "Expression", "Print":
+		expr, err := d.expression(object["expression"], memberPath(path, "expression"))
+		if err != nil {
+			return nil, err
+		}
+		if kind == "Print" {
+			return NewPrintStmt(expr), nil
+		}
+		return NewExpressionStmt(expr), nil
+	case "Var":
+		name, err := d.token(object["name"], memberPath(path, "name"))
+		if err != nil {
+			return nil, err
+		}
+		initializer, err := d.optionalExpression(object, path, "initializer")
+		if err != nil {
+			return nil, err
+		}
+		return NewVarStmt(name, initializer), nil
+	case "If":
+		condition, err := d.expression(object["condition"], memberPath(path, "condition"))
+		if err != nil {
+			return nil, err
+		}
+		thenBranch, err := d.statement(object["thenBranch"], memberPath(path, "thenBranch"))
+		if err != nil {
+			return nil, err
+		}
+		elseBranch, err := d.optionalStatement(object, path, "elseBranch")
+		if err != nil {
+			return nil, err
+		}
+		return NewIfStmt(condition, thenBranch, elseBranch), nil
+	case "While":
+		condition, err := d.expression(object["condition"], memberPath(path, "condition"))
+		if err != nil {
+			return nil, err
+		}
+		body, err := d.statement(object["body"], memberPath(path, "body"))
+		if err != nil {
+			return nil, err
+		}
+		return NewWhileStmt(condition, body), nil
+	case "Break", "Continue":
+		token, err := d.token(object["token"], memberPath(path, "token"))
+		if err != nil {
+			return nil, err
+		}
+		if kind == "Break" {
+			return NewBreakStmt(token), nil
+		}
+		return NewContinueStmt(token), nil
+	case "Function":
+		name, err := d.token(object["name"], memberPath(path, "name"))
+		if err != nil {
+			return nil, err
+		}
+		params, err := d.tokenList(object, path, "params")
+		if err != nil {
+			return nil, err
+		}
+		body, err := d.statementList(object, path, "body")
+		if err != nil {
+			return nil, err
+		}
+		return NewFunctionStmt(name, params, body), nil
+	case "Return":
+		keyword, err := d.token(object["keyword"], memberPath(path, "keyword"))
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.optionalExpression(object, path, "value")
+		if err != nil {
+			return nil, err
+		}
+		return NewReturnStmt(keyword, value), nil
+	}
+	return nil, d.errorf(memberPath(path, "node"), "unknown statement %q", kind)
+}
+
+func (d astJsonDecoder) optionalExpression(object map[string]interface{}, path string, member string) (Expr, error) {
+	if object[member] == nil {
+		return nil, nil
+	}
+	return d.expression(object[member], memberPath(path, member))
+}
+
+// binary reads the members shared by Binary and Logical expressions.
+func (d astJsonDecoder) binary(object map[string]interface{}, path string) (Expr, Token, Expr, error) {
+	left, err := d.expression(object["left"], memberPath(path, "left"))
+	if err != nil {
+		return nil, Token{}, nil, err
+	}
+	operator, err := d.token(object["operator"], memberPath(path, "operator"))
+	if err != nil {
+		return nil, Token{}, nil, err
+	}
+	right, err := d.expression(object["right"], memberPath(path, "right"))
+	if err != nil {
+		return nil, Token{}, nil, err
+	}
+	return left, operator, right, nil
+}
+
+func (d astJsonDecoder) expression(value interface{}, path string) (Expr, error) {
+	object, kind, err := d.node(value, path)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "Binary", "Logical":
+		left, operator, right, err := d.binary(object, path)
+		if err != nil {
+			return nil, err
+		}
+		if kind == "Logical" {
+			return NewLogicalExpr(left, operator, right), nil
+		}
+		return NewBinaryExpr(left, operator, right), nil
+	case "Conditional":
+		condition, err := d.expression(object["condition"], memberPath(path, "condition"))
+		if err != nil {
+			return nil, err
+		}
+		left, err := d.expression(object["left"], memberPath(path, "left"))
+		if err != nil {
+			return nil, err
+		}
+		right, err := d.expression(object["right"], memberPath(path, "right"))
+		if err != nil {
+			return nil, err
+		}
+		return NewConditionalExpr(condition, left, right), nil
+	case "Grouping":
+		expr, err := d.expression(object["expression"], memberPath(path, "expression"))
+		if err != nil {
+			return nil, err
+		}
+		return NewGroupingExpr(expr), nil
+	case "Literal":
+		literal, err := d.literal(object, path, "value")
+		if err != nil {
+			return nil, err
+		}
+		line, err := d.int(object, path, "line")
+		if err != nil {
+			return nil, err
+		}
+		return NewLiteralExpr(literal, line), nil
+	case "Unary":
+		operator, err := d.token(object["operator"], memberPath(path, "operator"))
+		if err != nil {
+			return nil, err
+		}
+		right, err := d.expression(object["right"], memberPath(path, "right"))
+		if err != nil {
+			return nil, err
+		}
+		return NewUnaryExpr(operator, right), nil
+	case "Variable":
+		name, err := d.token(object["name"], memberPath(path, "name"))
+		if err != nil {
+			return nil, err
+		}
+		return NewVariableExpr(name), nil
+	case "Assign":
+		name, err := d.token(object["name"], memberPath(path, "name"))
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.expression(object["value"], memberPath(path, "value"))
+		if err != nil {
+			return nil, err
+		}
+		return NewAssignExpr(name, value), nil
+	case "Call":
+		callee, err := d.expression(object["callee"], memberPath(path, "callee"))
+		if err != nil {
+			return nil, err
+		}
+		paren, err := d.token(object["paren"], memberPath(path, "paren"))
+		if err != nil {
+			return nil, err
+		}
+		array, err := d.array(object, path, "arguments")
+		if err != nil {
+			return nil, err
+		}
+		arguments := []Expr{}
+		for i, element := range array {
+			argument, err := d.expression(element, fmt.Sprintf("%s[%d]", memberPath(path, "arguments"), i))
+			if err != nil {
+				return nil, err
+			}
+			arguments = append(arguments, argument)
+		}
+		return NewCallExpr(callee, paren, arguments), nil
+	case "Get":
+		target, err := d.expression(object["object"], memberPath(path, "object"))
+		if err != nil {
+			return nil, err
+		}
+		name, err := d.token(object["name"], memberPath(path, "name"))
+		if err != nil {
+			return nil, err
+		}
+		return NewGetExpr(target, name), nil
+	}
+	return nil, d.errorf(memberPath(path, "node"), "unknown expression %q", kind)
+}
@@ -0,0 +1,143 @@
+package glox
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const astJsonTestSource = `var a = 1.5;
+var b;
+fun f(x, y) {
+  if (x > y and !false) return x; else return nil;
+}
+{
+  b = a ? "yes" : -a;
+  print (a + 2) * 3 % 4;
+}
+for (var i = 0; i < 3; i = i + 1) {
+  if (i == 1) continue;
+  if (i == 2 or i ~/ 2 ** 3 != 1) break;
+}
+print "a is ${a}!";
+print math.sqrt(f(a, 2));
+`
+
+func parseSourceWithColumns(t *testing.T, source string) []Stmt {
+	errorReporter := NewConsoleErrorReporter()
+	parser := NewParser(NewScannerWithColumns(source, errorReporter).ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	assert.False(t, errorReporter.HasError())
+	return statements
+}
+
+func TestAstJsonRoundTrip(t *testing.T) {
+	statements := parseSourceWithColumns(t, astJsonTestSource)
+	data, err := AstToJson(statements)
+	assert.NoError(t, err)
+	decoded, err := AstFromJson(data)
+	assert.NoError(t, err)
+	assert.Equal(t, statements, decoded)
+}
+
+func TestAstJsonRoundTripCorpus(t *testing.T) {
+	files := []string{}
+	err := filepath.WalkDir("testdata", func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, ".glox") {
+			files = append(files, path)
+		}
+		return err
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, files)
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			source, err := os.ReadFile(file)
+			assert.NoError(t, err)
+			errorReporter := NewConsoleErrorReporterWithWriter(io.Discard)
+			parser := NewParser(NewScanner(string(source), errorReporter).ScanTokens(), errorReporter)
+			statements := parser.Parse()
+			if errorReporter.HasError() {
+				return
+			}
+			data, err := AstToJson(statements)
+			assert.NoError(t, err)
+			decoded, err := AstFromJson(data)
+			assert.NoError(t, err)
+			assert.Equal(t, statements, decoded)
+		})
+	}
+}
+
+func TestAstToJson(t *testing.T) {
+	data, err := AstToJson(parseSourceWithColumns(t, "print -x;\n"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+  "version": 2,
+  "statements": [
+    {
+      "node": "Print",
+      "line": 1,
+      "expression": {
+        "node": "Unary",
+        "line": 1,
+        "operator": {"type": "MINUS", "lexeme": "-", "literal": null, "line": 1, "column": 7},
+        "right": {
+          "node": "Variable",
+          "line": 1,
+          "name": {"type": "IDENTIFIER", "lexeme": "x", "literal": "x", "line": 1, "column": 8}
+        }
+      }
+    }
+  ]
+}`, string(data))
+}
+
+func TestAstFromJsonVersion1(t *testing.T) {
+	statements, err := AstFromJson([]byte(`{"version": 1, "statements": [{"node": "Print", "line": 1, "expression": {"node": "Literal", "value": 2, "line": 1}}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []Stmt{NewPrintStmt(NewLiteralExpr(2.0, 1))}, statements)
+}
+
+func TestAstFromJsonErrors(t *testing.T) {
+	token := `{"type": "IDENTIFIER", "lexeme": "x", "literal": "x", "line": 1}`
+	tests := []struct {
+		name     string
+		document string
+		err      string
+	}{
+		{"syntax", `{`, "AstFromJson: unexpected end of JSON input"},
+		{"not an object", `[]`, "AstFromJson: document: expected an object"},
+		{"version", `{"version": 3, "statements": []}`, "AstFromJson: unsupported version 3"},
+		{"statements", `{"version": 1}`, "AstFromJson: statements: expected an array"},
+		{"unknown statement", `{"version": 1, "statements": [{"node": "Class"}]}`,
+			`AstFromJson: statements[0].node: unknown statement "Class"`},
+		{"expression as statement", `{"version": 1, "statements": [{"node": "Variable", "name": ` + token + `}]}`,
+			`AstFromJson: statements[0].node: unknown statement "Variable"`},
+		{"missing expression", `{"version": 1, "statements": [{"node": "Print"}]}`,
+			"AstFromJson: statements[0].expression: expected an object"},
+		{"token type", `{"version": 1, "statements": [{"node": "Var", "name": {"type": "NAME"}}]}`,
+			`AstFromJson: statements[0].name.type: unknown token type "NAME"`},
+		{"token line", `{"version": 1, "statements": [{"node": "Break", "token": {"type": "BREAK", "lexeme": "break", "literal": null, "line": 1.5}}]}`,
+			"AstFromJson: statements[0].token.line: expected an integer"},
+		{"param", `{"version": 1, "statements": [{"node": "Function", "name": ` + token + `, "params": [` + token + `, 1], "body": []}]}`,
+			"AstFromJson: statements[0].params[1]: expected an object"},
+		{"literal", `{"version": 1, "statements": [{"node": "Print", "expression": {"node": "Literal", "value": [], "line": 1}}]}`,
+			"AstFromJson: statements[0].expression.value: expected a number, a string, a boolean or null"},
+		{"argument", `{"version": 1, "statements": [{"node": "Expression", "expression": {"node": "Call", "callee": {"node": "Variable", "name": ` + token + `}, "paren": ` + token + `, "arguments": [{"node": "Print"}]}}]}`,
+			`AstFromJson: statements[0].expression.arguments[0].node: unknown expression "Print"`},
+		{"integer", `{"version": 2, "statements": [{"node": "Print", "expression": {"node": "Literal", "value": 9223372036854775808, "line": 1}}]}`,
+			"AstFromJson: statements[0].expression.value: integer out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := AstFromJson([]byte(tt.document))
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
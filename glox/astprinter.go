@@ -130,6 +130,10 @@ func (p AstPrinter) visitCallExpr(expr CallExpr) (interface{}, error) {
 	return p.parenthesize("call", args...), nil
 }
 
+func (p AstPrinter) visitGetExpr(expr GetExpr) (interface{}, error) {
+	return p.parenthesize("."+expr.Name.Lexeme, expr.Object), nil
+}
+
 func (p AstPrinter) parenthesize(name string, exprs ...interface{}) string {
 	var builder string
 	builder += "(" + name
@@ -15,7 +15,7 @@ func (c ClockCallable) getArity() int {
 }
 
 func (c ClockCallable) call(inter *Interpreter, arguments []interface{}) (interface{}, error) {
-	return time.Now().Unix(), nil
+	return float64(time.Now().UnixNano()) / 1e9, nil
 }
 
 func NewClockCallable() ClockCallable {
@@ -31,17 +31,63 @@ func (c FunctionCallable) getArity() int {
 	return len(c.declaration.Params)
 }
 
+// call runs the function, and then in turn each function it returns a call
+// to, so that tail calls do not grow the Go stack. The profiler still sees
+// them nested in their callers, as they are written, but for a tail call to
+// a function already among them, which goes back to its frame: tail
+// recursion does not grow the profile either.
 func (c FunctionCallable) call(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	profiled := []Token{}
+	if inter.profiler != nil {
+		defer func() {
+			for range profiled {
+				inter.profiler.exitFunction()
+			}
+		}()
+	}
+	for {
+		if inter.profiler != nil {
+			profiled = c.exitTailCallers(inter.profiler, profiled)
+			inter.profiler.enterFunction(c.declaration.Name.Lexeme, c.declaration.Name.Line)
+			profiled = append(profiled, c.declaration.Name)
+		}
+		value, result := c.execute(inter, arguments)
+		returned, ok := result.(ReturnResult)
+		if !ok {
+			return value, result
+		}
+		if returned.tailCall == nil {
+			return returned.value, nil
+		}
+		c, arguments = returned.tailCall.function, returned.tailCall.arguments
+	}
+}
+
+// exitTailCallers exits the profiled frames of a chain of tail calls back to
+// and including the frame of the function, if it is among them.
+func (c FunctionCallable) exitTailCallers(profiler *Profiler, profiled []Token) []Token {
+	for i, name := range profiled {
+		if name == c.declaration.Name {
+			for range profiled[i:] {
+				profiler.exitFunction()
+			}
+			return profiled[:i]
+		}
+	}
+	return profiled
+}
+
+// execute runs the body of the function once.
+func (c FunctionCallable) execute(inter *Interpreter, arguments []interface{}) (interface{}, error) {
 	env := NewEnvironmentWithEnclosing(c.closure)
 	for i, arg := range c.declaration.Params {
 		env.Define(arg.Lexeme, arguments[i])
 	}
-	value, result := inter.executeBlock(c.declaration.Body, &env)
-	switch result := result.(type) {
-	case ReturnResult:
-		return result.value, nil
+	if inter.debugger != nil {
+		inter.debugger.enterFunction(c.declaration.Name.Lexeme, c.declaration.Name.Line, &env)
+		defer inter.debugger.exitFunction()
 	}
-	return value, result
+	return inter.executeBlock(c.declaration.Body, &env)
 }
 
 func NewFunctionCallable(declaration FunctionStmt, closure *Environment) FunctionCallable {
@@ -50,3 +96,25 @@ func NewFunctionCallable(declaration FunctionStmt, closure *Environment) Functio
 		closure:     closure,
 	}
 }
+
+type NativeCallable struct {
+	name     string
+	arity    int
+	function func(inter *Interpreter, arguments []interface{}) (interface{}, error)
+}
+
+func (c NativeCallable) getArity() int {
+	return c.arity
+}
+
+func (c NativeCallable) call(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return c.function(inter, arguments)
+}
+
+func NewNativeCallable(name string, arity int, function func(inter *Interpreter, arguments []interface{}) (interface{}, error)) NativeCallable {
+	return NativeCallable{
+		name:     name,
+		arity:    arity,
+		function: function,
+	}
+}
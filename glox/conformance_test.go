@@ -0,0 +1,169 @@
+package glox
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * Conformance tests are .glox programs under testdata/conformance that
+ * carry their expectations in comments:
+ *
+ *   print 1 + 2; // expect: 3
+ *   print nil + 1; // expect runtime error: <message>
+ *   print (1; // expect parse error at line 3
+ *
+ * "expect:" lines must match stdout line by line, in order. A runtime error
+ * is expected on the line of its comment with exactly the given message, and
+ * stops the program. A parse error expectation, which also covers the static
+ * errors found by the Resolver, may name its message after a colon:
+ * "expect parse error at line 3: Expected expression.". Every error reported
+ * must be expected, and the exit status is checked as glox would return it:
+ * 65 after parse errors, 70 after a runtime error and 0 otherwise.
+ */
+
+const CONFORMANCE_DIR = "testdata/conformance"
+
+const CONFORMANCE_EXIT_OK = 0
+const CONFORMANCE_EXIT_PARSE_ERROR = 65
+const CONFORMANCE_EXIT_RUNTIME_ERROR = 70
+
+var expectOutputPattern = regexp.MustCompile(`// expect: ?(.*)$`)
+var expectRuntimeErrorPattern = regexp.MustCompile(`// expect runtime error: (.+)$`)
+var expectParseErrorPattern = regexp.MustCompile(`// expect parse error at line (\d+)(?:: (.+))?$`)
+var reportedErrorPattern = regexp.MustCompile(`^\[line (\d+)\] Error(?:\[\w+\])?: (.*)$`)
+
+type expectedError struct {
+	line    int
+	message string
+	parse   bool
+}
+
+type conformanceExpectations struct {
+	output   []string
+	errors   []expectedError
+	exitCode int
+}
+
+func parseExpectations(source string) conformanceExpectations {
+	expectations := conformanceExpectations{output: []string{}, errors: []expectedError{}}
+	for i, line := range strings.Split(source, "\n") {
+		if match := expectRuntimeErrorPattern.FindStringSubmatch(line); match != nil {
+			expectations.errors = append(expectations.errors, expectedError{line: i + 1, message: match[1]})
+			expectations.exitCode = CONFORMANCE_EXIT_RUNTIME_ERROR
+		} else if match := expectParseErrorPattern.FindStringSubmatch(line); match != nil {
+			errorLine, _ := strconv.Atoi(match[1])
+			expectations.errors = append(expectations.errors, expectedError{line: errorLine, message: match[2], parse: true})
+			expectations.exitCode = CONFORMANCE_EXIT_PARSE_ERROR
+		} else if match := expectOutputPattern.FindStringSubmatch(line); match != nil {
+			expectations.output = append(expectations.output, match[1])
+		}
+	}
+	return expectations
+}
+
+// runConformanceProgram runs source the way glox runs a script file, with
+// the Optimizer if asked to, and returns its stdout lines, reported error
+// lines and exit status.
+func runConformanceProgram(source string, optimize bool) ([]string, []string, int) {
+	var stdout, stderr bytes.Buffer
+	errorReporter := NewConsoleErrorReporterWithWriter(&stderr)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), &stdout, &stderr)
+	interpreter.SetReproducible(true)
+	scanner := NewScanner(source, errorReporter)
+	parser := NewParser(scanner.ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	exitCode := CONFORMANCE_EXIT_OK
+	if errorReporter.HasError() {
+		exitCode = CONFORMANCE_EXIT_PARSE_ERROR
+	} else {
+		resolver := NewResolver(&interpreter)
+		resolver.ResolveStatements(statements)
+		if errorReporter.HasError() {
+			exitCode = CONFORMANCE_EXIT_PARSE_ERROR
+		} else {
+			if optimize {
+				statements = NewOptimizer().Optimize(statements)
+			}
+			interpreter.Interpret(statements)
+			if errorReporter.HasError() {
+				exitCode = CONFORMANCE_EXIT_RUNTIME_ERROR
+			}
+		}
+	}
+	return splitLines(stdout.String()), splitLines(stderr.String()), exitCode
+}
+
+func splitLines(text string) []string {
+	if len(text) == 0 {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+func (e expectedError) matches(reported string) bool {
+	match := reportedErrorPattern.FindStringSubmatch(reported)
+	if match == nil || match[1] != strconv.Itoa(e.line) {
+		return false
+	}
+	if e.parse {
+		return strings.Contains(match[2], e.message)
+	}
+	return match[2] == e.message
+}
+
+func TestConformance(t *testing.T) {
+	testConformance(t, false)
+}
+
+// The optimized programs must behave the same, errors included.
+func TestConformanceOptimized(t *testing.T) {
+	testConformance(t, true)
+}
+
+func testConformance(t *testing.T, optimize bool) {
+	paths := []string{}
+	err := filepath.WalkDir(CONFORMANCE_DIR, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && strings.HasSuffix(path, ".glox") {
+			paths = append(paths, path)
+		}
+		return err
+	})
+	if !assert.NoError(t, err) || !assert.NotEmpty(t, paths) {
+		return
+	}
+	for _, path := range paths {
+		name, _ := filepath.Rel(CONFORMANCE_DIR, path)
+		t.Run(name, func(t *testing.T) {
+			source, err := os.ReadFile(path)
+			if !assert.NoError(t, err) {
+				return
+			}
+			expectations := parseExpectations(string(source))
+			output, errors, exitCode := runConformanceProgram(string(source), optimize)
+			assert.Equal(t, expectations.output, output, "stdout")
+			unexpected := append([]string{}, errors...)
+			for _, expected := range expectations.errors {
+				found := false
+				for i, reported := range unexpected {
+					if expected.matches(reported) {
+						unexpected = append(unexpected[:i], unexpected[i+1:]...)
+						found = true
+						break
+					}
+				}
+				assert.True(t, found, "expected error at line %d: %q, got %q", expected.line, expected.message, errors)
+			}
+			assert.Empty(t, unexpected, "unexpected errors")
+			assert.Equal(t, expectations.exitCode, exitCode, "exit status")
+		})
+	}
+}
@@ -0,0 +1,530 @@
+package glox
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	COVERAGE_FORMAT_TEXT = "text"
+	COVERAGE_FORMAT_HTML = "html"
+	COVERAGE_FORMAT_LCOV = "lcov"
+)
+
+// Kinds of branch points.
+const (
+	COVERAGE_BRANCH_IF          = "if"
+	COVERAGE_BRANCH_WHILE       = "while"
+	COVERAGE_BRANCH_AND         = "and"
+	COVERAGE_BRANCH_OR          = "or"
+	COVERAGE_BRANCH_CONDITIONAL = "?:"
+)
+
+// coverageBranchNames names the two branches of each kind of branch point.
+// The condition of if, while and ?: is either true or false, while and and
+// or either short-circuit or evaluate their right operand.
+var coverageBranchNames = map[string][2]string{
+	COVERAGE_BRANCH_IF:          {"true", "false"},
+	COVERAGE_BRANCH_WHILE:       {"true", "false"},
+	COVERAGE_BRANCH_AND:         {"short-circuit", "right"},
+	COVERAGE_BRANCH_OR:          {"short-circuit", "right"},
+	COVERAGE_BRANCH_CONDITIONAL: {"true", "false"},
+}
+
+// CoverageBranch is a branch point: how many times each of its two branches
+// was taken. Branch points of the same kind on the same line are counted
+// together.
+type CoverageBranch struct {
+	Line  int
+	Kind  string
+	Taken [2]int
+}
+
+type coverageBranchKey struct {
+	line int
+	kind string
+}
+
+// CoverageSummary counts the lines and branches of one or more files, and
+// how many of them ran.
+type CoverageSummary struct {
+	Lines       int
+	LinesHit    int
+	Branches    int
+	BranchesHit int
+}
+
+// LinePercent returns the percentage of lines that ran, 100 when there are
+// none.
+func (s CoverageSummary) LinePercent() float64 {
+	return coveragePercent(s.LinesHit, s.Lines)
+}
+
+// BranchPercent returns the percentage of branches taken, 100 when there are
+// none.
+func (s CoverageSummary) BranchPercent() float64 {
+	return coveragePercent(s.BranchesHit, s.Branches)
+}
+
+func coveragePercent(hit int, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(hit) / float64(total)
+}
+
+/*
+ * CoverageFile records which lines and branches of a file ran. The lines with
+ * statements and the branch points are found ahead of time, from the
+ * statements of the file, so that those that never ran are reported too.
+ */
+type CoverageFile struct {
+	Filename string
+	source   string
+	// lines counts the statements executed on each line.
+	lines    map[int]int
+	branches map[coverageBranchKey]*CoverageBranch
+}
+
+/*
+ * Coverage collects the coverage of the files run by one or more
+ * Interpreters, and reports it as text, as an HTML page or in the LCOV
+ * format read by CI services and editors.
+ */
+type Coverage struct {
+	files []*CoverageFile
+}
+
+func NewCoverage() *Coverage {
+	return &Coverage{files: []*CoverageFile{}}
+}
+
+// AddFile starts the coverage of a file with its source and the statements
+// parsed from it.
+func (c *Coverage) AddFile(filename string, source string, statements []Stmt) *CoverageFile {
+	file := &CoverageFile{
+		Filename: filename,
+		source:   source,
+		lines:    map[int]int{},
+		branches: map[coverageBranchKey]*CoverageBranch{},
+	}
+	collector := &coverageCollector{file: file}
+	collector.statements(statements)
+	c.files = append(c.files, file)
+	return file
+}
+
+// Files returns the files covered, in the order they were added.
+func (c *Coverage) Files() []*CoverageFile {
+	return c.files
+}
+
+// SetCoverage makes the interpreter record the lines and branches it runs in
+// file.
+func (inter *Interpreter) SetCoverage(file *CoverageFile) {
+	inter.coverage = file
+}
+
+func (f *CoverageFile) hitLine(line int) {
+	f.lines[line]++
+}
+
+func (f *CoverageFile) branch(line int, kind string) *CoverageBranch {
+	key := coverageBranchKey{line: line, kind: kind}
+	branch, ok := f.branches[key]
+	if !ok {
+		branch = &CoverageBranch{Line: line, Kind: kind}
+		f.branches[key] = branch
+	}
+	return branch
+}
+
+// hitBranch records that a branch point took its first branch, 0, or its
+// second one, 1.
+func (f *CoverageFile) hitBranch(line int, kind string, branch int) {
+	f.branch(line, kind).Taken[branch]++
+}
+
+// Lines returns the number of statements executed on each line with
+// statements.
+func (f *CoverageFile) Lines() map[int]int {
+	lines := map[int]int{}
+	for line, count := range f.lines {
+		lines[line] = count
+	}
+	return lines
+}
+
+// Branches returns the branch points of the file, by line and kind.
+func (f *CoverageFile) Branches() []CoverageBranch {
+	branches := []CoverageBranch{}
+	for _, branch := range f.branches {
+		branches = append(branches, *branch)
+	}
+	sort.Slice(branches, func(i, j int) bool {
+		if branches[i].Line != branches[j].Line {
+			return branches[i].Line < branches[j].Line
+		}
+		return branches[i].Kind < branches[j].Kind
+	})
+	return branches
+}
+
+// Summary counts the lines and branches of the file.
+func (f *CoverageFile) Summary() CoverageSummary {
+	summary := CoverageSummary{}
+	for _, count := range f.lines {
+		summary.Lines++
+		if count > 0 {
+			summary.LinesHit++
+		}
+	}
+	for _, branch := range f.branches {
+		for _, taken := range branch.Taken {
+			summary.Branches++
+			if taken > 0 {
+				summary.BranchesHit++
+			}
+		}
+	}
+	return summary
+}
+
+// Summary counts the lines and branches of all the files.
+func (c *Coverage) Summary() CoverageSummary {
+	total := CoverageSummary{}
+	for _, file := range c.files {
+		summary := file.Summary()
+		total.Lines += summary.Lines
+		total.LinesHit += summary.LinesHit
+		total.Branches += summary.Branches
+		total.BranchesHit += summary.BranchesHit
+	}
+	return total
+}
+
+func formatCoverageSummary(summary CoverageSummary) string {
+	return fmt.Sprintf("lines %.1f%% (%d/%d), branches %.1f%% (%d/%d)",
+		summary.LinePercent(), summary.LinesHit, summary.Lines,
+		summary.BranchPercent(), summary.BranchesHit, summary.Branches)
+}
+
+// WriteReport writes the coverage of each file, with the lines that never
+// ran and the branches never taken, and the total.
+func (c *Coverage) WriteReport(writer io.Writer) {
+	for _, file := range c.files {
+		fmt.Fprintf(writer, "%s: %s\n", file.Filename, formatCoverageSummary(file.Summary()))
+		missed := []int{}
+		for line, count := range file.lines {
+			if count == 0 {
+				missed = append(missed, line)
+			}
+		}
+		sort.Ints(missed)
+		if len(missed) > 0 {
+			fmt.Fprintf(writer, "    lines not run: %s\n", formatLineRanges(missed))
+		}
+		for _, branch := range file.Branches() {
+			names := coverageBranchNames[branch.Kind]
+			for i, taken := range branch.Taken {
+				if taken == 0 {
+					fmt.Fprintf(writer, "    branch not taken: line %d %s %s\n", branch.Line, branch.Kind, names[i])
+				}
+			}
+		}
+	}
+	fmt.Fprintf(writer, "total: %s\n", formatCoverageSummary(c.Summary()))
+}
+
+// formatLineRanges joins sorted lines, collapsing consecutive ones into
+// ranges, as in "3, 5-7".
+func formatLineRanges(lines []int) string {
+	ranges := []string{}
+	for i := 0; i < len(lines); {
+		j := i
+		for j+1 < len(lines) && lines[j+1] == lines[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(lines[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", lines[i], lines[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, ", ")
+}
+
+// WriteLcov writes the coverage in the LCOV tracefile format. Branch points
+// on the same line are numbered as blocks in order of kind.
+func (c *Coverage) WriteLcov(writer io.Writer) {
+	for _, file := range c.files {
+		fmt.Fprintln(writer, "TN:")
+		fmt.Fprintf(writer, "SF:%s\n", file.Filename)
+		block, previousLine := 0, 0
+		for _, branch := range file.Branches() {
+			if branch.Line != previousLine {
+				block, previousLine = 0, branch.Line
+			}
+			for i, taken := range branch.Taken {
+				count := strconv.Itoa(taken)
+				if branch.Taken[0]+branch.Taken[1] == 0 {
+					// the branch point itself never ran.
+					count = "-"
+				}
+				fmt.Fprintf(writer, "BRDA:%d,%d,%d,%s\n", branch.Line, block, i, count)
+			}
+			block++
+		}
+		summary := file.Summary()
+		fmt.Fprintf(writer, "BRF:%d\n", summary.Branches)
+		fmt.Fprintf(writer, "BRH:%d\n", summary.BranchesHit)
+		for _, line := range sortedCoverageLines(file.lines) {
+			fmt.Fprintf(writer, "DA:%d,%d\n", line, file.lines[line])
+		}
+		fmt.Fprintf(writer, "LF:%d\n", summary.Lines)
+		fmt.Fprintf(writer, "LH:%d\n", summary.LinesHit)
+		fmt.Fprintln(writer, "end_of_record")
+	}
+}
+
+func sortedCoverageLines(lines map[int]int) []int {
+	sorted := []int{}
+	for line := range lines {
+		sorted = append(sorted, line)
+	}
+	sort.Ints(sorted)
+	return sorted
+}
+
+const coverageHtmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>glox coverage</title>
+<style>
+body { font-family: sans-serif; }
+table.summary td, table.summary th { padding: 2px 12px; text-align: left; }
+table.source { border-collapse: collapse; font-family: monospace; }
+table.source td { padding: 0 8px; white-space: pre; }
+td.number, td.count { color: #888; text-align: right; }
+tr.hit td.code { background: #ddffdd; }
+tr.missed td.code { background: #ffdddd; }
+tr.partial td.code { background: #ffffcc; }
+</style>
+</head>
+<body>
+`
+
+// WriteHtml writes the coverage as a standalone HTML page: a summary of the
+// files, and their source with the lines that ran in green, those that did
+// not in red, and those with branches not taken in yellow.
+func (c *Coverage) WriteHtml(writer io.Writer) {
+	fmt.Fprint(writer, coverageHtmlHeader)
+	fmt.Fprintln(writer, "<h1>Coverage</h1>")
+	fmt.Fprintln(writer, `<table class="summary">`)
+	fmt.Fprintln(writer, "<tr><th>File</th><th>Lines</th><th>Branches</th></tr>")
+	for i, file := range c.files {
+		summary := file.Summary()
+		fmt.Fprintf(writer, "<tr><td><a href=\"#file%d\">%s</a></td><td>%.1f%% (%d/%d)</td><td>%.1f%% (%d/%d)</td></tr>\n",
+			i, html.EscapeString(file.Filename),
+			summary.LinePercent(), summary.LinesHit, summary.Lines,
+			summary.BranchPercent(), summary.BranchesHit, summary.Branches)
+	}
+	total := c.Summary()
+	fmt.Fprintf(writer, "<tr><th>Total</th><th>%.1f%% (%d/%d)</th><th>%.1f%% (%d/%d)</th></tr>\n",
+		total.LinePercent(), total.LinesHit, total.Lines,
+		total.BranchPercent(), total.BranchesHit, total.Branches)
+	fmt.Fprintln(writer, "</table>")
+
+	for i, file := range c.files {
+		partial := map[int][]string{}
+		for _, branch := range file.Branches() {
+			names := coverageBranchNames[branch.Kind]
+			for j, taken := range branch.Taken {
+				if taken == 0 {
+					partial[branch.Line] = append(partial[branch.Line], branch.Kind+" "+names[j])
+				}
+			}
+		}
+		fmt.Fprintf(writer, "<h2 id=\"file%d\">%s</h2>\n", i, html.EscapeString(file.Filename))
+		fmt.Fprintln(writer, `<table class="source">`)
+		for j, text := range strings.Split(strings.TrimSuffix(file.source, "\n"), "\n") {
+			line := j + 1
+			class, count, title := "", "", ""
+			if hits, ok := file.lines[line]; ok {
+				count = strconv.Itoa(hits)
+				class = "hit"
+				if hits == 0 {
+					class = "missed"
+				} else if len(partial[line]) > 0 {
+					class = "partial"
+					title = " title=\"not taken: " + html.EscapeString(strings.Join(partial[line], ", ")) + "\""
+				}
+			}
+			fmt.Fprintf(writer, "<tr class=\"%s\"%s><td class=\"number\">%d</td><td class=\"count\">%s</td><td class=\"code\">%s</td></tr>\n",
+				class, title, line, count, html.EscapeString(text))
+		}
+		fmt.Fprintln(writer, "</table>")
+	}
+	fmt.Fprintln(writer, "</body>")
+	fmt.Fprintln(writer, "</html>")
+}
+
+// coverageCollector finds the lines with statements and the branch points of
+// a file, mirroring where the Interpreter records them.
+type coverageCollector struct {
+	file *CoverageFile
+}
+
+func (c *coverageCollector) statements(statements []Stmt) {
+	for _, stmt := range statements {
+		c.statement(stmt)
+	}
+}
+
+func (c *coverageCollector) statement(stmt Stmt) {
+	if stmt == nil {
+		return
+	}
+	if _, ok := stmt.(BlockStmt); !ok {
+		c.file.lines[stmt.getLine()] += 0
+	}
+	stmt.accept(c)
+}
+
+func (c *coverageCollector) expression(expr Expr) {
+	if expr != nil {
+		expr.accept(c)
+	}
+}
+
+func (c *coverageCollector) visitBlockStmt(stmt BlockStmt) (interface{}, error) {
+	c.statements(stmt.Statements)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitExpressionStmt(stmt ExpressionStmt) (interface{}, error) {
+	c.expression(stmt.Expression)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitPrintStmt(stmt PrintStmt) (interface{}, error) {
+	c.expression(stmt.Print)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitVarStmt(stmt VarStmt) (interface{}, error) {
+	c.expression(stmt.Initializer)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitIfStmt(stmt IfStmt) (interface{}, error) {
+	c.file.branch(stmt.getLine(), COVERAGE_BRANCH_IF)
+	c.expression(stmt.Condition)
+	c.statement(stmt.ThenBranch)
+	c.statement(stmt.ElseBranch)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitWhileStmt(stmt WhileStmt) (interface{}, error) {
+	c.file.branch(stmt.getLine(), COVERAGE_BRANCH_WHILE)
+	c.expression(stmt.Condition)
+	c.statement(stmt.Body)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitBreakStmt(stmt BreakStmt) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *coverageCollector) visitContinueStmt(stmt ContinueStmt) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *coverageCollector) visitFunctionStmt(stmt FunctionStmt) (interface{}, error) {
+	c.statements(stmt.Body)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitReturnStmt(stmt ReturnStmt) (interface{}, error) {
+	c.expression(stmt.Value)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitBinaryExpr(expr BinaryExpr) (interface{}, error) {
+	c.expression(expr.Left)
+	c.expression(expr.Right)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitConditionalExpr(expr ConditionalExpr) (interface{}, error) {
+	c.file.branch(expr.getLine(), COVERAGE_BRANCH_CONDITIONAL)
+	c.expression(expr.Condition)
+	c.expression(expr.Left)
+	c.expression(expr.Right)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitGroupingExpr(expr GroupingExpr) (interface{}, error) {
+	c.expression(expr.Expression)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitLiteralExpr(expr LiteralExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *coverageCollector) visitLogicalExpr(expr LogicalExpr) (interface{}, error) {
+	c.file.branch(expr.getLine(), logicalBranchKind(expr))
+	c.expression(expr.Left)
+	c.expression(expr.Right)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitUnaryExpr(expr UnaryExpr) (interface{}, error) {
+	c.expression(expr.Right)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitVariableExpr(expr VariableExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *coverageCollector) visitAssignExpr(expr AssignExpr) (interface{}, error) {
+	c.expression(expr.Value)
+	return nil, nil
+}
+
+func (c *coverageCollector) visitCallExpr(expr CallExpr) (interface{}, error) {
+	c.expression(expr.Callee)
+	for _, argument := range expr.Arguments {
+		c.expression(argument)
+	}
+	return nil, nil
+}
+
+func (c *coverageCollector) visitGetExpr(expr GetExpr) (interface{}, error) {
+	c.expression(expr.Object)
+	return nil, nil
+}
+
+func logicalBranchKind(expr LogicalExpr) string {
+	if expr.Operator.Type == TOKEN_OR {
+		return COVERAGE_BRANCH_OR
+	}
+	return COVERAGE_BRANCH_AND
+}
+
+// coverageBranch returns the branch taken by a branch point: 0 when taken
+// is true, 1 otherwise.
+func coverageBranch(taken bool) int {
+	if taken {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,139 @@
+package glox
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// coverSource runs source with its coverage recorded.
+func coverSource(source string) *Coverage {
+	var stdout, stderr bytes.Buffer
+	errorReporter := NewConsoleErrorReporterWithWriter(&stderr)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), &stdout, &stderr)
+	parser := NewParser(NewScanner(source, errorReporter).ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	resolver := NewResolver(&interpreter)
+	resolver.ResolveStatements(statements)
+	coverage := NewCoverage()
+	interpreter.SetCoverage(coverage.AddFile("test.glox", source, statements))
+	interpreter.Interpret(statements)
+	return coverage
+}
+
+const coverageTestSource = `fun sign(n) {
+  if (n > 0) {
+    return 1;
+  } else if (n < 0) {
+    return -1;
+  }
+  return 0;
+}
+var i = 0;
+while (i < 3) i = i + 1;
+print sign(5) > 0 and sign(-1) < 0 ? "ok" : "ko";
+print false or true;
+`
+
+func TestCoverageLines(t *testing.T) {
+	file := coverSource(coverageTestSource).Files()[0]
+	assert.Equal(t, map[int]int{1: 1, 2: 2, 3: 1, 4: 1, 5: 1, 7: 0, 9: 1, 10: 4, 11: 1, 12: 1}, file.Lines())
+}
+
+func TestCoverageBranches(t *testing.T) {
+	file := coverSource(coverageTestSource).Files()[0]
+	assert.Equal(t, []CoverageBranch{
+		{Line: 2, Kind: COVERAGE_BRANCH_IF, Taken: [2]int{1, 1}},
+		{Line: 4, Kind: COVERAGE_BRANCH_IF, Taken: [2]int{1, 0}},
+		{Line: 10, Kind: COVERAGE_BRANCH_WHILE, Taken: [2]int{3, 1}},
+		{Line: 11, Kind: COVERAGE_BRANCH_CONDITIONAL, Taken: [2]int{1, 0}},
+		{Line: 11, Kind: COVERAGE_BRANCH_AND, Taken: [2]int{0, 1}},
+		{Line: 12, Kind: COVERAGE_BRANCH_OR, Taken: [2]int{0, 1}},
+	}, file.Branches())
+	assert.Equal(t, CoverageSummary{Lines: 10, LinesHit: 9, Branches: 12, BranchesHit: 8}, file.Summary())
+}
+
+func TestCoverageReport(t *testing.T) {
+	var report bytes.Buffer
+	coverSource(coverageTestSource).WriteReport(&report)
+	assert.Equal(t, `test.glox: lines 90.0% (9/10), branches 66.7% (8/12)
+    lines not run: 7
+    branch not taken: line 4 if false
+    branch not taken: line 11 ?: false
+    branch not taken: line 11 and short-circuit
+    branch not taken: line 12 or short-circuit
+total: lines 90.0% (9/10), branches 66.7% (8/12)
+`, report.String())
+}
+
+func TestCoverageLcov(t *testing.T) {
+	var lcov bytes.Buffer
+	coverSource("fun f(a) {\n  return a ? 1 : 2;\n}\nif (true) print 1;\n").WriteLcov(&lcov)
+	assert.Equal(t, `TN:
+SF:test.glox
+BRDA:2,0,0,-
+BRDA:2,0,1,-
+BRDA:4,0,0,1
+BRDA:4,0,1,0
+BRF:4
+BRH:1
+DA:1,1
+DA:2,0
+DA:4,2
+LF:3
+LH:2
+end_of_record
+`, lcov.String())
+}
+
+func TestCoverageHtml(t *testing.T) {
+	var page bytes.Buffer
+	coverSource("var a = 1 < 2;\nif (a) print \"<a>\"; else print 2;\nfun f() {\n  print 3;\n}\n").WriteHtml(&page)
+	html := page.String()
+	assert.Contains(t, html, `<tr><td><a href="#file0">test.glox</a></td><td>75.0% (3/4)</td><td>50.0% (1/2)</td></tr>`)
+	assert.Contains(t, html, `<tr class="hit"><td class="number">1</td><td class="count">1</td><td class="code">var a = 1 &lt; 2;</td></tr>`)
+	assert.Contains(t, html, `<tr class="partial" title="not taken: if false"><td class="number">2</td>`)
+	assert.Contains(t, html, `print &#34;&lt;a&gt;&#34;;`)
+	assert.Contains(t, html, `<tr class="missed"><td class="number">4</td><td class="count">0</td>`)
+	assert.Contains(t, html, `<tr class=""><td class="number">5</td><td class="count"></td><td class="code">}</td></tr>`)
+}
+
+func TestCoverageSummaryPercent(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary CoverageSummary
+		lines   float64
+		branch  float64
+	}{
+		{"empty", CoverageSummary{}, 100, 100},
+		{"half", CoverageSummary{Lines: 4, LinesHit: 2, Branches: 4, BranchesHit: 1}, 50, 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.lines, tt.summary.LinePercent())
+			assert.Equal(t, tt.branch, tt.summary.BranchPercent())
+		})
+	}
+}
+
+func TestFormatLineRanges(t *testing.T) {
+	assert.Equal(t, "1", formatLineRanges([]int{1}))
+	assert.Equal(t, "1-3, 5, 7-8", formatLineRanges([]int{1, 2, 3, 5, 7, 8}))
+}
+
+func TestTestRunnerCoverage(t *testing.T) {
+	dir := t.TempDir()
+	source := "fun abs(n) {\n  return n < 0 ? -n : n;\n}\nfun test_abs() {\n  assertEqual(abs(2), 2);\n}\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "abs_test.glox"), []byte(source), 0644))
+	runner := NewTestRunner(&bytes.Buffer{})
+	coverage := NewCoverage()
+	runner.SetCoverage(coverage)
+	results, err := runner.RunDir(dir)
+	assert.NoError(t, err)
+	assert.True(t, AllTestsPassed(results))
+	assert.Equal(t, CoverageSummary{Lines: 4, LinesHit: 4, Branches: 2, BranchesHit: 1}, coverage.Summary())
+}
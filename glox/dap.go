@@ -0,0 +1,413 @@
+package glox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// glox programs run in a single thread.
+const DAP_THREAD_ID = 1
+
+const DAP_EXIT_OK = 0
+const DAP_EXIT_ERROR = 65
+const DAP_EXIT_RUNTIME_ERROR = 70
+
+var errDapNotPaused = errors.New("the program is not paused")
+
+// dapResumeActions maps the requests that resume a paused program to the
+// action they resume it with.
+var dapResumeActions = map[string]int{
+	"continue": DEBUG_CONTINUE,
+	"next":     DEBUG_STEP_OVER,
+	"stepIn":   DEBUG_STEP_INTO,
+	"stepOut":  DEBUG_STEP_OUT,
+}
+
+/*
+ * DapServer is a Debug Adapter Protocol server, so that editors can debug
+ * glox programs through the Debugger. It serves a single launch request
+ * over a pair of streams, normally stdin and stdout, and runs the program on
+ * its own goroutine once the client is done configuring breakpoints. The
+ * output of the program is sent to the client as output events.
+ *
+ * Inspection requests are only answered while the program is paused: they
+ * are run by the program's goroutine, which waits in Stopped for them or for
+ * the request that resumes it.
+ */
+type DapServer struct {
+	reader      *bufio.Reader
+	writer      io.Writer
+	writeMutex  sync.Mutex
+	seq         int
+	debugger    *Debugger
+	program     string
+	source      string
+	stopOnEntry bool
+	// variables holds the scopes sent to the client while paused, indexed by
+	// their variablesReference minus one.
+	variables [][]DebugVariable
+
+	mutex       sync.Mutex
+	paused      bool
+	running     bool
+	actions     chan int
+	inspections chan func()
+	done        chan struct{}
+}
+
+type dapRequest struct {
+	Seq       int             `json:"seq"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// dapOutput sends what the program writes to the client.
+type dapOutput struct {
+	server   *DapServer
+	category string
+}
+
+func (o dapOutput) Write(p []byte) (int, error) {
+	o.server.event("output", map[string]interface{}{"category": o.category, "output": string(p)})
+	return len(p), nil
+}
+
+func NewDapServer(in io.Reader, out io.Writer) *DapServer {
+	server := &DapServer{
+		reader:      bufio.NewReader(in),
+		writer:      out,
+		variables:   [][]DebugVariable{},
+		actions:     make(chan int),
+		inspections: make(chan func()),
+		done:        make(chan struct{}),
+	}
+	server.debugger = NewDebugger(server)
+	return server
+}
+
+// Serve handles requests until the client disconnects, stopping the program
+// if it is still running.
+func (s *DapServer) Serve() error {
+	defer s.stop()
+	for {
+		content, err := readFramedMessage(s.reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		request := dapRequest{}
+		if err := json.Unmarshal(content, &request); err != nil {
+			return err
+		}
+		body, err := s.handle(request)
+		s.respond(request, body, err)
+		if action, ok := dapResumeActions[request.Command]; ok && err == nil {
+			// after responding, so that the response comes before the
+			// program stops again.
+			s.resume(action)
+		}
+		switch request.Command {
+		case "initialize":
+			s.event("initialized", nil)
+		case "configurationDone":
+			s.mutex.Lock()
+			s.running = true
+			s.mutex.Unlock()
+			go s.run()
+		case "disconnect":
+			return nil
+		}
+	}
+}
+
+func (s *DapServer) send(message map[string]interface{}) {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+	s.seq++
+	message["seq"] = s.seq
+	content, _ := json.Marshal(message)
+	writeFramedMessage(s.writer, content)
+}
+
+func (s *DapServer) respond(request dapRequest, body interface{}, err error) {
+	response := map[string]interface{}{
+		"type":        "response",
+		"request_seq": request.Seq,
+		"command":     request.Command,
+		"success":     err == nil,
+	}
+	if err != nil {
+		response["message"] = err.Error()
+	} else if body != nil {
+		response["body"] = body
+	}
+	s.send(response)
+}
+
+func (s *DapServer) event(name string, body interface{}) {
+	event := map[string]interface{}{"type": "event", "event": name}
+	if body != nil {
+		event["body"] = body
+	}
+	s.send(event)
+}
+
+func (s *DapServer) handle(request dapRequest) (interface{}, error) {
+	switch request.Command {
+	case "initialize":
+		return map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}, nil
+	case "launch":
+		arguments := struct {
+			Program     string `json:"program"`
+			StopOnEntry bool   `json:"stopOnEntry"`
+		}{}
+		if err := json.Unmarshal(request.Arguments, &arguments); err != nil {
+			return nil, err
+		}
+		source, err := os.ReadFile(arguments.Program)
+		if err != nil {
+			return nil, err
+		}
+		s.program, s.source, s.stopOnEntry = arguments.Program, string(source), arguments.StopOnEntry
+		return nil, nil
+	case "setBreakpoints":
+		arguments := struct {
+			Breakpoints []struct {
+				Line int `json:"line"`
+			} `json:"breakpoints"`
+		}{}
+		if err := json.Unmarshal(request.Arguments, &arguments); err != nil {
+			return nil, err
+		}
+		s.debugger.ClearBreakpoints()
+		breakpoints := []interface{}{}
+		for _, breakpoint := range arguments.Breakpoints {
+			s.debugger.SetBreakpoint(breakpoint.Line)
+			breakpoints = append(breakpoints, map[string]interface{}{"verified": true, "line": breakpoint.Line})
+		}
+		return map[string]interface{}{"breakpoints": breakpoints}, nil
+	case "configurationDone", "disconnect":
+		return nil, nil
+	case "terminate":
+		s.stop()
+		return nil, nil
+	case "threads":
+		return map[string]interface{}{
+			"threads": []interface{}{map[string]interface{}{"id": DAP_THREAD_ID, "name": "main"}},
+		}, nil
+	case "stackTrace":
+		return s.stackTrace()
+	case "scopes":
+		return s.scopes(request)
+	case "variables":
+		return s.variablesOf(request)
+	case "evaluate":
+		return s.evaluate(request)
+	case "continue", "next", "stepIn", "stepOut":
+		s.mutex.Lock()
+		paused := s.paused
+		s.mutex.Unlock()
+		if !paused {
+			return nil, errDapNotPaused
+		}
+		if request.Command == "continue" {
+			return map[string]interface{}{"allThreadsContinued": true}, nil
+		}
+		return nil, nil
+	case "pause":
+		s.debugger.Pause()
+		return nil, nil
+	}
+	return nil, errors.New("unsupported request: " + request.Command)
+}
+
+// run runs the launched program, on its own goroutine, and tells the client
+// when it ends.
+func (s *DapServer) run() {
+	defer close(s.done)
+	output := dapOutput{server: s, category: "stdout"}
+	errorOutput := dapOutput{server: s, category: "stderr"}
+	errorReporter := NewConsoleErrorReporterWithWriter(errorOutput)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), output, errorOutput)
+	interpreter.SetDebugger(s.debugger)
+	s.debugger.SetStopOnEntry(s.stopOnEntry)
+
+	exitCode := DAP_EXIT_OK
+	parser := NewParser(NewScanner(s.source, errorReporter).ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	if !errorReporter.HasError() {
+		resolver := NewResolver(&interpreter)
+		resolver.ResolveStatements(statements)
+	}
+	if errorReporter.HasError() {
+		exitCode = DAP_EXIT_ERROR
+	} else if _, err := interpreter.Interpret(statements); err != nil && !errors.Is(err, ErrDebuggerQuit) {
+		exitCode = DAP_EXIT_RUNTIME_ERROR
+	}
+	s.mutex.Lock()
+	s.running = false
+	s.mutex.Unlock()
+	s.event("exited", map[string]interface{}{"exitCode": exitCode})
+	s.event("terminated", nil)
+}
+
+// stop ends the program, if it is running, and waits for it.
+func (s *DapServer) stop() {
+	s.mutex.Lock()
+	running := s.running
+	s.paused = false
+	s.mutex.Unlock()
+	if !running {
+		return
+	}
+	s.debugger.Quit()
+	select {
+	case s.actions <- DEBUG_QUIT:
+		<-s.done
+	case <-s.done:
+	}
+}
+
+// Stopped tells the client the program paused, and then runs inspection
+// requests until the client resumes it.
+func (s *DapServer) Stopped(debugger *Debugger, reason string) int {
+	s.mutex.Lock()
+	s.paused = true
+	s.mutex.Unlock()
+	s.event("stopped", map[string]interface{}{
+		"reason":            reason,
+		"threadId":          DAP_THREAD_ID,
+		"allThreadsStopped": true,
+	})
+	for {
+		select {
+		case inspection := <-s.inspections:
+			inspection()
+		case action := <-s.actions:
+			return action
+		}
+	}
+}
+
+// resume lets the paused program run again.
+func (s *DapServer) resume(action int) {
+	s.mutex.Lock()
+	s.paused = false
+	s.mutex.Unlock()
+	s.variables = [][]DebugVariable{}
+	s.actions <- action
+}
+
+// inspect runs inspection on the goroutine of the paused program.
+func (s *DapServer) inspect(inspection func()) error {
+	s.mutex.Lock()
+	paused := s.paused
+	s.mutex.Unlock()
+	if !paused {
+		return errDapNotPaused
+	}
+	done := make(chan struct{})
+	s.inspections <- func() {
+		inspection()
+		close(done)
+	}
+	<-done
+	return nil
+}
+
+func (s *DapServer) stackTrace() (interface{}, error) {
+	frames := []DebugFrame{}
+	if err := s.inspect(func() { frames = s.debugger.Frames() }); err != nil {
+		return nil, err
+	}
+	stackFrames := []interface{}{}
+	for i, frame := range frames {
+		stackFrames = append(stackFrames, map[string]interface{}{
+			"id":     i,
+			"name":   frame.Name,
+			"line":   frame.Line,
+			"column": 1,
+			"source": map[string]interface{}{"name": filepath.Base(s.program), "path": s.program},
+		})
+	}
+	return map[string]interface{}{"stackFrames": stackFrames, "totalFrames": len(stackFrames)}, nil
+}
+
+func (s *DapServer) scopes(request dapRequest) (interface{}, error) {
+	arguments := struct {
+		FrameId int `json:"frameId"`
+	}{}
+	if err := json.Unmarshal(request.Arguments, &arguments); err != nil {
+		return nil, err
+	}
+	var scopes []DebugScope
+	var err error
+	if inspectErr := s.inspect(func() { scopes, err = s.debugger.Scopes(arguments.FrameId) }); inspectErr != nil {
+		return nil, inspectErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := []interface{}{}
+	for _, scope := range scopes {
+		s.variables = append(s.variables, scope.Variables)
+		result = append(result, map[string]interface{}{
+			"name":               scope.Name,
+			"variablesReference": len(s.variables),
+			"expensive":          false,
+		})
+	}
+	return map[string]interface{}{"scopes": result}, nil
+}
+
+func (s *DapServer) variablesOf(request dapRequest) (interface{}, error) {
+	arguments := struct {
+		VariablesReference int `json:"variablesReference"`
+	}{}
+	if err := json.Unmarshal(request.Arguments, &arguments); err != nil {
+		return nil, err
+	}
+	if arguments.VariablesReference < 1 || arguments.VariablesReference > len(s.variables) {
+		return nil, errors.New("unknown variablesReference")
+	}
+	variables := []interface{}{}
+	for _, variable := range s.variables[arguments.VariablesReference-1] {
+		variables = append(variables, map[string]interface{}{
+			"name":               variable.Name,
+			"value":              variable.Value,
+			"variablesReference": 0,
+		})
+	}
+	return map[string]interface{}{"variables": variables}, nil
+}
+
+func (s *DapServer) evaluate(request dapRequest) (interface{}, error) {
+	arguments := struct {
+		Expression string `json:"expression"`
+		FrameId    int    `json:"frameId"`
+	}{}
+	if err := json.Unmarshal(request.Arguments, &arguments); err != nil {
+		return nil, err
+	}
+	var value string
+	var err error
+	if inspectErr := s.inspect(func() { value, err = s.debugger.Evaluate(arguments.FrameId, arguments.Expression) }); inspectErr != nil {
+		return nil, inspectErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"result": value, "variablesReference": 0}, nil
+}
@@ -0,0 +1,183 @@
+package glox
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const DEBUG_CONSOLE_PROMPT = "(glox) "
+const DEBUG_CONSOLE_LIST_LINES = 5
+
+const debugConsoleHelp = `Commands:
+  c, continue          run until the next breakpoint
+  s, step              step into the next statement
+  n, next              step over calls to the next statement
+  o, out               step out of the current function
+  b, break LINE        set a breakpoint
+  d, delete [LINE]     delete a breakpoint, or all of them
+  breakpoints          list breakpoints
+  bt, stack            show the call stack
+  f, frame N           select the frame to inspect
+  v, vars              show the environments of the selected frame
+  p, print EXPR        evaluate an expression in the selected frame
+  l, list              show the source around the current line
+  q, quit              stop the program
+  h, help              show this help
+An empty line repeats the last command.`
+
+/*
+ * DebugConsole is the terminal user interface of the debugger. Each time the
+ * program pauses it shows where, and reads commands until one of them
+ * resumes the program. Frames are numbered from the innermost, which is
+ * selected when the program pauses.
+ */
+type DebugConsole struct {
+	reader      *bufio.Reader
+	writer      io.Writer
+	lines       []string
+	frame       int
+	lastCommand string
+}
+
+func NewDebugConsole(reader *bufio.Reader, writer io.Writer, source string) *DebugConsole {
+	return &DebugConsole{
+		reader: reader,
+		writer: writer,
+		lines:  strings.Split(source, "\n"),
+	}
+}
+
+func (c *DebugConsole) Stopped(debugger *Debugger, reason string) int {
+	c.frame = 0
+	frame := debugger.Frames()[0]
+	fmt.Fprintf(c.writer, "Stopped at %s, line %d in %s\n", reason, frame.Line, frame.Name)
+	c.listLine(frame.Line, true)
+	for {
+		fmt.Fprint(c.writer, DEBUG_CONSOLE_PROMPT)
+		line, err := c.reader.ReadString('\n')
+		if err != nil && len(line) == 0 {
+			fmt.Fprintln(c.writer)
+			return DEBUG_QUIT
+		}
+		command := strings.TrimSpace(line)
+		if command == "" {
+			command = c.lastCommand
+		}
+		c.lastCommand = command
+		if action, resume := c.execute(debugger, command); resume {
+			return action
+		}
+	}
+}
+
+// execute runs a command, and returns the action to resume the program
+// with if it is one that does.
+func (c *DebugConsole) execute(debugger *Debugger, command string) (int, bool) {
+	name, argument := command, ""
+	if i := strings.IndexAny(command, " \t"); i >= 0 {
+		name, argument = command[:i], strings.TrimSpace(command[i:])
+	}
+	switch name {
+	case "":
+	case "c", "continue":
+		return DEBUG_CONTINUE, true
+	case "s", "step":
+		return DEBUG_STEP_INTO, true
+	case "n", "next":
+		return DEBUG_STEP_OVER, true
+	case "o", "out":
+		return DEBUG_STEP_OUT, true
+	case "q", "quit":
+		return DEBUG_QUIT, true
+	case "b", "break":
+		if line, ok := c.lineArgument(argument); ok {
+			debugger.SetBreakpoint(line)
+			fmt.Fprintf(c.writer, "Breakpoint at line %d\n", line)
+		}
+	case "d", "delete":
+		if argument == "" {
+			debugger.ClearBreakpoints()
+			fmt.Fprintln(c.writer, "Deleted all breakpoints")
+		} else if line, ok := c.lineArgument(argument); ok {
+			debugger.ClearBreakpoint(line)
+			fmt.Fprintf(c.writer, "Deleted breakpoint at line %d\n", line)
+		}
+	case "breakpoints":
+		lines := debugger.Breakpoints()
+		if len(lines) == 0 {
+			fmt.Fprintln(c.writer, "No breakpoints")
+		}
+		for _, line := range lines {
+			fmt.Fprintf(c.writer, "Breakpoint at line %d\n", line)
+		}
+	case "bt", "stack":
+		for i, frame := range debugger.Frames() {
+			marker := " "
+			if i == c.frame {
+				marker = "*"
+			}
+			fmt.Fprintf(c.writer, "%s#%d %s at line %d\n", marker, i, frame.Name, frame.Line)
+		}
+	case "f", "frame":
+		frame, err := strconv.Atoi(argument)
+		if err != nil || frame < 0 || frame >= len(debugger.Frames()) {
+			fmt.Fprintf(c.writer, "No frame %q\n", argument)
+			break
+		}
+		c.frame = frame
+		selected := debugger.Frames()[frame]
+		fmt.Fprintf(c.writer, "#%d %s at line %d\n", frame, selected.Name, selected.Line)
+	case "v", "vars":
+		scopes, err := debugger.Scopes(c.frame)
+		if err != nil {
+			fmt.Fprintln(c.writer, err)
+			break
+		}
+		for _, scope := range scopes {
+			fmt.Fprintf(c.writer, "%s:\n", scope.Name)
+			for _, variable := range scope.Variables {
+				fmt.Fprintf(c.writer, "  %s = %s\n", variable.Name, variable.Value)
+			}
+		}
+	case "p", "print":
+		value, err := debugger.Evaluate(c.frame, argument)
+		if err != nil {
+			fmt.Fprintln(c.writer, err)
+			break
+		}
+		fmt.Fprintln(c.writer, value)
+	case "l", "list":
+		current := debugger.Frames()[c.frame].Line
+		for line := current - DEBUG_CONSOLE_LIST_LINES; line <= current+DEBUG_CONSOLE_LIST_LINES; line++ {
+			c.listLine(line, line == current)
+		}
+	case "h", "help":
+		fmt.Fprintln(c.writer, debugConsoleHelp)
+	default:
+		fmt.Fprintf(c.writer, "Unknown command %q, try help\n", name)
+	}
+	return DEBUG_CONTINUE, false
+}
+
+func (c *DebugConsole) lineArgument(argument string) (int, bool) {
+	line, err := strconv.Atoi(argument)
+	if err != nil || line < 1 {
+		fmt.Fprintf(c.writer, "Invalid line %q\n", argument)
+		return 0, false
+	}
+	return line, true
+}
+
+func (c *DebugConsole) listLine(line int, current bool) {
+	if line < 1 || line > len(c.lines) {
+		return
+	}
+	marker := " "
+	if current {
+		marker = ">"
+	}
+	fmt.Fprintf(c.writer, "%s%4d | %s\n", marker, line, c.lines[line-1])
+}
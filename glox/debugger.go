@@ -0,0 +1,316 @@
+package glox
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Actions a DebugHandler resumes a paused program with.
+const (
+	DEBUG_CONTINUE = iota
+	DEBUG_STEP_INTO
+	DEBUG_STEP_OVER
+	DEBUG_STEP_OUT
+	DEBUG_QUIT
+)
+
+// Reasons a program pauses for.
+const (
+	DEBUG_REASON_ENTRY      = "entry"
+	DEBUG_REASON_BREAKPOINT = "breakpoint"
+	DEBUG_REASON_STEP       = "step"
+	DEBUG_REASON_PAUSE      = "pause"
+)
+
+const DEBUG_SCRIPT_FRAME = "<script>"
+
+// ErrDebuggerQuit stops a program when its debugger quits.
+var ErrDebuggerQuit = errors.New("debugger: quit")
+
+// DebugHandler is the user interface of a Debugger. Stopped is called on the
+// interpreter's goroutine each time the program pauses, and returns how to
+// resume it. While it runs, the handler can inspect the paused program with
+// the Frames, Scopes and Evaluate methods of the debugger.
+type DebugHandler interface {
+	Stopped(debugger *Debugger, reason string) int
+}
+
+// DebugFrame is a function call in progress. Line is the line of the
+// statement the frame is executing.
+type DebugFrame struct {
+	Name        string
+	Line        int
+	environment *Environment
+}
+
+// DebugScope is one Environment of the chain a frame sees, from the
+// innermost block to the globals.
+type DebugScope struct {
+	Name      string
+	Variables []DebugVariable
+}
+
+type DebugVariable struct {
+	Name  string
+	Value string
+}
+
+/*
+ * Debugger pauses an Interpreter before statements on breakpoint lines, or
+ * after stepping: into the next statement, over the calls of the current
+ * one, or out of the current function. Pausing hands control to its
+ * DebugHandler, which inspects the call stack and the environments of the
+ * paused program and evaluates expressions in any of its frames.
+ *
+ * A breakpoint is hit when execution reaches its line coming from another
+ * line, or when a function call starts there, so that a line holding several
+ * statements pauses once.
+ */
+type Debugger struct {
+	handler     DebugHandler
+	interpreter *Interpreter
+	frames      []*DebugFrame
+	// mode is the last action the program resumed with, and stepDepth the
+	// number of frames when it did.
+	mode         int
+	stepDepth    int
+	stopOnEntry  bool
+	previousLine int
+	evaluating   bool
+
+	// mutex guards the fields that hosts may change while the program runs.
+	mutex          sync.Mutex
+	breakpoints    map[int]bool
+	pauseRequested bool
+	quitRequested  bool
+}
+
+func NewDebugger(handler DebugHandler) *Debugger {
+	return &Debugger{
+		handler:     handler,
+		frames:      []*DebugFrame{},
+		mode:        DEBUG_CONTINUE,
+		breakpoints: map[int]bool{},
+	}
+}
+
+// SetDebugger attaches a debugger to the interpreter, which from then on
+// pauses wherever the debugger asks it to.
+func (inter *Interpreter) SetDebugger(debugger *Debugger) {
+	inter.debugger = debugger
+	debugger.interpreter = inter
+	debugger.frames = []*DebugFrame{{Name: DEBUG_SCRIPT_FRAME, environment: inter.globals}}
+}
+
+// SetStopOnEntry makes the program pause before its first statement.
+func (d *Debugger) SetStopOnEntry(stopOnEntry bool) {
+	d.stopOnEntry = stopOnEntry
+}
+
+func (d *Debugger) SetBreakpoint(line int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.breakpoints[line] = true
+}
+
+func (d *Debugger) ClearBreakpoint(line int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	delete(d.breakpoints, line)
+}
+
+func (d *Debugger) ClearBreakpoints() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.breakpoints = map[int]bool{}
+}
+
+// Breakpoints returns the breakpoint lines in order.
+func (d *Debugger) Breakpoints() []int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	lines := []int{}
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Pause asks the running program to pause before its next statement. It
+// can be called from any goroutine.
+func (d *Debugger) Pause() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.pauseRequested = true
+}
+
+// Quit asks the running program to stop before its next statement. It can
+// be called from any goroutine.
+func (d *Debugger) Quit() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.quitRequested = true
+}
+
+// beforeStatement runs before each statement, and pauses the program when
+// it should stop there.
+func (d *Debugger) beforeStatement(stmt Stmt) error {
+	if _, ok := stmt.(BlockStmt); ok || d.evaluating {
+		return nil
+	}
+	line := stmt.getLine()
+	depth := len(d.frames)
+	d.frames[depth-1].Line = line
+
+	d.mutex.Lock()
+	quit := d.quitRequested
+	pause := d.pauseRequested
+	breakpoint := d.breakpoints[line] && line != d.previousLine
+	d.pauseRequested = false
+	d.mutex.Unlock()
+	d.previousLine = line
+	if quit {
+		return ErrDebuggerQuit
+	}
+
+	reason := ""
+	switch {
+	case d.stopOnEntry:
+		d.stopOnEntry = false
+		reason = DEBUG_REASON_ENTRY
+	case pause:
+		reason = DEBUG_REASON_PAUSE
+	case breakpoint:
+		reason = DEBUG_REASON_BREAKPOINT
+	case d.mode == DEBUG_STEP_INTO,
+		d.mode == DEBUG_STEP_OVER && depth <= d.stepDepth,
+		d.mode == DEBUG_STEP_OUT && depth < d.stepDepth:
+		reason = DEBUG_REASON_STEP
+	}
+	if reason == "" {
+		return nil
+	}
+	d.mode = d.handler.Stopped(d, reason)
+	d.stepDepth = depth
+	if d.mode == DEBUG_QUIT {
+		return ErrDebuggerQuit
+	}
+	return nil
+}
+
+// enterFunction pushes the frame of a function call, whose body runs in
+// environment.
+func (d *Debugger) enterFunction(name string, line int, environment *Environment) {
+	d.frames[len(d.frames)-1].environment = d.interpreter.environment
+	d.frames = append(d.frames, &DebugFrame{Name: name, Line: line, environment: environment})
+	d.previousLine = 0
+}
+
+func (d *Debugger) exitFunction() {
+	d.frames = d.frames[:len(d.frames)-1]
+	d.previousLine = 0
+}
+
+// Frames returns the call stack of the paused program, innermost first.
+func (d *Debugger) Frames() []DebugFrame {
+	frames := []DebugFrame{}
+	for i := len(d.frames) - 1; i >= 0; i-- {
+		frames = append(frames, *d.frames[i])
+	}
+	return frames
+}
+
+// frameEnvironment returns the environment frame, counted from the
+// innermost, is executing in.
+func (d *Debugger) frameEnvironment(frame int) (*Environment, error) {
+	if frame < 0 || frame >= len(d.frames) {
+		return nil, errors.New("debugger: no such frame")
+	}
+	if frame == 0 {
+		return d.interpreter.environment, nil
+	}
+	return d.frames[len(d.frames)-1-frame].environment, nil
+}
+
+// Scopes returns the environment chain of a frame, counted from the
+// innermost. Natives are left out of the globals.
+func (d *Debugger) Scopes(frame int) ([]DebugScope, error) {
+	environment, err := d.frameEnvironment(frame)
+	if err != nil {
+		return nil, err
+	}
+	scopes := []DebugScope{}
+	for ; environment != nil; environment = environment.enclosing {
+		scope := DebugScope{Name: "Locals", Variables: []DebugVariable{}}
+		if environment.enclosing == nil {
+			scope.Name = "Globals"
+		} else if len(scopes) > 0 {
+			scope.Name = "Enclosing"
+		}
+		names := []string{}
+		for name, value := range environment.values {
+			switch value.(type) {
+			case NativeCallable, ClockCallable, *NativeModule:
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			scope.Variables = append(scope.Variables, DebugVariable{Name: name, Value: quoteValue(environment.values[name])})
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// Evaluate runs source, an expression or statements, in the environment of
+// a frame of the paused program and returns the value of its last
+// expression. It does not pause on breakpoints.
+func (d *Debugger) Evaluate(frame int, source string) (string, error) {
+	environment, err := d.frameEnvironment(frame)
+	if err != nil {
+		return "", err
+	}
+	source = strings.TrimSpace(source)
+	if !strings.HasSuffix(source, ";") && !strings.HasSuffix(source, "}") {
+		source += ";"
+	}
+	var errorOutput bytes.Buffer
+	errorReporter := NewConsoleErrorReporterWithWriter(&errorOutput)
+	parser := NewParser(NewScanner(source, errorReporter).ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	if errorReporter.HasError() {
+		return "", errors.New(strings.TrimRight(errorOutput.String(), "\n"))
+	}
+
+	inter := d.interpreter
+	previousReporter, previousEnvironment, previousValue := inter.errorReporter, inter.environment, inter.lastValue
+	inter.errorReporter, inter.environment = errorReporter, environment
+	d.evaluating = true
+	defer func() {
+		inter.errorReporter, inter.environment, inter.lastValue = previousReporter, previousEnvironment, previousValue
+		d.evaluating = false
+	}()
+	resolver := newResolverInEnvironment(inter, environment)
+	resolver.ResolveStatements(statements)
+	if errorReporter.HasError() {
+		return "", errors.New(strings.TrimRight(errorOutput.String(), "\n"))
+	}
+	var value interface{}
+	for _, stmt := range statements {
+		result, err := inter.execute(stmt)
+		if err != nil {
+			return "", err
+		}
+		value = nil
+		if _, ok := stmt.(ExpressionStmt); ok {
+			value = result
+		}
+	}
+	return quoteValue(value), nil
+}
@@ -0,0 +1,392 @@
+package glox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const debugTestSource = `fun fib(n) {
+  if (n < 2) return n;
+  var a = fib(n - 1);
+  return a + fib(n - 2);
+}
+var result = fib(3);
+print result;
+`
+
+// scriptedDebugHandler resumes with the given actions in turn, recording
+// each stop as "reason name:line depth", and runs inspect on each.
+type scriptedDebugHandler struct {
+	actions []int
+	stops   []string
+	inspect func(debugger *Debugger)
+}
+
+func (h *scriptedDebugHandler) Stopped(debugger *Debugger, reason string) int {
+	frames := debugger.Frames()
+	h.stops = append(h.stops, fmt.Sprintf("%s %s:%d %d", reason, frames[0].Name, frames[0].Line, len(frames)))
+	if h.inspect != nil {
+		h.inspect(debugger)
+	}
+	if len(h.actions) == 0 {
+		return DEBUG_CONTINUE
+	}
+	action := h.actions[0]
+	h.actions = h.actions[1:]
+	return action
+}
+
+func debugSource(source string, handler DebugHandler, setup func(debugger *Debugger)) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	errorReporter := NewConsoleErrorReporterWithWriter(&stderr)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), &stdout, &stderr)
+	debugger := NewDebugger(handler)
+	interpreter.SetDebugger(debugger)
+	setup(debugger)
+	parser := NewParser(NewScanner(source, errorReporter).ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	resolver := NewResolver(&interpreter)
+	resolver.ResolveStatements(statements)
+	_, err := interpreter.Interpret(statements)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestDebuggerStepping(t *testing.T) {
+	testCases := []struct {
+		name        string
+		breakpoints []int
+		entry       bool
+		actions     []int
+		expected    []string
+	}{
+		{"no stops", []int{}, false, []int{}, []string{}},
+		{"entry", []int{}, true, []int{}, []string{"entry <script>:1 1"}},
+		{"breakpoint in recursion", []int{2}, false, []int{}, []string{
+			"breakpoint fib:2 2", "breakpoint fib:2 3", "breakpoint fib:2 4", "breakpoint fib:2 4", "breakpoint fib:2 3",
+		}},
+		{"step into", []int{}, true, []int{DEBUG_STEP_INTO, DEBUG_STEP_INTO, DEBUG_STEP_INTO, DEBUG_QUIT}, []string{
+			"entry <script>:1 1", "step <script>:6 1", "step fib:2 2", "step fib:3 2",
+		}},
+		{"step over", []int{6}, false, []int{DEBUG_STEP_OVER, DEBUG_STEP_OVER}, []string{
+			"breakpoint <script>:6 1", "step <script>:7 1",
+		}},
+		{"breakpoint while stepping over", []int{2}, false, []int{DEBUG_STEP_OVER, DEBUG_STEP_OVER, DEBUG_QUIT}, []string{
+			"breakpoint fib:2 2", "step fib:3 2", "breakpoint fib:2 3",
+		}},
+		{"step out", []int{}, true, []int{DEBUG_STEP_INTO, DEBUG_STEP_INTO, DEBUG_STEP_INTO, DEBUG_STEP_INTO, DEBUG_STEP_INTO, DEBUG_STEP_OUT, DEBUG_STEP_OUT, DEBUG_QUIT}, []string{
+			"entry <script>:1 1", "step <script>:6 1", "step fib:2 2", "step fib:3 2", "step fib:2 3", "step fib:3 3",
+			"step fib:4 2", "step <script>:7 1",
+		}},
+	}
+	for _, testCase := range testCases {
+		handler := &scriptedDebugHandler{actions: testCase.actions, stops: []string{}}
+		_, stderr, _ := debugSource(debugTestSource, handler, func(debugger *Debugger) {
+			debugger.SetStopOnEntry(testCase.entry)
+			for _, line := range testCase.breakpoints {
+				debugger.SetBreakpoint(line)
+			}
+		})
+		assert.Equal(t, testCase.expected, handler.stops, testCase.name)
+		assert.Empty(t, stderr, testCase.name)
+	}
+}
+
+func TestDebuggerQuit(t *testing.T) {
+	handler := &scriptedDebugHandler{actions: []int{DEBUG_QUIT}}
+	stdout, stderr, err := debugSource("print 1;\nprint 2;\nprint 3;\n", handler, func(debugger *Debugger) {
+		debugger.SetBreakpoint(2)
+	})
+	assert.ErrorIs(t, err, ErrDebuggerQuit)
+	assert.Equal(t, "1\n", stdout)
+	assert.Empty(t, stderr)
+}
+
+func TestDebuggerInspection(t *testing.T) {
+	source := `var greeting = "hi";
+fun counter(start) {
+  var count = start;
+  fun increment() {
+    count = count + 1;
+    return count;
+  }
+  return increment;
+}
+var next = counter(10);
+{
+  var local = next();
+  print local;
+}
+`
+	handler := &scriptedDebugHandler{}
+	handler.inspect = func(debugger *Debugger) {
+		frames := debugger.Frames()
+		assert.Equal(t, []string{"increment", "<script>"}, []string{frames[0].Name, frames[1].Name})
+		assert.Equal(t, 12, frames[1].Line)
+
+		scopes, err := debugger.Scopes(0)
+		assert.NoError(t, err)
+		assert.Equal(t, []DebugScope{
+			{Name: "Locals", Variables: []DebugVariable{}},
+			{Name: "Enclosing", Variables: []DebugVariable{{"count", "11"}, {"increment", "<fn increment>"}, {"start", "10"}}},
+			{Name: "Globals", Variables: []DebugVariable{{"counter", "<fn counter>"}, {"greeting", "\"hi\""}, {"next", "<fn increment>"}}},
+		}, scopes)
+		scopes, err = debugger.Scopes(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "Locals", scopes[0].Name)
+		assert.Len(t, scopes, 2)
+
+		value, err := debugger.Evaluate(0, "count * 2")
+		assert.NoError(t, err)
+		assert.Equal(t, "22", value)
+		value, err = debugger.Evaluate(1, "greeting + \"!\"")
+		assert.NoError(t, err)
+		assert.Equal(t, "\"hi!\"", value)
+		_, err = debugger.Evaluate(1, "count")
+		assert.EqualError(t, err, "undefined variable: count")
+		_, err = debugger.Evaluate(0, "count +")
+		assert.EqualError(t, err, "[line 1] Error[Parser]: ParseError: Expected expression.")
+		_, err = debugger.Evaluate(2, "count")
+		assert.EqualError(t, err, "debugger: no such frame")
+		// evaluation can change the program's state.
+		_, err = debugger.Evaluate(0, "count = 41")
+		assert.NoError(t, err)
+	}
+	stdout, stderr, err := debugSource(source, handler, func(debugger *Debugger) {
+		debugger.SetBreakpoint(6)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"breakpoint increment:6 2"}, handler.stops)
+	assert.Equal(t, "41\n", stdout)
+	assert.Empty(t, stderr)
+}
+
+func TestDebugConsole(t *testing.T) {
+	commands := "help\nb 2\nbreakpoints\nc\nbt\nv\np n + 1\nf 1\np n\nf 9\nd\nn\n\nl\nzz\nb x\nc\n"
+	var output bytes.Buffer
+	console := NewDebugConsole(bufio.NewReader(strings.NewReader(commands)), &output, debugTestSource)
+	stdout, _, err := debugSource(debugTestSource, console, func(debugger *Debugger) {
+		debugger.SetStopOnEntry(true)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "2\n", stdout)
+	expected := "Stopped at entry, line 1 in <script>\n" +
+		">   1 | fun fib(n) {\n" +
+		"(glox) " + debugConsoleHelp + "\n" +
+		"(glox) Breakpoint at line 2\n" +
+		"(glox) Breakpoint at line 2\n" +
+		"(glox) Stopped at breakpoint, line 2 in fib\n" +
+		">   2 |   if (n < 2) return n;\n" +
+		"(glox) *#0 fib at line 2\n" +
+		" #1 <script> at line 6\n" +
+		"(glox) Locals:\n" +
+		"  n = 3\n" +
+		"Globals:\n" +
+		"  fib = <fn fib>\n" +
+		"(glox) 4\n" +
+		"(glox) #1 <script> at line 6\n" +
+		"(glox) undefined variable: n\n" +
+		"(glox) No frame \"9\"\n" +
+		"(glox) Deleted all breakpoints\n" +
+		"(glox) Stopped at step, line 3 in fib\n" +
+		">   3 |   var a = fib(n - 1);\n" +
+		"(glox) Stopped at step, line 4 in fib\n" +
+		">   4 |   return a + fib(n - 2);\n" +
+		"(glox)     1 | fun fib(n) {\n" +
+		"    2 |   if (n < 2) return n;\n" +
+		"    3 |   var a = fib(n - 1);\n" +
+		">   4 |   return a + fib(n - 2);\n" +
+		"    5 | }\n" +
+		"    6 | var result = fib(3);\n" +
+		"    7 | print result;\n" +
+		"    8 | \n" +
+		"(glox) Unknown command \"zz\", try help\n" +
+		"(glox) Invalid line \"x\"\n" +
+		"(glox) "
+	assert.Equal(t, expected, output.String())
+}
+
+func TestDebugConsoleEndOfInput(t *testing.T) {
+	var output bytes.Buffer
+	console := NewDebugConsole(bufio.NewReader(strings.NewReader("")), &output, "print 1;")
+	stdout, _, err := debugSource("print 1;", console, func(debugger *Debugger) {
+		debugger.SetStopOnEntry(true)
+	})
+	assert.ErrorIs(t, err, ErrDebuggerQuit)
+	assert.Empty(t, stdout)
+	assert.Equal(t, "Stopped at entry, line 1 in <script>\n>   1 | print 1;\n(glox) \n", output.String())
+}
+
+// dapClient drives a DapServer through pipes, as an editor would. What
+// the server sends is read as soon as it is written, as a pipe of the
+// operating system would buffer it.
+type dapClient struct {
+	t        *testing.T
+	writer   io.Writer
+	messages chan map[string]interface{}
+	seq      int
+	done     chan error
+}
+
+func newDapClient(t *testing.T) *dapClient {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	client := &dapClient{t: t, writer: clientOut, messages: make(chan map[string]interface{}, 1000), done: make(chan error, 1)}
+	go func() {
+		err := NewDapServer(serverIn, serverOut).Serve()
+		serverOut.Close()
+		client.done <- err
+	}()
+	go func() {
+		reader := bufio.NewReader(clientIn)
+		for {
+			content, err := readFramedMessage(reader)
+			if err != nil {
+				close(client.messages)
+				return
+			}
+			message := map[string]interface{}{}
+			json.Unmarshal(content, &message)
+			client.messages <- message
+		}
+	}()
+	return client
+}
+
+func (c *dapClient) receive() map[string]interface{} {
+	select {
+	case message, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("the server closed the connection")
+		}
+		return message
+	case <-time.After(10 * time.Second):
+		c.t.Fatal("timed out waiting for the server")
+	}
+	return nil
+}
+
+// request sends a request and returns its response, along with the events
+// sent before it.
+func (c *dapClient) request(command string, arguments map[string]interface{}) (map[string]interface{}, []map[string]interface{}) {
+	c.seq++
+	content, _ := json.Marshal(map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": arguments})
+	writeFramedMessage(c.writer, content)
+	events := []map[string]interface{}{}
+	for {
+		message := c.receive()
+		if message["type"] == "response" && message["request_seq"] == float64(c.seq) {
+			return message, events
+		}
+		events = append(events, message)
+	}
+}
+
+// disconnect ends the session.
+func (c *dapClient) disconnect() {
+	response, _ := c.request("disconnect", nil)
+	assert.Equal(c.t, true, response["success"])
+	assert.NoError(c.t, <-c.done)
+}
+
+// waitFor returns the body of the next event with the given name, skipping
+// others.
+func (c *dapClient) waitFor(name string) map[string]interface{} {
+	for {
+		message := c.receive()
+		if message["type"] == "event" && message["event"] == name {
+			body, _ := message["body"].(map[string]interface{})
+			return body
+		}
+	}
+}
+
+func TestDapSession(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "fib.glox")
+	assert.NoError(t, os.WriteFile(program, []byte(debugTestSource), 0644))
+	client := newDapClient(t)
+
+	response, _ := client.request("initialize", map[string]interface{}{"adapterID": "glox"})
+	assert.Equal(t, true, response["success"])
+	assert.Equal(t, true, response["body"].(map[string]interface{})["supportsConfigurationDoneRequest"])
+	client.waitFor("initialized")
+	response, _ = client.request("launch", map[string]interface{}{"program": program})
+	assert.Equal(t, true, response["success"])
+	response, _ = client.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]interface{}{"path": program},
+		"breakpoints": []interface{}{map[string]interface{}{"line": 4}},
+	})
+	assert.Equal(t, []interface{}{map[string]interface{}{"verified": true, "line": float64(4)}}, response["body"].(map[string]interface{})["breakpoints"])
+	response, _ = client.request("stackTrace", map[string]interface{}{"threadId": DAP_THREAD_ID})
+	assert.Equal(t, false, response["success"])
+	assert.Equal(t, "the program is not paused", response["message"])
+	client.request("configurationDone", nil)
+
+	stopped := client.waitFor("stopped")
+	assert.Equal(t, "breakpoint", stopped["reason"])
+	response, _ = client.request("threads", nil)
+	assert.Len(t, response["body"].(map[string]interface{})["threads"], 1)
+	response, _ = client.request("stackTrace", map[string]interface{}{"threadId": DAP_THREAD_ID})
+	frames := response["body"].(map[string]interface{})["stackFrames"].([]interface{})
+	if assert.Len(t, frames, 3) {
+		assert.Equal(t, "fib", frames[0].(map[string]interface{})["name"])
+		assert.Equal(t, float64(4), frames[0].(map[string]interface{})["line"])
+		assert.Equal(t, program, frames[0].(map[string]interface{})["source"].(map[string]interface{})["path"])
+		assert.Equal(t, "<script>", frames[2].(map[string]interface{})["name"])
+	}
+	response, _ = client.request("scopes", map[string]interface{}{"frameId": 0})
+	scopes := response["body"].(map[string]interface{})["scopes"].([]interface{})
+	assert.Len(t, scopes, 2)
+	locals := scopes[0].(map[string]interface{})
+	assert.Equal(t, "Locals", locals["name"])
+	response, _ = client.request("variables", map[string]interface{}{"variablesReference": locals["variablesReference"]})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "a", "value": "1", "variablesReference": float64(0)},
+		map[string]interface{}{"name": "n", "value": "2", "variablesReference": float64(0)},
+	}, response["body"].(map[string]interface{})["variables"])
+	response, _ = client.request("evaluate", map[string]interface{}{"expression": "n * 100", "frameId": 1})
+	assert.Equal(t, "300", response["body"].(map[string]interface{})["result"])
+	response, _ = client.request("evaluate", map[string]interface{}{"expression": "nope", "frameId": 0})
+	assert.Equal(t, false, response["success"])
+	assert.Equal(t, "undefined variable: nope", response["message"])
+
+	response, _ = client.request("next", map[string]interface{}{"threadId": DAP_THREAD_ID})
+	assert.Equal(t, true, response["success"])
+	stopped = client.waitFor("stopped")
+	assert.Equal(t, "breakpoint", stopped["reason"])
+	client.request("stepOut", map[string]interface{}{"threadId": DAP_THREAD_ID})
+	assert.Equal(t, "step", client.waitFor("stopped")["reason"])
+	response, _ = client.request("stackTrace", map[string]interface{}{"threadId": DAP_THREAD_ID})
+	frames = response["body"].(map[string]interface{})["stackFrames"].([]interface{})
+	assert.Equal(t, float64(7), frames[0].(map[string]interface{})["line"])
+
+	client.request("continue", map[string]interface{}{"threadId": DAP_THREAD_ID})
+	output := client.waitFor("output")
+	assert.Equal(t, map[string]interface{}{"category": "stdout", "output": "2\n"}, output)
+	assert.Equal(t, float64(0), client.waitFor("exited")["exitCode"])
+	client.waitFor("terminated")
+	client.disconnect()
+}
+
+func TestDapDisconnectWhilePaused(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "loop.glox")
+	assert.NoError(t, os.WriteFile(program, []byte("var i = 0;\nwhile (true) {\n  i = i + 1;\n}\n"), 0644))
+	client := newDapClient(t)
+	client.request("initialize", nil)
+	client.request("launch", map[string]interface{}{"program": program, "stopOnEntry": true})
+	client.request("configurationDone", nil)
+	assert.Equal(t, "entry", client.waitFor("stopped")["reason"])
+	client.request("continue", map[string]interface{}{"threadId": DAP_THREAD_ID})
+	client.request("pause", map[string]interface{}{"threadId": DAP_THREAD_ID})
+	assert.Equal(t, "pause", client.waitFor("stopped")["reason"])
+	client.disconnect()
+}
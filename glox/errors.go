@@ -1,7 +1,9 @@
 package glox
 
 import (
-	"log"
+	"fmt"
+	"io"
+	"os"
 )
 
 type ErrorReporter interface {
@@ -13,6 +15,7 @@ type ErrorReporter interface {
 
 type ConsoleErrorReporter struct {
 	hasError bool
+	writer   io.Writer
 }
 
 func (er *ConsoleErrorReporter) HasError() bool {
@@ -33,15 +36,20 @@ func (er *ConsoleErrorReporter) Push(line int, where string, err error) {
 
 func (er *ConsoleErrorReporter) report(line int, where string, message string) {
 	if len(where) == 0 {
-		log.Printf("[line %d] Error: %s", line, message)
+		fmt.Fprintf(er.writer, "[line %d] Error: %s\n", line, message)
 	} else {
-		log.Printf("[line %d] Error[%s]: %s", line, where, message)
+		fmt.Fprintf(er.writer, "[line %d] Error[%s]: %s\n", line, where, message)
 	}
 	er.hasError = true
 }
 
 func NewConsoleErrorReporter() *ConsoleErrorReporter {
+	return NewConsoleErrorReporterWithWriter(os.Stderr)
+}
+
+func NewConsoleErrorReporterWithWriter(writer io.Writer) *ConsoleErrorReporter {
 	return &ConsoleErrorReporter{
 		hasError: false,
+		writer:   writer,
 	}
 }
@@ -0,0 +1,213 @@
+package glox
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+const FORMAT_INDENT = "  "
+
+/*
+ * Format returns source in the canonical glox style: two-space indentation,
+ * one statement per line, opening braces on the line of their statement,
+ * "} else" on one line, single spaces around binary operators and after
+ * commas, and at most one blank line between statements. Comments are kept
+ * where they are, either trailing a line or on lines of their own.
+ *
+ * Formatting works on the token stream rather than on the AST, which drops
+ * comments and desugars for loops, so the output has exactly the tokens of
+ * the input. Format is idempotent: formatting its output again returns it
+ * unchanged. Sources that do not scan or parse are returned as an error.
+ */
+func Format(source string) (string, error) {
+	var errorOutput bytes.Buffer
+	errorReporter := NewConsoleErrorReporterWithWriter(&errorOutput)
+	tokens := NewScannerWithComments(source, errorReporter).ScanTokens()
+	code := []Token{}
+	for _, token := range tokens {
+		if token.Type != TOKEN_COMMENT {
+			code = append(code, token)
+		}
+	}
+	if !errorReporter.HasError() {
+		parser := NewParser(code, errorReporter)
+		parser.Parse()
+	}
+	if errorReporter.HasError() {
+		return "", errors.New(strings.TrimRight(errorOutput.String(), "\n"))
+	}
+	formatter := &formatter{tokens: tokens}
+	return formatter.format(), nil
+}
+
+type formatter struct {
+	tokens  []Token
+	builder strings.Builder
+	indent  int
+	parens  int
+	// previous is the last token written, previousCode the last one that
+	// is not a comment.
+	previous       *Token
+	previousCode   *Token
+	previousUnary  bool
+	pendingNewline bool
+}
+
+func (f *formatter) format() string {
+	for i := 0; i < len(f.tokens) && f.tokens[i].Type != TOKEN_EOF; i++ {
+		token := f.tokens[i]
+		next := f.tokens[i+1]
+		if token.Type == TOKEN_COMMENT {
+			f.writeComment(token, next)
+			continue
+		}
+		if token.Type == TOKEN_RIGHT_BRACE {
+			f.indent--
+		}
+		if f.pendingNewline {
+			f.newline(token)
+		} else if f.previous != nil && f.needsSpace(token) {
+			f.builder.WriteString(" ")
+		}
+		unary := (token.Type == TOKEN_MINUS || token.Type == TOKEN_BANG) && !f.afterOperand()
+		f.builder.WriteString(token.Lexeme)
+		f.wrote(token)
+		f.previousUnary = unary
+
+		switch token.Type {
+		case TOKEN_LEFT_PAREN:
+			f.parens++
+		case TOKEN_RIGHT_PAREN:
+			f.parens--
+		case TOKEN_LEFT_BRACE:
+			if next.Type == TOKEN_RIGHT_BRACE {
+				// empty blocks stay on one line.
+				f.builder.WriteString(next.Lexeme)
+				f.wrote(next)
+				i++
+				f.pendingNewline = f.tokens[i+1].Type != TOKEN_ELSE
+			} else {
+				f.indent++
+				f.pendingNewline = true
+			}
+		case TOKEN_RIGHT_BRACE:
+			f.pendingNewline = next.Type != TOKEN_ELSE
+		case TOKEN_SEMICOLON:
+			f.pendingNewline = f.parens == 0 && next.Type != TOKEN_ELSE
+		}
+	}
+	if f.builder.Len() > 0 {
+		f.builder.WriteString("\n")
+	}
+	return f.builder.String()
+}
+
+func (f *formatter) writeComment(comment Token, next Token) {
+	text := strings.TrimRight(comment.Lexeme, " \t\r")
+	if f.previous != nil && tokenStartLine(comment) == f.previous.Line {
+		// trailing or inline comment, it stays on its line.
+		f.builder.WriteString(" " + text)
+	} else {
+		if f.previous != nil {
+			f.pendingNewline = true
+		}
+		if f.pendingNewline {
+			f.newline(comment)
+		}
+		f.builder.WriteString(text)
+	}
+	f.previous = &comment
+	if strings.HasPrefix(text, "//") || tokenStartLine(next) > comment.Line {
+		f.pendingNewline = true
+	}
+}
+
+// newline ends the current line before token, keeping a single blank line
+// if the source had any, and indents the new line. Lines that continue a
+// statement are indented one more level.
+func (f *formatter) newline(token Token) {
+	f.builder.WriteString("\n")
+	if f.previous != nil && tokenStartLine(token)-f.previous.Line >= 2 &&
+		f.previous.Type != TOKEN_LEFT_BRACE && token.Type != TOKEN_RIGHT_BRACE {
+		f.builder.WriteString("\n")
+	}
+	indent := f.indent
+	if f.inStatement() {
+		indent++
+	}
+	f.builder.WriteString(strings.Repeat(FORMAT_INDENT, indent))
+	f.pendingNewline = false
+}
+
+func (f *formatter) wrote(token Token) {
+	f.previous = &token
+	f.previousCode = &token
+}
+
+// inStatement reports whether the last token written leaves a statement
+// unfinished.
+func (f *formatter) inStatement() bool {
+	if f.previousCode == nil {
+		return false
+	}
+	switch f.previousCode.Type {
+	case TOKEN_LEFT_BRACE, TOKEN_RIGHT_BRACE:
+		return false
+	case TOKEN_SEMICOLON:
+		return f.parens > 0
+	}
+	return true
+}
+
+// afterOperand reports whether the last token written ends an operand, so
+// that a following "-" is a binary operator.
+func (f *formatter) afterOperand() bool {
+	if f.previousCode == nil {
+		return false
+	}
+	switch f.previousCode.Type {
+	case TOKEN_IDENTIFIER, TOKEN_NUMBER, TOKEN_STRING, TOKEN_TRUE, TOKEN_FALSE,
+		TOKEN_NIL, TOKEN_THIS, TOKEN_SUPER, TOKEN_RIGHT_PAREN:
+		return true
+	}
+	return false
+}
+
+func (f *formatter) needsSpace(token Token) bool {
+	previous := f.previous
+	if previous.Type == TOKEN_COMMENT {
+		return true
+	}
+	if previous.Type == TOKEN_INTERPOLATION || isInterpolationEnd(token) {
+		return false
+	}
+	switch token.Type {
+	case TOKEN_RIGHT_PAREN, TOKEN_COMMA, TOKEN_SEMICOLON, TOKEN_DOT:
+		return false
+	case TOKEN_LEFT_PAREN:
+		if f.afterOperand() {
+			// a call.
+			return false
+		}
+	}
+	switch previous.Type {
+	case TOKEN_LEFT_PAREN, TOKEN_DOT:
+		return false
+	case TOKEN_MINUS, TOKEN_BANG:
+		return !f.previousUnary
+	}
+	return true
+}
+
+// isInterpolationEnd reports whether token is the rest of a string literal
+// after an interpolated expression, which starts with the closing "}".
+func isInterpolationEnd(token Token) bool {
+	return (token.Type == TOKEN_STRING || token.Type == TOKEN_INTERPOLATION) && strings.HasPrefix(token.Lexeme, "}")
+}
+
+// tokenStartLine returns the line a token starts on. Token.Line is the line
+// where it ends, which differs for multi-line strings and comments.
+func tokenStartLine(token Token) int {
+	return token.Line - strings.Count(token.Lexeme, "\n")
+}
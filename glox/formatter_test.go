@@ -0,0 +1,87 @@
+package glox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"spacing", "var  a=1+2*-3;print a ;", "var a = 1 + 2 * -3;\nprint a;\n"},
+		{"unary", "print !  true;print - -1;print 1- -1;", "print !true;\nprint --1;\nprint 1 - -1;\n"},
+		{"calls and properties", "print str ( 1 , 2 ) . trim ( ) ;", "print str(1, 2).trim();\n"},
+		{"blocks", "fun f(a,b){if(a){return b;}else{return a;}}", "fun f(a, b) {\n  if (a) {\n    return b;\n  } else {\n    return a;\n  }\n}\n"},
+		{"empty blocks", "fun f(){}\nif(true){}else{}", "fun f() {}\nif (true) {} else {}\n"},
+		{"single statement bodies", "if (a)\n  print 1;\nelse\n  print 2;\nwhile(a)a=a-1;", "if (a) print 1; else print 2;\nwhile (a) a = a - 1;\n"},
+		{"for clauses", "for(var i=0;i<3;i=i+1)print i;for(;;){break;}", "for (var i = 0; i < 3; i = i + 1) print i;\nfor (;;) {\n  break;\n}\n"},
+		{"ternary and logical", "print a?b:c and d or!e;", "print a ? b : c and d or !e;\n"},
+		{"blank lines", "var a;\n\n\n\nvar b;\nvar c;\n{\n\nprint a;\n\n}\n", "var a;\n\nvar b;\nvar c;\n{\n  print a;\n}\n"},
+		{"trailing comments", "var a = 1;   // one\n{ // open\nprint a; /* two */\n}", "var a = 1; // one\n{ // open\n  print a; /* two */\n}\n"},
+		{"own line comments", "// header\n\n  fun f() {\n      // inside\n  return 1;\n}\n", "// header\n\nfun f() {\n  // inside\n  return 1;\n}\n"},
+		{"block comments", "/* multi\n   line */\nprint /* inline */ 1;", "/* multi\n   line */\nprint /* inline */ 1;\n"},
+		{"comment in statement", "var a = 1 + // first\n2;", "var a = 1 + // first\n  2;\n"},
+		{"interpolation", "print \"a ${ 1+2 } b ${x}\";", "print \"a ${1 + 2} b ${x}\";\n"},
+		{"strings kept verbatim", "print \"  two\n  lines\\t\";", "print \"  two\n  lines\\t\";\n"},
+	}
+	for _, testCase := range testCases {
+		formatted, err := Format(testCase.source)
+		assert.NoError(t, err, testCase.name)
+		assert.Equal(t, testCase.expected, formatted, testCase.name)
+		again, err := Format(formatted)
+		assert.NoError(t, err, testCase.name)
+		assert.Equal(t, formatted, again, testCase.name+" is idempotent")
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"scan error", "print \"open;", "[line 1] Error: Unterminated string."},
+		{"parse error", "print (1;", "[line 1] Error[Parser]: ParseError: Expect ')' after expression."},
+	}
+	for _, testCase := range testCases {
+		_, err := Format(testCase.source)
+		if assert.Error(t, err, testCase.name) {
+			assert.Equal(t, testCase.expectedError, err.Error(), testCase.name)
+		}
+	}
+}
+
+// TestFormatConformance formats every conformance program, checking that
+// formatting is idempotent and does not change what the program does.
+func TestFormatConformance(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(CONFORMANCE_DIR, "*", "*.glox"))
+	if !assert.NoError(t, err) || !assert.NotEmpty(t, paths) {
+		return
+	}
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		formatted, err := Format(string(source))
+		if err != nil {
+			// programs expecting parse errors cannot be formatted.
+			assert.Equal(t, CONFORMANCE_EXIT_PARSE_ERROR, parseExpectations(string(source)).exitCode, path)
+			continue
+		}
+		again, err := Format(formatted)
+		assert.NoError(t, err, path)
+		assert.Equal(t, formatted, again, path)
+		expectedOutput, _, expectedExitCode := runConformanceProgram(string(source), false)
+		output, _, exitCode := runConformanceProgram(formatted, false)
+		assert.Equal(t, expectedOutput, output, path)
+		assert.Equal(t, expectedExitCode, exitCode, path)
+	}
+}
@@ -0,0 +1,297 @@
+package glox
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func newFsModule() *NativeModule {
+	return NewNativeModule("fs", map[string]interface{}{
+		"readFile":   NewNativeCallable("readFile", 1, fsReadFile),
+		"writeFile":  NewNativeCallable("writeFile", 2, fsWriteFile),
+		"appendFile": NewNativeCallable("appendFile", 2, fsAppendFile),
+		"readLines":  NewNativeCallable("readLines", 1, fsReadLines),
+		"eachLine":   NewNativeCallable("eachLine", 2, fsEachLine),
+		"exists":     NewNativeCallable("exists", 1, fsExists),
+		"isFile":     NewNativeCallable("isFile", 1, fsIsFile),
+		"isDir":      NewNativeCallable("isDir", 1, fsIsDir),
+		"stat":       NewNativeCallable("stat", 1, fsStat),
+		"listDir":    NewNativeCallable("listDir", 1, fsListDir),
+		"mkdir":      NewNativeCallable("mkdir", 1, fsMkdir),
+		"remove":     NewNativeCallable("remove", 1, fsRemove),
+		"removeAll":  NewNativeCallable("removeAll", 1, fsRemoveAll),
+		"join":       NewNativeCallable("join", -1, fsJoin),
+		"basename":   NewNativeCallable("basename", 1, fsBasename),
+		"dirname":    NewNativeCallable("dirname", 1, fsDirname),
+		"extname":    NewNativeCallable("extname", 1, fsExtname),
+		"abs":        NewNativeCallable("abs", 1, fsAbs),
+	})
+}
+
+func fsReadFile(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("readFile", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("readFile: %w", err)
+	}
+	return string(contents), nil
+}
+
+func fsWriteFile(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("writeFile", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := stringArgument("writeFile", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		return nil, fmt.Errorf("writeFile: %w", err)
+	}
+	return nil, nil
+}
+
+func fsAppendFile(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("appendFile", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := stringArgument("appendFile", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("appendFile: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(contents); err != nil {
+		return nil, fmt.Errorf("appendFile: %w", err)
+	}
+	return nil, nil
+}
+
+func fsReadLines(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("readLines", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	lines := []interface{}{}
+	err = scanLines(path, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("readLines: %w", err)
+	}
+	return NewListValue(lines), nil
+}
+
+// fsEachLine calls a Lox function with every line of a file, without
+// loading the whole file in memory.
+func fsEachLine(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("eachLine", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	err = scanLines(path, func(line string) error {
+		_, err := inter.callValue(arguments[1], []interface{}{line})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("eachLine: %w", err)
+	}
+	return nil, nil
+}
+
+func scanLines(path string, callback func(line string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			if err := callback(strings.TrimRight(line, "\r\n")); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func fsExists(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("exists", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	_, err = os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("exists: %w", err)
+	}
+	return true, nil
+}
+
+func fsIsFile(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("isFile", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, nil
+	}
+	return info.Mode().IsRegular(), nil
+}
+
+func fsIsDir(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("isDir", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, nil
+	}
+	return info.IsDir(), nil
+}
+
+// fsStat describes a file as a map with its name, size in bytes, whether it
+// is a directory, permission bits and modification time in Unix seconds.
+func fsStat(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("stat", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	stat := NewMapValue()
+	stat.Set("name", info.Name())
+	stat.Set("size", info.Size())
+	stat.Set("isDir", info.IsDir())
+	stat.Set("mode", int64(info.Mode().Perm()))
+	stat.Set("modTime", float64(info.ModTime().UnixNano())/1e9)
+	return stat, nil
+}
+
+func fsListDir(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("listDir", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("listDir: %w", err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	elements := []interface{}{}
+	for _, name := range names {
+		elements = append(elements, name)
+	}
+	return NewListValue(elements), nil
+}
+
+func fsMkdir(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("mkdir", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
+	}
+	return nil, nil
+}
+
+func fsRemove(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("remove", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, fmt.Errorf("remove: %w", err)
+	}
+	return nil, nil
+}
+
+func fsRemoveAll(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("removeAll", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return nil, fmt.Errorf("removeAll: %w", err)
+	}
+	return nil, nil
+}
+
+func fsJoin(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	elements := []string{}
+	for i := range arguments {
+		element, err := stringArgument("join", arguments, i)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	return filepath.Join(elements...), nil
+}
+
+func fsBasename(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("basename", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return filepath.Base(path), nil
+}
+
+func fsDirname(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("dirname", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return filepath.Dir(path), nil
+}
+
+func fsExtname(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("extname", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return filepath.Ext(path), nil
+}
+
+func fsAbs(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	path, err := stringArgument("abs", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("abs: %w", err)
+	}
+	return absolute, nil
+}
@@ -0,0 +1,59 @@
+package glox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFsModule(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+	}{
+		{"write and read", "fs.writeFile(fs.join(dir, \"a.txt\"), \"héllo\\n\"); fs.readFile(fs.join(dir, \"a.txt\"));", "héllo\n"},
+		{"append", "var p = fs.join(dir, \"b.txt\"); fs.appendFile(p, \"1\\n\"); fs.appendFile(p, \"2\"); fs.readFile(p);", "1\n2"},
+		{"readLines", "var p = fs.join(dir, \"c.txt\"); fs.writeFile(p, \"x\\r\\ny\\n\\nz\"); \"|\".join(fs.readLines(p));", "x|y||z"},
+		{"eachLine", "var p = fs.join(dir, \"d.txt\"); fs.writeFile(p, \"1\\n2\\n3\\n\"); var total = 0; fun add(line) { total = total + line.toNumber(); } fs.eachLine(p, add); total;", 6.0},
+		{"exists", "fs.exists(fs.join(dir, \"a.txt\")) and !fs.exists(fs.join(dir, \"missing\"));", true},
+		{"isFile and isDir", "fs.isFile(fs.join(dir, \"a.txt\")) and fs.isDir(dir) and !fs.isDir(fs.join(dir, \"a.txt\"));", true},
+		{"stat", "var s = fs.stat(fs.join(dir, \"a.txt\")); \"${s.get(\"name\")} ${s.get(\"size\")} ${s.get(\"isDir\")}\";", "a.txt 7 false"},
+		{"mkdir and listDir", "fs.mkdir(fs.join(dir, \"sub\", \"deep\")); fs.writeFile(fs.join(dir, \"sub\", \"f\"), \"\"); \",\".join(fs.listDir(fs.join(dir, \"sub\")));", "deep,f"},
+		{"remove", "var p = fs.join(dir, \"gone\"); fs.writeFile(p, \"\"); fs.remove(p); fs.exists(p);", false},
+		{"removeAll", "fs.removeAll(fs.join(dir, \"sub\")); fs.exists(fs.join(dir, \"sub\"));", false},
+		{"path helpers", "var p = fs.join(\"a\", \"b\", \"../c\", \"file.tar.gz\"); \"${p} ${fs.dirname(p)} ${fs.basename(p)} ${fs.extname(p)}\";", "a/c/file.tar.gz a/c file.tar.gz .gz"},
+	}
+	for _, testCase := range testCases {
+		source := fmt.Sprintf("var dir = %q; %s", dir, testCase.source)
+		lastValue, _, stderr := interpretSource(source)
+		assert.Empty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestFsModuleErrors(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "lines.txt"), []byte("1\nx\n"), 0644))
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"read missing file", "fs.readFile(fs.join(dir, \"missing.txt\"));", fmt.Sprintf("[line 2] Error[interpreter]: readFile: open %s/missing.txt: no such file or directory\n", dir)},
+		{"list missing dir", "fs.listDir(fs.join(dir, \"missing\"));", fmt.Sprintf("[line 2] Error[interpreter]: listDir: open %s/missing: no such file or directory\n", dir)},
+		{"remove missing file", "fs.remove(fs.join(dir, \"missing\"));", fmt.Sprintf("[line 2] Error[interpreter]: remove: remove %s/missing: no such file or directory\n", dir)},
+		{"wrong argument type", "fs.readFile(42);", "[line 2] Error[interpreter]: readFile: argument 1 must be a string, got number\n"},
+		{"callback error reported once", "fun parse(line) {\n return line.toNumber(); }\nfs.eachLine(fs.join(dir, \"lines.txt\"), parse);", "[line 3] Error[interpreter]: toNumber: cannot parse \"x\" as a number\n"},
+		{"callback arity", "fun noArgs() {}\nfs.eachLine(fs.join(dir, \"lines.txt\"), noArgs);", "[line 3] Error[interpreter]: eachLine: expected a function of 1 arguments but got one of 0\n"},
+	}
+	for _, testCase := range testCases {
+		source := fmt.Sprintf("var dir = %q;\n%s", dir, testCase.source)
+		_, _, stderr := interpretSource(source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+	}
+}
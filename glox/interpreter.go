@@ -1,9 +1,16 @@
 package glox
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
 	"reflect"
-	"strconv"
+	"sync"
+	"time"
 )
 
 const INTERPRETER_WHERE = "interpreter"
@@ -12,8 +19,40 @@ type Interpreter struct {
 	errorReporter ErrorReporter
 	environment   *Environment
 	globals       *Environment
-	locals        map[*Expr]int
+	locals        map[*binding]int
 	lastValue     interface{}
+	stdin         *bufio.Reader
+	stdout        io.Writer
+	stderr        io.Writer
+	random        *rand.Rand
+	// allowProcesses lets scripts spawn subprocesses through the process
+	// module. Hosts running untrusted scripts can turn it off.
+	allowProcesses bool
+	// lock is held while Lox code runs. Natives that block on I/O release it
+	// so that server handlers running on other goroutines can take turns.
+	lock *sync.Mutex
+	// debugger, when set, is consulted before each statement and told about
+	// each function call.
+	debugger *Debugger
+	// profiler, when set, times each statement and function call.
+	profiler *Profiler
+	// coverage, when set, records the lines and branches that run.
+	coverage *CoverageFile
+}
+
+// RuntimeError is an error raised while interpreting a script, which has
+// already been pushed to the ErrorReporter along with its line.
+type RuntimeError struct {
+	Line int
+	Err  error
+}
+
+func (e RuntimeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e RuntimeError) Unwrap() error {
+	return e.Err
 }
 
 type BreakResult struct {
@@ -32,6 +71,16 @@ func (c ContinueResult) Error() string {
 
 type ReturnResult struct {
 	value interface{}
+	// tailCall, when set, is the call returned from, left for the
+	// FunctionCallable returning to make once the Go stack has unwound.
+	tailCall *tailCall
+}
+
+// tailCall is a call to a Lox function in tail position, with its arguments
+// already evaluated.
+type tailCall struct {
+	function  FunctionCallable
+	arguments []interface{}
 }
 
 func (r ReturnResult) Error() string {
@@ -39,34 +88,117 @@ func (r ReturnResult) Error() string {
 }
 
 func NewInterpreter(errorReporter ErrorReporter) Interpreter {
+	return NewInterpreterWithStreams(errorReporter, os.Stdin, os.Stdout, os.Stderr)
+}
+
+func NewInterpreterWithStreams(errorReporter ErrorReporter, stdin io.Reader, stdout io.Writer, stderr io.Writer) Interpreter {
 	globals := NewEnvironment()
 	globals.Define("clock", NewClockCallable())
+	globals.Define("readLine", NewNativeCallable("readLine", 0, nativeReadLine))
+	globals.Define("printErr", NewNativeCallable("printErr", 1, nativePrintErr))
+	globals.Define("str", NewNativeCallable("str", 1, nativeStr))
+	globals.Define("int", NewNativeCallable("int", 1, nativeInt))
+	globals.Define("float", NewNativeCallable("float", 1, nativeFloat))
+	globals.Define("len", NewNativeCallable("len", 1, nativeLen))
+	globals.Define("list", NewNativeCallable("list", -1, nativeList))
+	globals.Define("map", NewNativeCallable("map", 0, nativeMap))
+	globals.Define("math", newMathModule())
+	globals.Define("fs", newFsModule())
+	globals.Define("json", newJsonModule())
+	globals.Define("regex", newRegexModule())
+	globals.Define("time", newTimeModule())
+	globals.Define("random", newRandomModule())
+	globals.Define("process", newProcessModule())
+	globals.Define("net", newNetModule())
+	globals.Define("assert", NewNativeCallable("assert", -1, nativeAssert))
+	globals.Define("assertEqual", NewNativeCallable("assertEqual", -1, nativeAssertEqual))
+	globals.Define("assertThrows", NewNativeCallable("assertThrows", -1, nativeAssertThrows))
 	return Interpreter{
-		errorReporter: errorReporter,
-		globals:       &globals,
-		environment:   &globals,
-		locals:        map[*Expr]int{},
-		lastValue:     nil,
+		errorReporter:  errorReporter,
+		globals:        &globals,
+		environment:    &globals,
+		locals:         map[*binding]int{},
+		lastValue:      nil,
+		stdin:          bufio.NewReader(stdin),
+		stdout:         stdout,
+		stderr:         stderr,
+		random:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		allowProcesses: true,
+		lock:           &sync.Mutex{},
+	}
+}
+
+// SetAllowProcesses controls whether scripts may run subprocesses. When
+// denied, the process natives fail with a runtime error.
+func (inter *Interpreter) SetAllowProcesses(allow bool) {
+	inter.allowProcesses = allow
+}
+
+// SetReproducible makes runs repeatable: the random module restarts from a
+// fixed seed instead of one taken from the current time.
+func (inter *Interpreter) SetReproducible(reproducible bool) {
+	if reproducible {
+		inter.random.Seed(REPRODUCIBLE_RANDOM_SEED)
+	} else {
+		inter.random.Seed(time.Now().UnixNano())
 	}
 }
 
+// Interpret executes statements in order, stopping at the first runtime
+// error, which it returns.
 func (inter *Interpreter) Interpret(statements []Stmt) (interface{}, error) {
+	inter.lock.Lock()
+	defer inter.lock.Unlock()
 	for _, stmt := range statements {
-		inter.execute(stmt)
+		_, err := inter.execute(stmt)
+		// a tail call returned from the script has no function to make it.
+		if returned, ok := err.(ReturnResult); ok && returned.tailCall != nil {
+			_, err = returned.tailCall.function.call(inter, returned.tailCall.arguments)
+		}
+		var runtimeErr RuntimeError
+		if errors.As(err, &runtimeErr) || errors.Is(err, ErrDebuggerQuit) {
+			return inter.lastValue, err
+		}
 	}
 	return inter.lastValue, nil
 }
 
+// withoutLock runs a blocking operation with the interpreter lock released.
+func (inter *Interpreter) withoutLock(operation func()) {
+	inter.lock.Unlock()
+	defer inter.lock.Lock()
+	operation()
+}
+
 func (inter *Interpreter) GetLastValue() (interface{}, error) {
 	return inter.lastValue, nil
 }
 
 func (inter *Interpreter) execute(stmt Stmt) (interface{}, error) {
+	if inter.debugger != nil {
+		if err := inter.debugger.beforeStatement(stmt); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := stmt.(BlockStmt); !ok {
+		if inter.coverage != nil {
+			inter.coverage.hitLine(stmt.getLine())
+		}
+		if inter.profiler != nil {
+			inter.profiler.enterStatement(stmt)
+			defer inter.profiler.exitStatement()
+		}
+	}
 	return stmt.accept(inter)
 }
 
 func (inter *Interpreter) resolve(expr Expr, depth int) {
-	inter.locals[&expr] = depth
+	switch expr := expr.(type) {
+	case VariableExpr:
+		inter.locals[expr.binding] = depth
+	case AssignExpr:
+		inter.locals[expr.binding] = depth
+	}
 }
 
 func (inter *Interpreter) visitBlockStmt(stmt BlockStmt) (interface{}, error) {
@@ -82,9 +214,12 @@ func (inter *Interpreter) visitExpressionStmt(stmt ExpressionStmt) (interface{},
 
 func (inter *Interpreter) visitPrintStmt(stmt PrintStmt) (interface{}, error) {
 	value, err := inter.evaluate(stmt.Print)
-	fmt.Println(value)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(inter.stdout, Stringify(value))
 	inter.lastValue = value
-	return inter.lastValue, err
+	return inter.lastValue, nil
 }
 
 func (inter *Interpreter) visitVarStmt(stmt VarStmt) (interface{}, error) {
@@ -108,8 +243,10 @@ func (inter *Interpreter) visitIfStmt(stmt IfStmt) (interface{}, error) {
 	}
 	conditionVal, err := isTruthy(evalResult)
 	if err != nil {
-		inter.errorReporter.Push(stmt.Condition.getLine(), INTERPRETER_WHERE, err)
-		return nil, err
+		return nil, inter.runtimeError(stmt.Condition, err)
+	}
+	if inter.coverage != nil {
+		inter.coverage.hitBranch(stmt.getLine(), COVERAGE_BRANCH_IF, coverageBranch(conditionVal))
 	}
 	if conditionVal {
 		return inter.execute(stmt.ThenBranch)
@@ -127,7 +264,10 @@ func (inter *Interpreter) visitWhileStmt(stmt WhileStmt) (interface{}, error) {
 		}
 		keepRunning, err := isTruthy(evalResult)
 		if err != nil {
-			return nil, err
+			return nil, inter.runtimeError(stmt.Condition, err)
+		}
+		if inter.coverage != nil {
+			inter.coverage.hitBranch(stmt.getLine(), COVERAGE_BRANCH_WHILE, coverageBranch(keepRunning))
 		}
 		if !keepRunning {
 			break
@@ -140,6 +280,9 @@ func (inter *Interpreter) visitWhileStmt(stmt WhileStmt) (interface{}, error) {
 			return value, result
 		case ContinueResult:
 			continue
+		case nil:
+		default:
+			return value, result
 		}
 	}
 	return nil, nil
@@ -160,9 +303,16 @@ func (inter *Interpreter) visitFunctionStmt(stmt FunctionStmt) (interface{}, err
 }
 
 func (inter *Interpreter) visitReturnStmt(stmt ReturnStmt) (interface{}, error) {
-	value, err := inter.evaluate(stmt.Value)
-	if err != nil {
-		return nil, err
+	if call, ok := stmt.Value.(CallExpr); ok {
+		return inter.returnCall(call)
+	}
+	var value interface{} = nil
+	if stmt.Value != nil {
+		var err error
+		value, err = inter.evaluate(stmt.Value)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return nil, ReturnResult{
 		value: value,
@@ -184,8 +334,13 @@ func (inter *Interpreter) visitLogicalExpr(expr LogicalExpr) (interface{}, error
 	}
 	leftVal, err := isTruthy(left)
 	if err != nil {
-		inter.errorReporter.Push(expr.Left.getLine(), INTERPRETER_WHERE, err)
-		return nil, err
+		return nil, inter.runtimeError(expr.Left, err)
+	}
+	if inter.coverage != nil {
+		// the first branch short-circuits, the second evaluates the right
+		// operand.
+		shortCircuit := leftVal == (expr.Operator.Type == TOKEN_OR)
+		inter.coverage.hitBranch(expr.getLine(), logicalBranchKind(expr), coverageBranch(shortCircuit))
 	}
 	if expr.Operator.Type == TOKEN_OR {
 		if leftVal {
@@ -208,10 +363,20 @@ func (inter *Interpreter) visitUnaryExpr(expr UnaryExpr) (interface{}, error) {
 	switch expr.Operator.Type {
 	case TOKEN_BANG:
 		val, err := isTruthy(right)
-		return val, err
+		if err != nil {
+			return nil, inter.runtimeError(expr, err)
+		}
+		return !val, nil
 	case TOKEN_MINUS:
-		val, err := anyToFloat64(right)
-		return val, err
+		val, err := toNumber(right)
+		if err != nil {
+			return nil, inter.runtimeError(expr, fmt.Errorf("operator -: operand must be a number: %w", err))
+		}
+		val, err = negate(val)
+		if err != nil {
+			return nil, inter.runtimeError(expr, fmt.Errorf("operator -: %w", err))
+		}
+		return val, nil
 	}
 
 	// unreachable
@@ -229,63 +394,25 @@ func (inter *Interpreter) visitBinaryExpr(expr BinaryExpr) (interface{}, error)
 	}
 
 	switch expr.Operator.Type {
-	case TOKEN_GREATER:
-		leftVal, rightVal, err := inter.checkNumberOperands(expr, expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
-		return leftVal > rightVal, nil
-	case TOKEN_GREATER_EQUAL:
-		leftVal, rightVal, err := inter.checkNumberOperands(expr, expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
-		return leftVal >= rightVal, nil
-	case TOKEN_LESS:
-		leftVal, rightVal, err := inter.checkNumberOperands(expr, expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
-		return leftVal < rightVal, nil
-	case TOKEN_LESS_EQUAL:
-		leftVal, rightVal, err := inter.checkNumberOperands(expr, expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
-		return leftVal <= rightVal, nil
-	case TOKEN_MINUS:
-		leftVal, rightVal, err := inter.checkNumberOperands(expr, expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
-		return leftVal - rightVal, nil
-	case TOKEN_SLASH:
+	case TOKEN_GREATER, TOKEN_GREATER_EQUAL, TOKEN_LESS, TOKEN_LESS_EQUAL:
 		leftVal, rightVal, err := inter.checkNumberOperands(expr, expr.Operator, left, right)
 		if err != nil {
 			return nil, err
 		}
-		return leftVal / rightVal, nil
-	case TOKEN_STAR:
+		return compareNumbers(expr.Operator.Type, leftVal, rightVal), nil
+	case TOKEN_MINUS, TOKEN_SLASH, TOKEN_STAR, TOKEN_PERCENT, TOKEN_TILDE_SLASH, TOKEN_STAR_STAR:
 		leftVal, rightVal, err := inter.checkNumberOperands(expr, expr.Operator, left, right)
 		if err != nil {
 			return nil, err
 		}
-		return leftVal * rightVal, nil
+		return inter.arithmetic(expr, leftVal, rightVal)
 	case TOKEN_PLUS:
 		if isNumber(left) && isNumber(right) {
-			leftVal, rightVal, err := inter.checkNumberOperands(expr, expr.Operator, left, right)
-			if err != nil {
-				return nil, err
-			}
-			return leftVal + rightVal, nil
-		} else if isString(left) && isString(right) {
-			leftVal, rightVal, err := inter.checkStringOperands(expr, expr.Operator, left, right)
-			if err != nil {
-				return nil, err
-			}
-			return leftVal + rightVal, nil
+			return inter.arithmetic(expr, left, right)
+		} else if isString(left) || isString(right) {
+			return Stringify(left) + Stringify(right), nil
 		}
-		return nil, fmt.Errorf("operands must be two numbers or two strings")
+		return nil, inter.runtimeError(expr, fmt.Errorf("operator %s: operands must be two numbers or at least one string", expr.Operator.Lexeme))
 	case TOKEN_BANG_EQUAL:
 		return !isEqual(left, right), nil
 	case TOKEN_EQUAL_EQUAL:
@@ -301,7 +428,11 @@ func (inter *Interpreter) visitConditionalExpr(expr ConditionalExpr) (interface{
 	if err != nil {
 		return nil, err
 	}
-	if val, _ := isTruthy(condition); val {
+	val, _ := isTruthy(condition)
+	if inter.coverage != nil {
+		inter.coverage.hitBranch(expr.getLine(), COVERAGE_BRANCH_CONDITIONAL, coverageBranch(val))
+	}
+	if val {
 		return inter.evaluate(expr.Left)
 	} else {
 		return inter.evaluate(expr.Right)
@@ -309,9 +440,9 @@ func (inter *Interpreter) visitConditionalExpr(expr ConditionalExpr) (interface{
 }
 
 func (inter *Interpreter) visitVariableExpr(expr VariableExpr) (interface{}, error) {
-	value, err := inter.lookUpVariable(expr.Name, expr)
+	value, err := inter.lookUpVariable(expr.Name, expr.binding)
 	if err != nil {
-		return nil, err
+		return nil, inter.runtimeError(expr, err)
 	}
 	return value, nil
 }
@@ -321,45 +452,116 @@ func (inter *Interpreter) visitAssignExpr(expr AssignExpr) (interface{}, error)
 	if err != nil {
 		return nil, err
 	}
-	var e Expr = expr
-	distance, ok := inter.locals[&e]
+	distance, ok := inter.locals[expr.binding]
 	if ok {
-		inter.environment.AssignAt(distance, expr.Name.Lexeme, value)
+		err = inter.environment.AssignAt(distance, expr.Name.Lexeme, value)
 	} else {
-		inter.globals.Assign(expr.Name.Lexeme, value)
+		err = inter.globals.Assign(expr.Name.Lexeme, value)
+	}
+	if err != nil {
+		return nil, inter.runtimeError(expr, err)
 	}
 	return value, nil
 }
 
+// returnCall returns from a call in tail position. Calls to Lox functions
+// are not made here but by the FunctionCallable returning, so that
+// recursion in tail position runs in constant Go stack.
+func (inter *Interpreter) returnCall(expr CallExpr) (interface{}, error) {
+	callee, argumentValues, err := inter.evaluateCall(expr)
+	if err != nil {
+		return nil, err
+	}
+	if function, ok := callee.(FunctionCallable); ok && function.getArity() == len(argumentValues) {
+		return nil, ReturnResult{
+			tailCall: &tailCall{function: function, arguments: argumentValues},
+		}
+	}
+	value, err := inter.callValueAt(expr, callee, argumentValues)
+	if err != nil {
+		return nil, err
+	}
+	return nil, ReturnResult{
+		value: value,
+	}
+}
+
 func (inter *Interpreter) visitCallExpr(expr CallExpr) (interface{}, error) {
-	callee, err := inter.evaluate(expr.Callee)
+	callee, argumentValues, err := inter.evaluateCall(expr)
 	if err != nil {
 		return nil, err
 	}
+	return inter.callValueAt(expr, callee, argumentValues)
+}
+
+// evaluateCall evaluates the callee and then the arguments of a call.
+func (inter *Interpreter) evaluateCall(expr CallExpr) (interface{}, []interface{}, error) {
+	callee, err := inter.evaluate(expr.Callee)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	var argumentValues []interface{} = []interface{}{}
 	for _, argumentExpr := range expr.Arguments {
 		argumentValue, err := inter.evaluate(argumentExpr)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		argumentValues = append(argumentValues, argumentValue)
 	}
+	return callee, argumentValues, nil
+}
 
+// callValueAt calls callee, reporting errors at the line of the call.
+func (inter *Interpreter) callValueAt(expr CallExpr, callee interface{}, argumentValues []interface{}) (interface{}, error) {
 	switch callee := callee.(type) {
 	case Callable:
 		argumentCount := len(argumentValues)
-		if argumentCount != callee.getArity() {
-			err = fmt.Errorf("expected %d arguments but got %d", callee.getArity(), argumentCount)
-			inter.errorReporter.Push(expr.getLine(), INTERPRETER_WHERE, err)
-			return nil, err
+		if callee.getArity() >= 0 && argumentCount != callee.getArity() {
+			return nil, inter.runtimeError(expr, fmt.Errorf("expected %d arguments but got %d", callee.getArity(), argumentCount))
+		}
+		value, err := callee.call(inter, argumentValues)
+		if _, ok := callee.(NativeCallable); ok && err != nil {
+			return nil, inter.runtimeError(expr, err)
 		}
-		return callee.call(inter, argumentValues)
+		return value, err
 	default:
-		err = fmt.Errorf("can only call function and classes")
-		inter.errorReporter.Push(expr.getLine(), INTERPRETER_WHERE, err)
+		return nil, inter.runtimeError(expr, fmt.Errorf("can only call function and classes"))
+	}
+}
+
+func (inter *Interpreter) visitGetExpr(expr GetExpr) (interface{}, error) {
+	object, err := inter.evaluate(expr.Object)
+	if err != nil {
 		return nil, err
 	}
+	var property interface{} = nil
+	switch object := object.(type) {
+	case string:
+		property, err = getStringMethod(object, expr.Name.Lexeme)
+	case *ListValue:
+		property, err = getListMethod(object, expr.Name.Lexeme)
+	case *MapValue:
+		property, err = getMapMethod(object, expr.Name.Lexeme)
+	case *RegexValue:
+		property, err = getRegexMethod(object, expr.Name.Lexeme)
+	case *TimeValue:
+		property, err = getTimeMethod(object, expr.Name.Lexeme)
+	case *HttpServerValue:
+		property, err = getServerMethod(object, expr.Name.Lexeme)
+	case *ListenerValue:
+		property, err = getListenerMethod(object, expr.Name.Lexeme)
+	case *ConnectionValue:
+		property, err = getConnectionMethod(object, expr.Name.Lexeme)
+	case *NativeModule:
+		property, err = object.get(expr.Name.Lexeme)
+	default:
+		err = fmt.Errorf("%s values have no properties", typeName(object))
+	}
+	if err != nil {
+		return nil, inter.runtimeError(expr, err)
+	}
+	return property, nil
 }
 
 func (inter *Interpreter) evaluate(expr Expr) (interface{}, error) {
@@ -384,45 +586,72 @@ func (inter *Interpreter) executeBlock(statements []Stmt, localEnv *Environment)
 		case ReturnResult:
 			value = result.value
 			return value, result
+		case nil:
+		default:
+			return value, result
 		}
 	}
 	return value, result
 }
 
-func (inter *Interpreter) checkNumberOperands(expr Expr, operator Token, left interface{}, right interface{}) (float64, float64, error) {
-	returnError := func(err error) (float64, float64, error) {
-		inter.errorReporter.Push(expr.getLine(), INTERPRETER_WHERE, fmt.Errorf("operator %s: operands must be numbers: %w", operator.Lexeme, err))
-		return 0, 0, nil
+// checkNumberOperands converts the operands of an arithmetic or comparison
+// operator to numbers, ints or floats.
+func (inter *Interpreter) checkNumberOperands(expr Expr, operator Token, left interface{}, right interface{}) (interface{}, interface{}, error) {
+	returnError := func(err error) (interface{}, interface{}, error) {
+		return nil, nil, inter.runtimeError(expr, fmt.Errorf("operator %s: operands must be numbers: %w", operator.Lexeme, err))
 	}
-	leftVal, err := anyToFloat64(left)
+	leftVal, err := toNumber(left)
 	if err != nil {
 		return returnError(err)
 	}
-	rightVal, err := anyToFloat64(right)
+	rightVal, err := toNumber(right)
 	if err != nil {
 		return returnError(err)
 	}
 	return leftVal, rightVal, nil
 }
 
-func (inter *Interpreter) checkStringOperands(expr Expr, operator Token, left interface{}, right interface{}) (string, string, error) {
-	returnError := func(err error) (string, string, error) {
-		inter.errorReporter.Push(expr.getLine(), INTERPRETER_WHERE, fmt.Errorf("operator %s: operands must be strings: %w", operator.Lexeme, err))
-		return "", "", nil
-	}
-	leftVal, err := anyToString(left)
+// arithmetic applies the operator of expr to two numbers, reporting overflows
+// and divisions by zero.
+func (inter *Interpreter) arithmetic(expr BinaryExpr, left interface{}, right interface{}) (interface{}, error) {
+	value, err := arithmetic(expr.Operator.Type, left, right)
 	if err != nil {
-		return returnError(err)
+		return nil, inter.runtimeError(expr, fmt.Errorf("operator %s: %w", expr.Operator.Lexeme, err))
 	}
-	rightVal, err := anyToString(right)
-	if err != nil {
-		return returnError(err)
+	return value, nil
+}
+
+// runtimeError reports err at the line of expr and returns it as a
+// RuntimeError, so it can be propagated to the caller in a single statement.
+// Errors that already are a RuntimeError have been reported where they
+// happened and are returned untouched, as is a debugger quitting.
+func (inter *Interpreter) runtimeError(expr Expr, err error) error {
+	var runtimeErr RuntimeError
+	if errors.As(err, &runtimeErr) || errors.Is(err, ErrDebuggerQuit) {
+		return err
 	}
-	return leftVal, rightVal, nil
+	inter.errorReporter.Push(expr.getLine(), INTERPRETER_WHERE, err)
+	return RuntimeError{
+		Line: expr.getLine(),
+		Err:  err,
+	}
+}
+
+// callValue calls a Lox callable on behalf of a native function, such as
+// the callback passed to fs.eachLine.
+func (inter *Interpreter) callValue(callee interface{}, arguments []interface{}) (interface{}, error) {
+	callable, ok := callee.(Callable)
+	if !ok {
+		return nil, fmt.Errorf("expected a function but got %s", typeName(callee))
+	}
+	if callable.getArity() >= 0 && callable.getArity() != len(arguments) {
+		return nil, fmt.Errorf("expected a function of %d arguments but got one of %d", len(arguments), callable.getArity())
+	}
+	return callable.call(inter, arguments)
 }
 
-func (inter *Interpreter) lookUpVariable(name Token, expr Expr) (interface{}, error) {
-	distance, ok := inter.locals[&expr]
+func (inter *Interpreter) lookUpVariable(name Token, binding *binding) (interface{}, error) {
+	distance, ok := inter.locals[binding]
 	if ok {
 		return inter.environment.GetAt(distance, name.Lexeme)
 	} else {
@@ -441,41 +670,21 @@ func isString(val interface{}) bool {
 
 func isNumber(val interface{}) bool {
 	switch val.(type) {
-	case float64:
+	case int64, float64:
 		return true
 	default:
 		return false
 	}
 }
 
-func anyToString(val interface{}) (string, error) {
-	switch val := val.(type) {
-	case string:
-		return val, nil
-	case nil:
-		return "nil", nil
-	case float64:
-		return strconv.FormatFloat(val, 'f', -1, 64), nil
-	default:
-		return "", fmt.Errorf("cannot convert to string: %v", val)
-	}
-}
-
-func anyToFloat64(val interface{}) (float64, error) {
-	switch val := val.(type) {
-	case float64:
-		return val, nil
-	case string:
-		return strconv.ParseFloat(val, 64)
-	case float32:
-		return float64(val), nil
-	case int32:
-		return float64(val), nil
-	case int64:
-		return float64(val), nil
-	default:
-		return 0, fmt.Errorf("cannot convert to float: %v", val)
+// floorMod returns the remainder of the floored division of x by y, so the
+// result has the sign of y and x == math.Floor(x/y)*y + floorMod(x, y).
+func floorMod(x float64, y float64) float64 {
+	mod := math.Mod(x, y)
+	if mod != 0 && (mod < 0) != (y < 0) {
+		mod += y
 	}
+	return mod
 }
 
 func isTruthy(val interface{}) (bool, error) {
@@ -488,11 +697,16 @@ func isTruthy(val interface{}) (bool, error) {
 		return len(val) > 0, nil
 	case float64:
 		return val > 0, nil
+	case int64:
+		return val > 0, nil
 	default:
 		return false, fmt.Errorf("cannot convert to boolean: %v", val)
 	}
 }
 
 func isEqual(left interface{}, right interface{}) bool {
+	if isNumber(left) && isNumber(right) {
+		return numbersEqual(left, right)
+	}
 	return reflect.DeepEqual(left, right)
 }
@@ -1,7 +1,10 @@
 package glox
 
 import (
+	"bytes"
+	"errors"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,27 +16,35 @@ func TestInterpreterStatements(t *testing.T) {
 		source        string
 		expectedValue interface{}
 	}{
-		{"addition", "2+2;", 4.0},
+		{"addition", "2+2;", int64(4)},
 		{"operator precedence", "2+3*4-4/2;", 12.0},
-		{"conditional expression, true", "2<=3?3-1:false;", 2.0},
+		{"conditional expression, true", "2<=3?3-1:false;", int64(2)},
 		{"conditional expression, false", "2==3?3-1:false;", false},
 		{"default variable declaration", "var test;", nil},
-		{"simple variable declaration", "var test = 2*3;", 6.0},
-		{"if-true-then", "if(2<3){var test=1;}", 1.0},
+		{"simple variable declaration", "var test = 2*3;", int64(6)},
+		{"if-true-then", "if(2<3){var test=1;}", int64(1)},
 		{"if-false-then", "if(2>3){var test=1;}", nil},
-		{"if-true-then-false-else", "if(2<3){var test=1;}else{var test=2;}", 1.0},
-		{"if-false-then-true-else", "if(2>3){var test=1;}else{var test=2;}", 2.0},
-		{"if(logicalExpr)-true-then-else", "if(3>=3 and 2==2 and 3<4 and true==true){var test=1;}else{var test=2;}", 1.0},
-		{"if(logicalExpr)-true-then-else", "if(3>3 or 2==1 or 3>4 or true==true){var test=1;}else{var test=2;}", 1.0},
-		{"if(logicalExpr)-true-then-else", "if(3>3 or 2==1 or 3>4 or (true==false)){var test=1;}else{var test=2;}", 2.0},
-		{"while(trueLogicalExpr)-block", "var counter=0;while(counter<5){counter=counter+1;}", 5.0},
+		{"if-true-then-false-else", "if(2<3){var test=1;}else{var test=2;}", int64(1)},
+		{"if-false-then-true-else", "if(2>3){var test=1;}else{var test=2;}", int64(2)},
+		{"if(logicalExpr)-true-then-else", "if(3>=3 and 2==2 and 3<4 and true==true){var test=1;}else{var test=2;}", int64(1)},
+		{"if(logicalExpr)-true-then-else", "if(3>3 or 2==1 or 3>4 or true==true){var test=1;}else{var test=2;}", int64(1)},
+		{"if(logicalExpr)-true-then-else", "if(3>3 or 2==1 or 3>4 or (true==false)){var test=1;}else{var test=2;}", int64(2)},
+		{"while(trueLogicalExpr)-block", "var counter=0;while(counter<5){counter=counter+1;}", int64(5)},
 		{"while(falseLogicalExpr)-block", "while(false){print 1;}", nil},
-		{"ForStmt", "var i;for(i=0;i<5;i=i+1){i;}", 5.0},
-		{"ContinueStmt", "var counter=0;while(counter<5){counter=counter+1;continue;counter=0;}", 5.0},
-		{"BreakStmt", "var counter=0;while(counter<5){counter=counter+1;break;counter=0;}", 1.0},
-		{"CallExpr", "if(clock()>0){var counter=1;}", 1.0},
+		{"ForStmt", "var i;for(i=0;i<5;i=i+1){i;}", int64(5)},
+		{"ContinueStmt", "var counter=0;while(counter<5){counter=counter+1;continue;counter=0;}", int64(5)},
+		{"BreakStmt", "var counter=0;while(counter<5){counter=counter+1;break;counter=0;}", int64(1)},
+		{"CallExpr", "if(clock()>0){var counter=1;}", int64(1)},
 		{"FunctionStmt", "fun testFunction(arg){var counter=arg;}testFunction(1.0);", 1.0},
-		{"ReturnStmt", "fun testFunction(num) { var i; for (i = 0; i < num; i=i+1) { if (i >= 2) { return i; } } } testFunction(10.0);", 2.0},
+		{"return without a value", "fun f() { return; } f();", nil},
+		{"ReturnStmt", "fun testFunction(num) { var i; for (i = 0; i < num; i=i+1) { if (i >= 2) { return i; } } } testFunction(10.0);", int64(2)},
+		{"string concatenation", "\"fib(\" + 19 + \") = \" + 4181 + \" \" + nil;", "fib(19) = 4181 nil"},
+		{"logical not", "!(1 > 2) and !nil;", true},
+		{"map methods", "var m = fs.stat(\".\"); m.set(\"extra\", 1); m.remove(\"mode\"); \",\".join(m.keys()) + m.has(\"mode\") + m.length();", "name,size,isDir,modTime,extrafalse5"},
+		{"list methods", "var l = list(1, 2); l.push(3); l.set(0, l.pop()); str(l) + len(l);", "[3, 2]2"},
+		{"string interpolation", "var name=\"Ann\"; var n=2; \"Hello ${name}, you have ${n + 1} items${\"!\".repeat(n)}\";", "Hello Ann, you have 3 items!!"},
+		{"adjacent interpolations", "var a=1; var b=2; \"${a}${b}\";", "12"},
+		{"str native", "fun f(){} str(f) + str(clock) + str(true) + str(0.5);", "<fn f><native fn>true0.5"},
 	}
 	for _, testCase := range testCases {
 		errorReporter := NewConsoleErrorReporter()
@@ -61,8 +72,8 @@ func TestInterpreterFiles(t *testing.T) {
 		path      string
 		lastValue interface{}
 	}{
-		{"01-fibonacci", "testdata/interpreter/01-fibonacci.glox", 4181.0},
-		{"02-closures", "testdata/interpreter/02-closures.glox", 2.0},
+		{"01-fibonacci", "testdata/interpreter/01-fibonacci.glox", int64(4181)},
+		{"02-closures", "testdata/interpreter/02-closures.glox", int64(2)},
 	}
 
 	for _, testCase := range testCases {
@@ -93,3 +104,150 @@ func TestInterpreterFiles(t *testing.T) {
 	}
 
 }
+
+func TestInterpreterStreams(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("first line\nsecond line\n")
+	errorReporter := NewConsoleErrorReporterWithWriter(&stderr)
+	interpreter := NewInterpreterWithStreams(errorReporter, stdin, &stdout, &stderr)
+	source := "print readLine(); printErr(readLine()); print readLine();"
+	scanner := NewScanner(source, errorReporter)
+	parser := NewParser(scanner.ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	if !assert.False(t, errorReporter.HasError()) {
+		return
+	}
+	resolver := NewResolver(&interpreter)
+	resolver.ResolveStatements(statements)
+	interpreter.Interpret(statements)
+	assert.Equal(t, "first line\nnil\n", stdout.String())
+	assert.Equal(t, "second line\n", stderr.String())
+}
+
+func TestInterpreterNumberOperators(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+		expectedError string
+	}{
+		{"negation", "var a = -(1 + 2); a;", int64(-3), ""},
+		{"negation of a variable", "var a = 2; -a;", int64(-2), ""},
+		{"non-number operand", "(nil * 2) * (nil * 3);", nil, "[line 1] Error[interpreter]: operator *: operands must be numbers: cannot convert to float: <nil>\n"},
+		{"non-number negation", "-nil;", nil, "[line 1] Error[interpreter]: operator -: operand must be a number: cannot convert to float: <nil>\n"},
+	}
+	for _, testCase := range testCases {
+		lastValue, _, stderr := interpretSource(testCase.source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestInterpreterRuntimeErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"undefined variable", "print 1;\nundefined;", "[line 2] Error[interpreter]: undefined variable: undefined\n"},
+		{"undefined assignment", "undefined = 1;", "[line 1] Error[interpreter]: undefined variable: undefined\n"},
+		{"nested operand", "-(1 + nil);", "[line 1] Error[interpreter]: operator +: operands must be two numbers or at least one string\n"},
+	}
+	for _, testCase := range testCases {
+		_, _, stderr := interpretSource(testCase.source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+	}
+}
+
+func TestInterpreterRuntimeErrorLine(t *testing.T) {
+	errorReporter := NewConsoleErrorReporterWithWriter(ioutil.Discard)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), ioutil.Discard, ioutil.Discard)
+	parser := NewParser(NewScanner("\n1 + nil;", errorReporter).ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	_, err := interpreter.execute(statements[0])
+	var runtimeErr RuntimeError
+	if assert.True(t, errors.As(err, &runtimeErr)) {
+		assert.Equal(t, 2, runtimeErr.Line)
+	}
+}
+
+func TestInterpreterBlockStopsAtRuntimeError(t *testing.T) {
+	testCases := []struct {
+		name           string
+		source         string
+		expectedOutput string
+	}{
+		{"block", "{ print 1; 1 + nil; print 2; }", "1\n"},
+		{"function body", "fun f() { print \"a\"; nil + 1; print \"b\"; }\nf();", "a\n"},
+		{"nested block", "{ { nil + 1; print 1; } print 2; }", ""},
+	}
+	for _, testCase := range testCases {
+		_, stdout, stderr := interpretSource(testCase.source)
+		assert.NotEmpty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedOutput, stdout, testCase.name)
+	}
+}
+
+func TestInterpreterPrintRuntimeError(t *testing.T) {
+	_, stdout, stderr := interpretSource("print 1;\nprint -nil;")
+	assert.Equal(t, "1\n", stdout)
+	assert.Equal(t, "[line 2] Error[interpreter]: operator -: operand must be a number: cannot convert to float: <nil>\n", stderr)
+}
+
+func TestInterpreterWhileRuntimeError(t *testing.T) {
+	_, stdout, stderr := interpretSource("var i = 0;\nwhile (i < 3) {\n  i = i + 1;\n  print i;\n  nil + 1;\n}")
+	assert.Equal(t, "1\n", stdout)
+	assert.Equal(t, "[line 5] Error[interpreter]: operator +: operands must be two numbers or at least one string\n", stderr)
+}
+
+func TestInterpreterStopsAtRuntimeError(t *testing.T) {
+	var stdout bytes.Buffer
+	errorReporter := NewConsoleErrorReporterWithWriter(ioutil.Discard)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), &stdout, ioutil.Discard)
+	parser := NewParser(NewScanner("print 1;\nnil + 1;\nprint 2;", errorReporter).ScanTokens(), errorReporter)
+	_, err := interpreter.Interpret(parser.Parse())
+	assert.Equal(t, "1\n", stdout.String())
+	var runtimeErr RuntimeError
+	if assert.True(t, errors.As(err, &runtimeErr)) {
+		assert.Equal(t, 2, runtimeErr.Line)
+	}
+}
+
+// interpretSource runs source through the whole pipeline and returns the last
+// evaluated value along with everything written to stdout and stderr.
+func interpretSource(source string) (interface{}, string, string) {
+	var stdout, stderr bytes.Buffer
+	errorReporter := NewConsoleErrorReporterWithWriter(&stderr)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), &stdout, &stderr)
+	scanner := NewScanner(source, errorReporter)
+	parser := NewParser(scanner.ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	if errorReporter.HasError() {
+		return nil, stdout.String(), stderr.String()
+	}
+	resolver := NewResolver(&interpreter)
+	resolver.ResolveStatements(statements)
+	lastValue, _ := interpreter.Interpret(statements)
+	return lastValue, stdout.String(), stderr.String()
+}
+
+func TestInterpreterTailCalls(t *testing.T) {
+	// a million frames would overflow the Go stack without tail calls.
+	_, stdout, stderr := interpretSource(`fun count(n, total) {
+  if (n == 0) return total;
+  return count(n - 1, total + 1);
+}
+fun isEven(n) {
+  if (n == 0) return true;
+  return isOdd(n - 1);
+}
+fun isOdd(n) {
+  if (n == 0) return false;
+  return isEven(n - 1);
+}
+print count(1000000, 0);
+print isEven(1000000);
+`)
+	assert.Equal(t, "1000000\ntrue\n", stdout)
+	assert.Empty(t, stderr)
+}
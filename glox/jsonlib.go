@@ -0,0 +1,250 @@
+package glox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+func newJsonModule() *NativeModule {
+	return NewNativeModule("json", map[string]interface{}{
+		"parse":     NewNativeCallable("parse", 1, jsonParse),
+		"stringify": NewNativeCallable("stringify", -1, jsonStringify),
+	})
+}
+
+// jsonParse converts JSON text into Lox values: objects become maps, keeping
+// the order of their keys, arrays become lists and numbers become ints when
+// written without a fraction or an exponent, and floats otherwise.
+func jsonParse(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	text, err := stringArgument("parse", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	value, err := decodeJSONValue(decoder)
+	if err != nil {
+		return nil, jsonSyntaxError(text, decoder.InputOffset(), err)
+	}
+	end := decoder.InputOffset()
+	rest := text[end:]
+	if trimmed := strings.TrimLeft(rest, " \t\r\n"); len(trimmed) > 0 {
+		offset := end + int64(len(rest)-len(trimmed))
+		return nil, jsonSyntaxError(text, offset, fmt.Errorf("unexpected data after top-level value"))
+	}
+	return value, nil
+}
+
+func decodeJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if number, ok := token.(json.Number); ok {
+		return decodeJSONNumber(number)
+	}
+	delimiter, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	switch delimiter {
+	case '[':
+		elements := []interface{}{}
+		for decoder.More() {
+			element, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return NewListValue(elements), nil
+	case '{':
+		object := NewMapValue()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.Set(key, value)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return object, nil
+	}
+	return nil, fmt.Errorf("unexpected %v", delimiter)
+}
+
+// decodeJSONNumber returns an int for numbers without a fraction or an
+// exponent, unless too large for one, and a float otherwise.
+func decodeJSONNumber(number json.Number) (interface{}, error) {
+	if !strings.ContainsAny(number.String(), ".eE") {
+		if integer, err := number.Int64(); err == nil {
+			return integer, nil
+		}
+	}
+	return number.Float64()
+}
+
+// jsonSyntaxError describes a decoding error with the byte offset where it
+// happened, along with the matching line and column of the JSON text.
+func jsonSyntaxError(text string, offset int64, err error) error {
+	var syntaxError *json.SyntaxError
+	if errors.As(err, &syntaxError) {
+		// the decoder counts the offending byte as already read.
+		offset = syntaxError.Offset
+		if offset < int64(len(text)) {
+			offset--
+		}
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF || offset >= int64(len(text)) {
+		err = fmt.Errorf("unexpected end of JSON input")
+		offset = int64(len(text))
+	}
+	line := strings.Count(text[:offset], "\n") + 1
+	column := int(offset) - strings.LastIndex(text[:offset], "\n")
+	return fmt.Errorf("parse: %v at offset %d (line %d, column %d)", err, offset, line, column)
+}
+
+// jsonStringify converts a Lox value into JSON text. The optional indent
+// is either a number of spaces or the string used for each indentation level.
+func jsonStringify(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("stringify", arguments, 1, 2); err != nil {
+		return nil, err
+	}
+	indent := ""
+	if len(arguments) > 1 {
+		switch value := arguments[1].(type) {
+		case nil:
+		case string:
+			indent = value
+		case int64, float64:
+			spaces, err := intArgument("stringify", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			if spaces < 0 || spaces > 10 {
+				return nil, fmt.Errorf("stringify: indent must be between 0 and 10 spaces, got %d", spaces)
+			}
+			indent = strings.Repeat(" ", spaces)
+		default:
+			return nil, fmt.Errorf("stringify: argument 2 must be a number or a string, got %s", typeName(value))
+		}
+	}
+	encoder := jsonEncoder{
+		indent:   indent,
+		visiting: map[interface{}]bool{},
+	}
+	if err := encoder.encode(arguments[0], 0); err != nil {
+		return nil, fmt.Errorf("stringify: %w", err)
+	}
+	return encoder.builder.String(), nil
+}
+
+type jsonEncoder struct {
+	builder  strings.Builder
+	indent   string
+	visiting map[interface{}]bool
+}
+
+func (e *jsonEncoder) encode(value interface{}, depth int) error {
+	switch value := value.(type) {
+	case nil:
+		e.builder.WriteString("null")
+	case bool:
+		if value {
+			e.builder.WriteString("true")
+		} else {
+			e.builder.WriteString("false")
+		}
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return fmt.Errorf("cannot encode %s as JSON", formatNumber(value))
+		}
+		e.builder.WriteString(formatNumber(value))
+	case int64:
+		e.builder.WriteString(Stringify(value))
+	case string:
+		e.encodeString(value)
+	case *ListValue:
+		if e.visiting[value] {
+			return fmt.Errorf("cannot encode cyclic list as JSON")
+		}
+		e.visiting[value] = true
+		defer delete(e.visiting, value)
+		if len(value.Elements) == 0 {
+			e.builder.WriteString("[]")
+			return nil
+		}
+		e.builder.WriteString("[")
+		for i, element := range value.Elements {
+			if i > 0 {
+				e.builder.WriteString(",")
+			}
+			e.newline(depth + 1)
+			if err := e.encode(element, depth+1); err != nil {
+				return err
+			}
+		}
+		e.newline(depth)
+		e.builder.WriteString("]")
+	case *MapValue:
+		if e.visiting[value] {
+			return fmt.Errorf("cannot encode cyclic map as JSON")
+		}
+		e.visiting[value] = true
+		defer delete(e.visiting, value)
+		if value.Len() == 0 {
+			e.builder.WriteString("{}")
+			return nil
+		}
+		e.builder.WriteString("{")
+		for i, key := range value.keys {
+			if i > 0 {
+				e.builder.WriteString(",")
+			}
+			e.newline(depth + 1)
+			e.encodeString(Stringify(key))
+			e.builder.WriteString(":")
+			if len(e.indent) > 0 {
+				e.builder.WriteString(" ")
+			}
+			if err := e.encode(value.values[key], depth+1); err != nil {
+				return err
+			}
+		}
+		e.newline(depth)
+		e.builder.WriteString("}")
+	default:
+		return fmt.Errorf("cannot encode %s as JSON", typeName(value))
+	}
+	return nil
+}
+
+func (e *jsonEncoder) encodeString(value string) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	e.builder.Write(bytes.TrimRight(buffer.Bytes(), "\n"))
+}
+
+func (e *jsonEncoder) newline(depth int) {
+	if len(e.indent) == 0 {
+		return
+	}
+	e.builder.WriteString("\n")
+	e.builder.WriteString(strings.Repeat(e.indent, depth))
+}
@@ -0,0 +1,55 @@
+package glox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonModule(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+	}{
+		{"parse scalars", "var v = json.parse(\"[1.5, \\\"s\\\", true, null]\"); str(v);", "[1.5, \"s\", true, nil]"},
+		{"parse object keeps key order", "var v = json.parse(\"{\\\"b\\\": 1, \\\"a\\\": {\\\"c\\\": [2]}}\"); \",\".join(v.keys()) + v.get(\"a\").get(\"c\").get(0);", "b,a2"},
+		{"parse ints", "json.parse(\"[9007199254740993]\").get(0);", int64(9007199254740993)},
+		{"parse floats", "json.parse(\"[2.0, 1e2]\").get(1);", 100.0},
+		{"parse unicode", "json.parse(\"\\\"caf\\\\u00e9 \\\\ud83d\\\\ude00\\\"\");", "café 😀"},
+		{"stringify compact", "var m = map(); m.set(\"name\", \"a<b>\"); m.set(\"tags\", list(1, nil, false)); json.stringify(m);", "{\"name\":\"a<b>\",\"tags\":[1,null,false]}"},
+		{"stringify indented", "var m = map(); m.set(\"a\", list(1, 2)); m.set(\"b\", map()); json.stringify(m, 2);", "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {}\n}"},
+		{"stringify string indent", "json.stringify(list(list()), \"\\t\");", "[\n\t[]\n]"},
+		{"stringify escapes", "json.stringify(\"line\\n\\\"quoted\\\"\");", "\"line\\n\\\"quoted\\\"\""},
+		{"stringify non-string keys", "var m = map(); m.set(1, true); json.stringify(m);", "{\"1\":true}"},
+		{"round trip", "var text = \"{\\\"a\\\":[1,2.5,{\\\"b\\\":null}],\\\"c\\\":\\\"d\\\"}\"; json.stringify(json.parse(text)) == text;", true},
+		{"shared values are not cycles", "var l = list(1); json.stringify(list(l, l));", "[[1],[1]]"},
+	}
+	for _, testCase := range testCases {
+		lastValue, _, stderr := interpretSource(testCase.source)
+		assert.Empty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestJsonModuleErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"invalid character", "json.parse(\"[1, x]\");", "[line 1] Error[interpreter]: parse: invalid character 'x' looking for beginning of value at offset 4 (line 1, column 5)\n"},
+		{"error on later line", "json.parse(\"{\\n  \\\"a\\\": 1,\\n  \\\"b\\\" 2\\n}\");", "[line 1] Error[interpreter]: parse: invalid character '2' after object key at offset 18 (line 3, column 7)\n"},
+		{"unexpected end", "json.parse(\"{\\\"a\\\": [1\");", "[line 1] Error[interpreter]: parse: unexpected end of JSON input at offset 8 (line 1, column 9)\n"},
+		{"trailing data", "json.parse(\"1 2\");", "[line 1] Error[interpreter]: parse: unexpected data after top-level value at offset 2 (line 1, column 3)\n"},
+		{"empty input", "json.parse(\"\");", "[line 1] Error[interpreter]: parse: unexpected end of JSON input at offset 0 (line 1, column 1)\n"},
+		{"cyclic list", "var l = list(); l.push(l); json.stringify(l);", "[line 1] Error[interpreter]: stringify: cannot encode cyclic list as JSON\n"},
+		{"cyclic map", "var m = map(); m.set(\"self\", list(m)); json.stringify(m);", "[line 1] Error[interpreter]: stringify: cannot encode cyclic map as JSON\n"},
+		{"function", "json.stringify(clock);", "[line 1] Error[interpreter]: stringify: cannot encode function as JSON\n"},
+		{"nan", "json.stringify(0 / 0);", "[line 1] Error[interpreter]: stringify: cannot encode nan as JSON\n"},
+	}
+	for _, testCase := range testCases {
+		_, _, stderr := interpretSource(testCase.source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+	}
+}
@@ -0,0 +1,67 @@
+package glox
+
+import "fmt"
+
+type listMethod struct {
+	arity    int
+	function func(inter *Interpreter, receiver *ListValue, arguments []interface{}) (interface{}, error)
+}
+
+var listMethods = map[string]listMethod{
+	"length": {0, listLength},
+	"get":    {1, listGet},
+	"set":    {2, listSet},
+	"push":   {1, listPush},
+	"pop":    {0, listPop},
+}
+
+func getListMethod(receiver *ListValue, name string) (interface{}, error) {
+	method, ok := listMethods[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined list method: %s", name)
+	}
+	return NewNativeCallable(name, method.arity, func(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+		return method.function(inter, receiver, arguments)
+	}), nil
+}
+
+func listLength(inter *Interpreter, receiver *ListValue, arguments []interface{}) (interface{}, error) {
+	return int64(len(receiver.Elements)), nil
+}
+
+func listGet(inter *Interpreter, receiver *ListValue, arguments []interface{}) (interface{}, error) {
+	index, err := intArgument("get", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(receiver.Elements) {
+		return nil, fmt.Errorf("get: index %d out of range for list of length %d", index, len(receiver.Elements))
+	}
+	return receiver.Elements[index], nil
+}
+
+func listSet(inter *Interpreter, receiver *ListValue, arguments []interface{}) (interface{}, error) {
+	index, err := intArgument("set", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(receiver.Elements) {
+		return nil, fmt.Errorf("set: index %d out of range for list of length %d", index, len(receiver.Elements))
+	}
+	receiver.Elements[index] = arguments[1]
+	return arguments[1], nil
+}
+
+func listPush(inter *Interpreter, receiver *ListValue, arguments []interface{}) (interface{}, error) {
+	receiver.Elements = append(receiver.Elements, arguments[0])
+	return int64(len(receiver.Elements)), nil
+}
+
+func listPop(inter *Interpreter, receiver *ListValue, arguments []interface{}) (interface{}, error) {
+	if len(receiver.Elements) == 0 {
+		return nil, fmt.Errorf("pop: list is empty")
+	}
+	last := receiver.Elements[len(receiver.Elements)-1]
+	receiver.Elements = receiver.Elements[:len(receiver.Elements)-1]
+	return last, nil
+}
@@ -0,0 +1,415 @@
+package glox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const LSP_SOURCE = "glox"
+
+const (
+	LSP_ERROR_PARSE            = -32700
+	LSP_ERROR_INVALID_REQUEST  = -32600
+	LSP_ERROR_METHOD_NOT_FOUND = -32601
+	LSP_ERROR_INVALID_PARAMS   = -32602
+)
+
+// Kinds as numbered by the Language Server Protocol.
+const (
+	LSP_SEVERITY_ERROR          = 1
+	LSP_SYMBOL_KIND_FUNCTION    = 12
+	LSP_SYMBOL_KIND_VARIABLE    = 13
+	LSP_COMPLETION_FUNCTION     = 3
+	LSP_COMPLETION_VARIABLE     = 6
+	LSP_COMPLETION_MODULE       = 9
+	LSP_COMPLETION_KEYWORD      = 14
+	LSP_TEXT_DOCUMENT_SYNC_FULL = 1
+)
+
+var lspMemberPattern = regexp.MustCompile(`([A-Za-z_][A-Za-z_0-9]*)\.[A-Za-z_0-9]*$`)
+
+/*
+ * LspServer is a Language Server Protocol server for glox, speaking JSON-RPC
+ * over a pair of streams, normally stdin and stdout. Each open document is
+ * scanned, parsed and resolved on every change, with full document sync, to
+ * publish its errors as diagnostics and answer go to definition, find
+ * references, hover, document symbols and completion requests.
+ */
+type LspServer struct {
+	reader    *bufio.Reader
+	writer    io.Writer
+	documents map[string]*lspIndex
+	shutdown  bool
+}
+
+type lspMessage struct {
+	Id     *json.RawMessage `json:"id,omitempty"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+type lspError struct {
+	code    int
+	message string
+}
+
+func (e lspError) Error() string {
+	return e.message
+}
+
+type lspTextDocumentPosition struct {
+	TextDocument struct {
+		Uri string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+	Context  struct {
+		IncludeDeclaration bool `json:"includeDeclaration"`
+	} `json:"context"`
+}
+
+func NewLspServer(in io.Reader, out io.Writer) *LspServer {
+	return &LspServer{
+		reader:    bufio.NewReader(in),
+		writer:    out,
+		documents: map[string]*lspIndex{},
+	}
+}
+
+// Serve handles messages until the client sends exit or closes the input.
+func (s *LspServer) Serve() error {
+	for {
+		content, err := readFramedMessage(s.reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		message := lspMessage{}
+		if err := json.Unmarshal(content, &message); err != nil {
+			s.respond(nil, nil, lspError{LSP_ERROR_PARSE, err.Error()})
+			continue
+		}
+		if message.Method == "exit" {
+			return nil
+		}
+		result, err := s.handle(message)
+		if message.Id == nil {
+			// notifications get no response.
+			continue
+		}
+		s.respond(message.Id, result, err)
+	}
+}
+
+func (s *LspServer) write(message map[string]interface{}) {
+	message["jsonrpc"] = "2.0"
+	content, _ := json.Marshal(message)
+	writeFramedMessage(s.writer, content)
+}
+
+// readFramedMessage returns the content of the next message, framed by a
+// Content-Length header as in the Language Server and Debug Adapter
+// protocols.
+func readFramedMessage(reader *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		header := strings.SplitN(line, ":", 2)
+		if len(header) == 2 && strings.EqualFold(strings.TrimSpace(header[0]), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(header[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid Content-Length: %v", header[1])
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("missing Content-Length")
+	}
+	content := make([]byte, length)
+	_, err := io.ReadFull(reader, content)
+	return content, err
+}
+
+func writeFramedMessage(writer io.Writer, content []byte) {
+	fmt.Fprintf(writer, "Content-Length: %d\r\n\r\n%s", len(content), content)
+}
+
+func (s *LspServer) respond(id *json.RawMessage, result interface{}, err error) {
+	message := map[string]interface{}{"id": id}
+	if err != nil {
+		code := LSP_ERROR_INVALID_PARAMS
+		var protocolErr lspError
+		if errors.As(err, &protocolErr) {
+			code = protocolErr.code
+		}
+		message["error"] = map[string]interface{}{"code": code, "message": err.Error()}
+	} else {
+		message["result"] = result
+	}
+	s.write(message)
+}
+
+func (s *LspServer) notify(method string, params interface{}) {
+	s.write(map[string]interface{}{"method": method, "params": params})
+}
+
+func (s *LspServer) handle(message lspMessage) (interface{}, error) {
+	if s.shutdown {
+		return nil, lspError{LSP_ERROR_INVALID_REQUEST, "server is shut down"}
+	}
+	switch message.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":       LSP_TEXT_DOCUMENT_SYNC_FULL,
+				"definitionProvider":     true,
+				"referencesProvider":     true,
+				"hoverProvider":          true,
+				"documentSymbolProvider": true,
+				"completionProvider":     map[string]interface{}{"triggerCharacters": []string{"."}},
+			},
+			"serverInfo": map[string]interface{}{"name": LSP_SOURCE},
+		}, nil
+	case "initialized", "$/cancelRequest", "$/setTrace", "workspace/didChangeConfiguration":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		params := struct {
+			TextDocument struct {
+				Uri  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}{}
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			return nil, err
+		}
+		s.update(params.TextDocument.Uri, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		params := struct {
+			TextDocument struct {
+				Uri string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}{}
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) > 0 {
+			s.update(params.TextDocument.Uri, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		params := lspTextDocumentPosition{}
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.Uri)
+		s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+			"uri":         params.TextDocument.Uri,
+			"diagnostics": []interface{}{},
+		})
+		return nil, nil
+	case "textDocument/definition":
+		return s.withPosition(message, s.definition)
+	case "textDocument/references":
+		return s.withPosition(message, s.references)
+	case "textDocument/hover":
+		return s.withPosition(message, s.hover)
+	case "textDocument/completion":
+		return s.withPosition(message, s.completion)
+	case "textDocument/documentSymbol":
+		return s.withPosition(message, s.documentSymbols)
+	}
+	return nil, lspError{LSP_ERROR_METHOD_NOT_FOUND, "method not found: " + message.Method}
+}
+
+func (s *LspServer) withPosition(message lspMessage, handler func(uri string, index *lspIndex, params lspTextDocumentPosition) interface{}) (interface{}, error) {
+	params := lspTextDocumentPosition{}
+	if err := json.Unmarshal(message.Params, &params); err != nil {
+		return nil, err
+	}
+	index, ok := s.documents[params.TextDocument.Uri]
+	if !ok {
+		return nil, lspError{LSP_ERROR_INVALID_PARAMS, "unknown document: " + params.TextDocument.Uri}
+	}
+	return handler(params.TextDocument.Uri, index, params), nil
+}
+
+// update analyzes a new version of a document and publishes its
+// diagnostics.
+func (s *LspServer) update(uri string, text string) {
+	index := newLspIndex(text)
+	s.documents[uri] = index
+	diagnostics := []interface{}{}
+	for _, diagnostic := range index.diagnostics {
+		diagnostics = append(diagnostics, map[string]interface{}{
+			"range":    index.lineRange(diagnostic.line - 1),
+			"severity": LSP_SEVERITY_ERROR,
+			"source":   LSP_SOURCE,
+			"message":  diagnostic.message,
+		})
+	}
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+}
+
+func (s *LspServer) definition(uri string, index *lspIndex, params lspTextDocumentPosition) interface{} {
+	symbol, _, ok := index.symbolAt(params.Position)
+	if !ok || symbol.kind == LSP_SYMBOL_NATIVE {
+		return nil
+	}
+	return map[string]interface{}{"uri": uri, "range": index.tokenRange(symbol.name)}
+}
+
+func (s *LspServer) references(uri string, index *lspIndex, params lspTextDocumentPosition) interface{} {
+	locations := []interface{}{}
+	symbol, _, ok := index.symbolAt(params.Position)
+	if !ok {
+		return locations
+	}
+	if params.Context.IncludeDeclaration && symbol.kind != LSP_SYMBOL_NATIVE {
+		locations = append(locations, map[string]interface{}{"uri": uri, "range": index.tokenRange(symbol.name)})
+	}
+	for _, reference := range symbol.references {
+		locations = append(locations, map[string]interface{}{"uri": uri, "range": index.tokenRange(reference)})
+	}
+	return locations
+}
+
+func (s *LspServer) hover(uri string, index *lspIndex, params lspTextDocumentPosition) interface{} {
+	symbol, token, ok := index.symbolAt(params.Position)
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{
+		"contents": map[string]interface{}{
+			"kind":  "markdown",
+			"value": "```glox\n" + symbol.signature() + "\n```",
+		},
+		"range": index.tokenRange(token),
+	}
+}
+
+func (s *LspServer) documentSymbols(uri string, index *lspIndex, params lspTextDocumentPosition) interface{} {
+	return index.documentSymbols(nil)
+}
+
+func (s *LspServer) completion(uri string, index *lspIndex, params lspTextDocumentPosition) interface{} {
+	items := []interface{}{}
+	if module, ok := index.moduleBefore(params.Position); ok {
+		names := []string{}
+		for name := range module.members {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			symbol := &lspSymbol{name: NewToken(TOKEN_IDENTIFIER, name, name, 0), kind: LSP_SYMBOL_NATIVE, value: module.members[name]}
+			items = append(items, completionItem(symbol))
+		}
+		return items
+	}
+	for _, symbol := range index.visibleAt(params.Position) {
+		items = append(items, completionItem(symbol))
+	}
+	keywords := []string{}
+	for keyword := range Keywords {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+	for _, keyword := range keywords {
+		items = append(items, map[string]interface{}{"label": keyword, "kind": LSP_COMPLETION_KEYWORD})
+	}
+	return items
+}
+
+func completionItem(symbol *lspSymbol) map[string]interface{} {
+	kind := LSP_COMPLETION_VARIABLE
+	switch symbol.value.(type) {
+	case *NativeModule:
+		kind = LSP_COMPLETION_MODULE
+	case Callable:
+		kind = LSP_COMPLETION_FUNCTION
+	}
+	if symbol.kind == LSP_SYMBOL_FUNCTION {
+		kind = LSP_COMPLETION_FUNCTION
+	}
+	return map[string]interface{}{
+		"label":  symbol.name.Lexeme,
+		"kind":   kind,
+		"detail": symbol.signature(),
+	}
+}
+
+// moduleBefore returns the native module whose member is being typed at
+// position, as in "math.fl".
+func (index *lspIndex) moduleBefore(position lspPosition) (*NativeModule, bool) {
+	if position.Line < 0 || position.Line >= len(index.lines) {
+		return nil, false
+	}
+	units := utf16.Encode([]rune(index.lines[position.Line]))
+	if position.Character < len(units) {
+		units = units[:position.Character]
+	}
+	match := lspMemberPattern.FindStringSubmatch(string(utf16.Decode(units)))
+	if match == nil {
+		return nil, false
+	}
+	symbol, ok := index.natives[match[1]]
+	if !ok {
+		return nil, false
+	}
+	module, ok := symbol.value.(*NativeModule)
+	return module, ok
+}
+
+// documentSymbols returns the functions and variables declared directly in
+// parent, or at the top level, with the declarations nested in functions
+// as children.
+func (index *lspIndex) documentSymbols(parent *lspSymbol) []interface{} {
+	symbols := []interface{}{}
+	for _, symbol := range index.symbols {
+		if symbol.parent != parent || symbol.kind == LSP_SYMBOL_PARAMETER {
+			continue
+		}
+		selection := index.tokenRange(symbol.name)
+		documentSymbol := map[string]interface{}{
+			"name":           symbol.name.Lexeme,
+			"detail":         symbol.signature(),
+			"kind":           LSP_SYMBOL_KIND_VARIABLE,
+			"range":          selection,
+			"selectionRange": selection,
+		}
+		if symbol.kind == LSP_SYMBOL_FUNCTION {
+			end := index.scopeEnd(symbol.name, true)
+			end.Character++
+			documentSymbol["kind"] = LSP_SYMBOL_KIND_FUNCTION
+			documentSymbol["range"] = lspRange{Start: selection.Start, End: end}
+			documentSymbol["children"] = index.documentSymbols(symbol)
+		}
+		symbols = append(symbols, documentSymbol)
+	}
+	return symbols
+}
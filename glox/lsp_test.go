@@ -0,0 +1,319 @@
+package glox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const lspTestUri = "file:///test.glox"
+
+const lspTestSource = `var total = 0;
+fun add(a, b) {
+  var sum = a + b;
+  return sum;
+}
+total = add(total, 2);
+print math.floor(total);
+`
+
+// runLspSession sends messages to a new server and returns the messages it
+// writes back, decoded.
+func runLspSession(t *testing.T, messages ...map[string]interface{}) []map[string]interface{} {
+	var input, output bytes.Buffer
+	for _, message := range messages {
+		message["jsonrpc"] = "2.0"
+		content, err := json.Marshal(message)
+		assert.NoError(t, err)
+		fmt.Fprintf(&input, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	}
+	assert.NoError(t, NewLspServer(&input, &output).Serve())
+
+	responses := []map[string]interface{}{}
+	reader := bufio.NewReader(&output)
+	for {
+		header, err := reader.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		length, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "Content-Length:")))
+		assert.NoError(t, err)
+		reader.ReadString('\n')
+		content := make([]byte, length)
+		_, err = io.ReadFull(reader, content)
+		assert.NoError(t, err)
+		response := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(content, &response))
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+func lspRequest(id int, method string, params map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"id": id, "method": method, "params": params}
+}
+
+func lspPositionParams(line int, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": lspTestUri},
+		"position":     map[string]interface{}{"line": line, "character": character},
+		"context":      map[string]interface{}{"includeDeclaration": true},
+	}
+}
+
+func lspDidOpen(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"method": "textDocument/didOpen",
+		"params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": lspTestUri, "languageId": "glox", "version": 1, "text": text},
+		},
+	}
+}
+
+// lspResult returns the result of the response to request id.
+func lspResult(responses []map[string]interface{}, id int) interface{} {
+	for _, response := range responses {
+		if response["id"] == float64(id) {
+			return response["result"]
+		}
+	}
+	return nil
+}
+
+func lspRangeOf(line int, start int, end int) map[string]interface{} {
+	return map[string]interface{}{
+		"start": map[string]interface{}{"line": float64(line), "character": float64(start)},
+		"end":   map[string]interface{}{"line": float64(line), "character": float64(end)},
+	}
+}
+
+func TestLspInitialize(t *testing.T) {
+	responses := runLspSession(t,
+		lspRequest(1, "initialize", map[string]interface{}{}),
+		map[string]interface{}{"method": "initialized", "params": map[string]interface{}{}},
+		lspRequest(2, "workspace/symbol", map[string]interface{}{}),
+		lspRequest(3, "shutdown", nil),
+		map[string]interface{}{"method": "exit"},
+		lspRequest(4, "shutdown", nil),
+	)
+	if !assert.Len(t, responses, 3) {
+		return
+	}
+	capabilities := responses[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	assert.Equal(t, float64(LSP_TEXT_DOCUMENT_SYNC_FULL), capabilities["textDocumentSync"])
+	for _, provider := range []string{"definitionProvider", "referencesProvider", "hoverProvider", "documentSymbolProvider"} {
+		assert.Equal(t, true, capabilities[provider], provider)
+	}
+	assert.Equal(t, float64(LSP_ERROR_METHOD_NOT_FOUND), responses[1]["error"].(map[string]interface{})["code"])
+	assert.Equal(t, float64(3), responses[2]["id"])
+	assert.Nil(t, responses[2]["error"])
+}
+
+func TestLspDiagnostics(t *testing.T) {
+	responses := runLspSession(t,
+		lspDidOpen("var a = 1;\nprint (a;\n"),
+		map[string]interface{}{
+			"method": "textDocument/didChange",
+			"params": map[string]interface{}{
+				"textDocument":   map[string]interface{}{"uri": lspTestUri, "version": 2},
+				"contentChanges": []interface{}{map[string]interface{}{"text": "{\n  var a = a;\n}\n"}},
+			},
+		},
+		map[string]interface{}{
+			"method": "textDocument/didChange",
+			"params": map[string]interface{}{
+				"textDocument":   map[string]interface{}{"uri": lspTestUri, "version": 3},
+				"contentChanges": []interface{}{map[string]interface{}{"text": "print 1;\n"}},
+			},
+		},
+	)
+	if !assert.Len(t, responses, 3) {
+		return
+	}
+	expected := []interface{}{
+		[]interface{}{map[string]interface{}{
+			"range":    lspRangeOf(1, 0, 9),
+			"severity": float64(LSP_SEVERITY_ERROR),
+			"source":   LSP_SOURCE,
+			"message":  "ParseError: Expect ')' after expression.",
+		}},
+		[]interface{}{map[string]interface{}{
+			"range":    lspRangeOf(1, 0, 12),
+			"severity": float64(LSP_SEVERITY_ERROR),
+			"source":   LSP_SOURCE,
+			"message":  "can't read local variable in its own initializer",
+		}},
+		[]interface{}{},
+	}
+	for i, response := range responses {
+		assert.Equal(t, "textDocument/publishDiagnostics", response["method"])
+		params := response["params"].(map[string]interface{})
+		assert.Equal(t, lspTestUri, params["uri"])
+		assert.Equal(t, expected[i], params["diagnostics"], "version %d", i+1)
+	}
+}
+
+func TestLspDiagnosticsBeforeAnyStatement(t *testing.T) {
+	responses := runLspSession(t, lspDidOpen("}}}"))
+	if !assert.Len(t, responses, 1) {
+		return
+	}
+	params := responses[0]["params"].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"range":    lspRangeOf(0, 0, 3),
+		"severity": float64(LSP_SEVERITY_ERROR),
+		"source":   LSP_SOURCE,
+		"message":  "ParseError: Expected expression.",
+	}}, params["diagnostics"])
+}
+
+func TestLspNavigation(t *testing.T) {
+	responses := runLspSession(t,
+		lspDidOpen(lspTestSource),
+		// "sum" in return sum;
+		lspRequest(1, "textDocument/definition", lspPositionParams(3, 10)),
+		// "add" in its declaration.
+		lspRequest(2, "textDocument/references", lspPositionParams(1, 5)),
+		// "total" in the call.
+		lspRequest(3, "textDocument/references", lspPositionParams(5, 13)),
+		// "floor" is a property, not a name.
+		lspRequest(4, "textDocument/definition", lspPositionParams(6, 12)),
+		// "math" is a native.
+		lspRequest(5, "textDocument/definition", lspPositionParams(6, 7)),
+	)
+	assert.Equal(t, map[string]interface{}{"uri": lspTestUri, "range": lspRangeOf(2, 6, 9)}, lspResult(responses, 1))
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"uri": lspTestUri, "range": lspRangeOf(1, 4, 7)},
+		map[string]interface{}{"uri": lspTestUri, "range": lspRangeOf(5, 8, 11)},
+	}, lspResult(responses, 2))
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"uri": lspTestUri, "range": lspRangeOf(0, 4, 9)},
+		map[string]interface{}{"uri": lspTestUri, "range": lspRangeOf(5, 12, 17)},
+		map[string]interface{}{"uri": lspTestUri, "range": lspRangeOf(5, 0, 5)},
+		map[string]interface{}{"uri": lspTestUri, "range": lspRangeOf(6, 17, 22)},
+	}, lspResult(responses, 3))
+	assert.Nil(t, lspResult(responses, 4))
+	assert.Nil(t, lspResult(responses, 5))
+}
+
+func TestLspHover(t *testing.T) {
+	responses := runLspSession(t,
+		lspDidOpen(lspTestSource),
+		lspRequest(1, "textDocument/hover", lspPositionParams(5, 9)),
+		lspRequest(2, "textDocument/hover", lspPositionParams(2, 12)),
+		lspRequest(3, "textDocument/hover", lspPositionParams(0, 6)),
+		lspRequest(4, "textDocument/hover", lspPositionParams(6, 7)),
+		lspRequest(5, "textDocument/hover", lspPositionParams(4, 0)),
+	)
+	testCases := []struct {
+		id       int
+		expected string
+	}{
+		{1, "fun add(a, b)"},
+		{2, "(parameter) a of fun add(a, b)"},
+		{3, "var total"},
+		{4, "(module) math"},
+	}
+	for _, testCase := range testCases {
+		hover, ok := lspResult(responses, testCase.id).(map[string]interface{})
+		if !assert.True(t, ok, testCase.expected) {
+			continue
+		}
+		contents := hover["contents"].(map[string]interface{})
+		assert.Equal(t, "```glox\n"+testCase.expected+"\n```", contents["value"])
+	}
+	assert.Nil(t, lspResult(responses, 5))
+}
+
+func TestLspDocumentSymbols(t *testing.T) {
+	responses := runLspSession(t,
+		lspDidOpen(lspTestSource),
+		lspRequest(1, "textDocument/documentSymbol", lspPositionParams(0, 0)),
+	)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"name":           "total",
+			"detail":         "var total",
+			"kind":           float64(LSP_SYMBOL_KIND_VARIABLE),
+			"range":          lspRangeOf(0, 4, 9),
+			"selectionRange": lspRangeOf(0, 4, 9),
+		},
+		map[string]interface{}{
+			"name":   "add",
+			"detail": "fun add(a, b)",
+			"kind":   float64(LSP_SYMBOL_KIND_FUNCTION),
+			"range": map[string]interface{}{
+				"start": map[string]interface{}{"line": float64(1), "character": float64(4)},
+				"end":   map[string]interface{}{"line": float64(4), "character": float64(1)},
+			},
+			"selectionRange": lspRangeOf(1, 4, 7),
+			"children": []interface{}{
+				map[string]interface{}{
+					"name":           "sum",
+					"detail":         "var sum",
+					"kind":           float64(LSP_SYMBOL_KIND_VARIABLE),
+					"range":          lspRangeOf(2, 6, 9),
+					"selectionRange": lspRangeOf(2, 6, 9),
+				},
+			},
+		},
+	}, lspResult(responses, 1))
+}
+
+func TestLspCompletion(t *testing.T) {
+	responses := runLspSession(t,
+		lspDidOpen(lspTestSource),
+		// inside add, after "return ".
+		lspRequest(1, "textDocument/completion", lspPositionParams(3, 9)),
+		// at the top level, after add.
+		lspRequest(2, "textDocument/completion", lspPositionParams(6, 0)),
+		// after "math.".
+		lspRequest(3, "textDocument/completion", lspPositionParams(6, 11)),
+	)
+	labels := func(id int) []string {
+		result := []string{}
+		for _, item := range lspResult(responses, id).([]interface{}) {
+			result = append(result, item.(map[string]interface{})["label"].(string))
+		}
+		return result
+	}
+
+	inside := labels(1)
+	for _, name := range []string{"sum", "a", "b", "add", "total", "math", "clock", "while", "fun"} {
+		assert.Contains(t, inside, name)
+	}
+	assert.Equal(t, []string{"sum", "b", "a", "add", "total"}, inside[:5], "innermost names first")
+
+	outside := labels(2)
+	for _, name := range []string{"sum", "a", "b"} {
+		assert.NotContains(t, outside, name)
+	}
+	assert.Contains(t, outside, "add")
+
+	members := labels(3)
+	assert.Contains(t, members, "floor")
+	assert.Contains(t, members, "sqrt")
+	assert.NotContains(t, members, "while")
+}
+
+func TestLspIndexUtf16Positions(t *testing.T) {
+	index := newLspIndex("var s = \"😀é\"; var x = s;\n")
+	symbol, token, ok := index.symbolAt(lspPosition{Line: 0, Character: 19})
+	if assert.True(t, ok) {
+		assert.Equal(t, "x", token.Lexeme)
+		assert.Equal(t, "var x", symbol.signature())
+		assert.Equal(t, lspRange{Start: lspPosition{0, 19}, End: lspPosition{0, 20}}, index.tokenRange(token))
+	}
+	symbol, _, ok = index.symbolAt(lspPosition{Line: 0, Character: 23})
+	if assert.True(t, ok) {
+		assert.Equal(t, "s", symbol.name.Lexeme)
+	}
+}
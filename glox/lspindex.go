@@ -0,0 +1,447 @@
+package glox
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	LSP_SYMBOL_VARIABLE = iota
+	LSP_SYMBOL_FUNCTION
+	LSP_SYMBOL_PARAMETER
+	LSP_SYMBOL_NATIVE
+)
+
+// lspPosition is a zero-based position as used by the Language Server
+// Protocol, with characters counted in UTF-16 code units.
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+func (r lspRange) contains(position lspPosition) bool {
+	return !position.before(r.Start) && !r.End.before(position)
+}
+
+func (p lspPosition) before(other lspPosition) bool {
+	return p.Line < other.Line || (p.Line == other.Line && p.Character < other.Character)
+}
+
+type lspDiagnostic struct {
+	line    int
+	message string
+}
+
+// lspSymbol is a name declared in a document, or a native global.
+type lspSymbol struct {
+	name       Token
+	kind       int
+	params     []Token
+	value      interface{}
+	parent     *lspSymbol
+	references []Token
+	// visibleFrom and visibleTo delimit where the name can be used, for
+	// completion. Globals are visible everywhere.
+	global      bool
+	visibleFrom lspPosition
+	visibleTo   lspPosition
+}
+
+// lspIndex is the analysis of one version of a document: its diagnostics
+// from the Scanner, Parser and Resolver, and the declarations and uses of
+// every name.
+type lspIndex struct {
+	lines       []string
+	tokens      []Token
+	diagnostics []lspDiagnostic
+	symbols     []*lspSymbol
+	natives     map[string]*lspSymbol
+	uses        map[Token]*lspSymbol
+	// enclosing maps the position of each token to the index of the "{"
+	// it is inside of, or -1, and matching maps "{" to "}" indexes.
+	enclosing map[[2]int]int
+	matching  map[int]int
+
+	scopes     []map[string]*lspSymbol
+	function   *lspSymbol
+	unresolved []Token
+}
+
+// lspReporter collects the errors of an analysis as diagnostics.
+type lspReporter struct {
+	diagnostics []lspDiagnostic
+}
+
+func (r *lspReporter) Error(line int, message string) {
+	r.diagnostics = append(r.diagnostics, lspDiagnostic{line: line, message: message})
+}
+
+func (r *lspReporter) Push(line int, where string, err error) {
+	r.Error(line, err.Error())
+}
+
+func (r *lspReporter) HasError() bool {
+	return len(r.diagnostics) > 0
+}
+
+func (r *lspReporter) ClearError() {
+}
+
+func newLspIndex(text string) *lspIndex {
+	reporter := &lspReporter{diagnostics: []lspDiagnostic{}}
+	interpreter := NewInterpreterWithStreams(reporter, strings.NewReader(""), io.Discard, io.Discard)
+	tokens := NewScannerWithColumns(text, reporter).ScanTokens()
+	index := &lspIndex{
+		lines:   strings.Split(text, "\n"),
+		tokens:  tokens,
+		symbols: []*lspSymbol{},
+		natives: map[string]*lspSymbol{},
+		uses:    map[Token]*lspSymbol{},
+		scopes:  []map[string]*lspSymbol{{}},
+	}
+	// a bug in the analysis of a document must not take the server down,
+	// the document is left with what was found before it.
+	defer func() {
+		if r := recover(); r != nil {
+			reporter.Error(1, fmt.Sprintf("internal error: %v", r))
+			index.diagnostics = reporter.diagnostics
+		}
+	}()
+	parser := NewParser(tokens, reporter)
+	statements := parser.Parse()
+	resolver := NewResolver(&interpreter)
+	resolver.ResolveStatements(statements)
+	index.diagnostics = reporter.diagnostics
+
+	for name, value := range interpreter.globals.values {
+		index.natives[name] = &lspSymbol{name: NewToken(TOKEN_IDENTIFIER, name, name, 0), kind: LSP_SYMBOL_NATIVE, value: value, global: true}
+	}
+	index.matchBraces()
+	for _, stmt := range statements {
+		stmt.accept(index)
+	}
+	// globals can be used before their declaration, inside functions.
+	for _, name := range index.unresolved {
+		if symbol, ok := index.scopes[0][name.Lexeme]; ok {
+			index.use(name, symbol)
+		} else if native, ok := index.natives[name.Lexeme]; ok {
+			index.use(name, native)
+		}
+	}
+	return index
+}
+
+func (index *lspIndex) matchBraces() {
+	index.enclosing = map[[2]int]int{}
+	index.matching = map[int]int{}
+	open := []int{}
+	for i, token := range index.tokens {
+		if token.Type == TOKEN_RIGHT_BRACE && len(open) > 0 {
+			index.matching[open[len(open)-1]] = i
+			open = open[:len(open)-1]
+		}
+		enclosing := -1
+		if len(open) > 0 {
+			enclosing = open[len(open)-1]
+		}
+		index.enclosing[[2]int{token.Line, token.Column}] = enclosing
+		if token.Type == TOKEN_LEFT_BRACE {
+			open = append(open, i)
+		}
+	}
+}
+
+// scopeEnd returns the position where the block containing token, or the
+// body of the function whose parameter it is, ends.
+func (index *lspIndex) scopeEnd(token Token, parameter bool) lspPosition {
+	brace := index.enclosing[[2]int{token.Line, token.Column}]
+	if parameter {
+		brace = -1
+		for i, other := range index.tokens {
+			if other.Type == TOKEN_LEFT_BRACE && (other.Line > token.Line || (other.Line == token.Line && other.Column > token.Column)) {
+				brace = i
+				break
+			}
+		}
+	}
+	closing, ok := index.matching[brace]
+	if brace < 0 || !ok {
+		return lspPosition{Line: len(index.lines), Character: 0}
+	}
+	return index.tokenRange(index.tokens[closing]).Start
+}
+
+func (index *lspIndex) declare(name Token, kind int) *lspSymbol {
+	symbol := &lspSymbol{
+		name:        name,
+		kind:        kind,
+		parent:      index.function,
+		global:      len(index.scopes) == 1,
+		visibleFrom: index.tokenRange(name).Start,
+		visibleTo:   index.scopeEnd(name, kind == LSP_SYMBOL_PARAMETER),
+	}
+	index.scopes[len(index.scopes)-1][name.Lexeme] = symbol
+	index.symbols = append(index.symbols, symbol)
+	index.uses[name] = symbol
+	return symbol
+}
+
+func (index *lspIndex) use(name Token, symbol *lspSymbol) {
+	symbol.references = append(symbol.references, name)
+	index.uses[name] = symbol
+}
+
+func (index *lspIndex) resolve(name Token) {
+	for i := len(index.scopes) - 1; i > 0; i-- {
+		if symbol, ok := index.scopes[i][name.Lexeme]; ok {
+			index.use(name, symbol)
+			return
+		}
+	}
+	index.unresolved = append(index.unresolved, name)
+}
+
+func (index *lspIndex) beginScope() {
+	index.scopes = append(index.scopes, map[string]*lspSymbol{})
+}
+
+func (index *lspIndex) endScope() {
+	index.scopes = index.scopes[:len(index.scopes)-1]
+}
+
+// tokenRange returns the range a single-line token covers.
+func (index *lspIndex) tokenRange(token Token) lspRange {
+	line := tokenStartLine(token) - 1
+	start := index.character(line, token.Column-1)
+	return lspRange{
+		Start: lspPosition{Line: line, Character: start},
+		End:   lspPosition{Line: line, Character: start + len(utf16.Encode([]rune(token.Lexeme)))},
+	}
+}
+
+// character converts a column in runes into UTF-16 code units.
+func (index *lspIndex) character(line int, column int) int {
+	if line < 0 || line >= len(index.lines) {
+		return 0
+	}
+	runes := []rune(index.lines[line])
+	if column > len(runes) {
+		column = len(runes)
+	}
+	return len(utf16.Encode(runes[:column]))
+}
+
+func (index *lspIndex) lineRange(line int) lspRange {
+	if line >= len(index.lines) {
+		line = len(index.lines) - 1
+	}
+	if line < 0 {
+		line = 0
+	}
+	return lspRange{
+		Start: lspPosition{Line: line, Character: 0},
+		End:   lspPosition{Line: line, Character: index.character(line, len([]rune(index.lines[line])))},
+	}
+}
+
+// symbolAt returns the symbol declared or used by the identifier at
+// position, if any, along with that identifier.
+func (index *lspIndex) symbolAt(position lspPosition) (*lspSymbol, Token, bool) {
+	for _, token := range index.tokens {
+		if token.Type != TOKEN_IDENTIFIER || !index.tokenRange(token).contains(position) {
+			continue
+		}
+		symbol, ok := index.uses[token]
+		return symbol, token, ok
+	}
+	return nil, Token{}, false
+}
+
+// signature describes a symbol for hover and completion.
+func (symbol *lspSymbol) signature() string {
+	switch symbol.kind {
+	case LSP_SYMBOL_FUNCTION:
+		return "fun " + symbol.name.Lexeme + "(" + joinLexemes(symbol.params) + ")"
+	case LSP_SYMBOL_PARAMETER:
+		return "(parameter) " + symbol.name.Lexeme + " of fun " + symbol.parent.name.Lexeme + "(" + joinLexemes(symbol.parent.params) + ")"
+	case LSP_SYMBOL_NATIVE:
+		switch value := symbol.value.(type) {
+		case *NativeModule:
+			return "(module) " + value.name
+		case Callable:
+			if value.getArity() < 0 {
+				return "(native) fun " + symbol.name.Lexeme + "(...)"
+			}
+			return fmt.Sprintf("(native) fun %s/%d", symbol.name.Lexeme, value.getArity())
+		}
+		return "(native) " + symbol.name.Lexeme
+	}
+	return "var " + symbol.name.Lexeme
+}
+
+func joinLexemes(tokens []Token) string {
+	names := []string{}
+	for _, token := range tokens {
+		names = append(names, token.Lexeme)
+	}
+	return strings.Join(names, ", ")
+}
+
+// visibleAt returns the symbols that can be used at position, innermost
+// first, and then the natives, sorted by name.
+func (index *lspIndex) visibleAt(position lspPosition) []*lspSymbol {
+	visible := []*lspSymbol{}
+	seen := map[string]bool{}
+	for i := len(index.symbols) - 1; i >= 0; i-- {
+		symbol := index.symbols[i]
+		if seen[symbol.name.Lexeme] {
+			continue
+		}
+		inScope := !position.before(symbol.visibleFrom) && !symbol.visibleTo.before(position)
+		if symbol.global || inScope {
+			visible = append(visible, symbol)
+			seen[symbol.name.Lexeme] = true
+		}
+	}
+	names := []string{}
+	for name := range index.natives {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		visible = append(visible, index.natives[name])
+	}
+	return visible
+}
+
+func (index *lspIndex) visitBlockStmt(stmt BlockStmt) (interface{}, error) {
+	index.beginScope()
+	for _, statement := range stmt.Statements {
+		statement.accept(index)
+	}
+	index.endScope()
+	return nil, nil
+}
+
+func (index *lspIndex) visitExpressionStmt(stmt ExpressionStmt) (interface{}, error) {
+	return stmt.Expression.accept(index)
+}
+
+func (index *lspIndex) visitPrintStmt(stmt PrintStmt) (interface{}, error) {
+	return stmt.Print.accept(index)
+}
+
+func (index *lspIndex) visitVarStmt(stmt VarStmt) (interface{}, error) {
+	if stmt.Initializer != nil {
+		stmt.Initializer.accept(index)
+	}
+	index.declare(stmt.Name, LSP_SYMBOL_VARIABLE)
+	return nil, nil
+}
+
+func (index *lspIndex) visitIfStmt(stmt IfStmt) (interface{}, error) {
+	stmt.Condition.accept(index)
+	stmt.ThenBranch.accept(index)
+	if stmt.ElseBranch != nil {
+		stmt.ElseBranch.accept(index)
+	}
+	return nil, nil
+}
+
+func (index *lspIndex) visitWhileStmt(stmt WhileStmt) (interface{}, error) {
+	stmt.Condition.accept(index)
+	return stmt.Body.accept(index)
+}
+
+func (index *lspIndex) visitBreakStmt(stmt BreakStmt) (interface{}, error) {
+	return nil, nil
+}
+
+func (index *lspIndex) visitContinueStmt(stmt ContinueStmt) (interface{}, error) {
+	return nil, nil
+}
+
+func (index *lspIndex) visitFunctionStmt(stmt FunctionStmt) (interface{}, error) {
+	function := index.declare(stmt.Name, LSP_SYMBOL_FUNCTION)
+	function.params = stmt.Params
+	enclosing := index.function
+	index.function = function
+	index.beginScope()
+	for _, param := range stmt.Params {
+		index.declare(param, LSP_SYMBOL_PARAMETER)
+	}
+	for _, statement := range stmt.Body {
+		statement.accept(index)
+	}
+	index.endScope()
+	index.function = enclosing
+	return nil, nil
+}
+
+func (index *lspIndex) visitReturnStmt(stmt ReturnStmt) (interface{}, error) {
+	if stmt.Value != nil {
+		stmt.Value.accept(index)
+	}
+	return nil, nil
+}
+
+func (index *lspIndex) visitBinaryExpr(expr BinaryExpr) (interface{}, error) {
+	expr.Left.accept(index)
+	return expr.Right.accept(index)
+}
+
+func (index *lspIndex) visitConditionalExpr(expr ConditionalExpr) (interface{}, error) {
+	expr.Condition.accept(index)
+	expr.Left.accept(index)
+	return expr.Right.accept(index)
+}
+
+func (index *lspIndex) visitGroupingExpr(expr GroupingExpr) (interface{}, error) {
+	return expr.Expression.accept(index)
+}
+
+func (index *lspIndex) visitLiteralExpr(expr LiteralExpr) (interface{}, error) {
+	return nil, nil
+}
+
+func (index *lspIndex) visitLogicalExpr(expr LogicalExpr) (interface{}, error) {
+	expr.Left.accept(index)
+	return expr.Right.accept(index)
+}
+
+func (index *lspIndex) visitUnaryExpr(expr UnaryExpr) (interface{}, error) {
+	return expr.Right.accept(index)
+}
+
+func (index *lspIndex) visitVariableExpr(expr VariableExpr) (interface{}, error) {
+	index.resolve(expr.Name)
+	return nil, nil
+}
+
+func (index *lspIndex) visitAssignExpr(expr AssignExpr) (interface{}, error) {
+	expr.Value.accept(index)
+	index.resolve(expr.Name)
+	return nil, nil
+}
+
+func (index *lspIndex) visitCallExpr(expr CallExpr) (interface{}, error) {
+	expr.Callee.accept(index)
+	for _, argument := range expr.Arguments {
+		argument.accept(index)
+	}
+	return nil, nil
+}
+
+func (index *lspIndex) visitGetExpr(expr GetExpr) (interface{}, error) {
+	return expr.Object.accept(index)
+}
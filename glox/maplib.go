@@ -0,0 +1,96 @@
+package glox
+
+import "fmt"
+
+type mapMethod struct {
+	arity    int
+	function func(inter *Interpreter, receiver *MapValue, arguments []interface{}) (interface{}, error)
+}
+
+var mapMethods = map[string]mapMethod{
+	"length": {0, mapLength},
+	"get":    {1, mapGet},
+	"set":    {2, mapSet},
+	"has":    {1, mapHas},
+	"remove": {1, mapRemove},
+	"keys":   {0, mapKeys},
+	"values": {0, mapValues},
+}
+
+func getMapMethod(receiver *MapValue, name string) (interface{}, error) {
+	method, ok := mapMethods[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined map method: %s", name)
+	}
+	return NewNativeCallable(name, method.arity, func(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+		return method.function(inter, receiver, arguments)
+	}), nil
+}
+
+// mapKeyArgument checks that a map key is a hashable Lox value. Floats
+// holding an integer are keyed as that int, since they are equal.
+func mapKeyArgument(name string, arguments []interface{}, index int) (interface{}, error) {
+	switch key := arguments[index].(type) {
+	case float64:
+		return mapKeyNumber(key), nil
+	case nil, bool, int64, string:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%s: a %s cannot be used as a map key", name, typeName(key))
+	}
+}
+
+func mapLength(inter *Interpreter, receiver *MapValue, arguments []interface{}) (interface{}, error) {
+	return int64(receiver.Len()), nil
+}
+
+// mapGet returns nil for missing keys, use has() to tell them apart from
+// keys explicitly set to nil.
+func mapGet(inter *Interpreter, receiver *MapValue, arguments []interface{}) (interface{}, error) {
+	key, err := mapKeyArgument("get", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := receiver.Get(key)
+	return value, nil
+}
+
+func mapSet(inter *Interpreter, receiver *MapValue, arguments []interface{}) (interface{}, error) {
+	key, err := mapKeyArgument("set", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	receiver.Set(key, arguments[1])
+	return arguments[1], nil
+}
+
+func mapHas(inter *Interpreter, receiver *MapValue, arguments []interface{}) (interface{}, error) {
+	key, err := mapKeyArgument("has", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	_, ok := receiver.Get(key)
+	return ok, nil
+}
+
+func mapRemove(inter *Interpreter, receiver *MapValue, arguments []interface{}) (interface{}, error) {
+	key, err := mapKeyArgument("remove", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := receiver.Get(key)
+	receiver.Delete(key)
+	return value, nil
+}
+
+func mapKeys(inter *Interpreter, receiver *MapValue, arguments []interface{}) (interface{}, error) {
+	return NewListValue(receiver.Keys()), nil
+}
+
+func mapValues(inter *Interpreter, receiver *MapValue, arguments []interface{}) (interface{}, error) {
+	values := []interface{}{}
+	for _, key := range receiver.keys {
+		values = append(values, receiver.values[key])
+	}
+	return NewListValue(values), nil
+}
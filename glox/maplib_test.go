@@ -0,0 +1,65 @@
+package glox
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// interpretWithMap runs source with a global m holding the map {"a": 1, "b": 2}.
+func interpretWithMap(source string) (interface{}, string) {
+	var stderr bytes.Buffer
+	errorReporter := NewConsoleErrorReporterWithWriter(&stderr)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), ioutil.Discard, &stderr)
+	m := NewMapValue()
+	m.Set("a", 1.0)
+	m.Set("b", 2.0)
+	interpreter.globals.Define("m", m)
+	parser := NewParser(NewScanner(source, errorReporter).ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	resolver := NewResolver(&interpreter)
+	resolver.ResolveStatements(statements)
+	lastValue, _ := interpreter.Interpret(statements)
+	return lastValue, stderr.String()
+}
+
+func TestMapMethods(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+	}{
+		{"get", "m.get(\"a\");", 1.0},
+		{"get missing key", "m.get(\"z\");", nil},
+		{"set", "m.set(\"c\", 3); m.get(\"c\");", int64(3)},
+		{"has", "m.has(\"a\") and !m.has(\"z\");", true},
+		{"remove", "m.remove(\"a\"); m.has(\"a\");", false},
+		{"remove returns the value", "m.remove(\"b\");", 2.0},
+		{"length", "m.length();", int64(2)},
+		{"keys keep insertion order", "m.set(\"0\", nil); \",\".join(m.keys());", "a,b,0"},
+		{"values", "m.values().get(1);", 2.0},
+	}
+	for _, testCase := range testCases {
+		lastValue, stderr := interpretWithMap(testCase.source)
+		assert.Empty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestMapErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"unhashable key", "m.set(m, 1);", "[line 1] Error[interpreter]: set: a map cannot be used as a map key\n"},
+		{"undefined method", "m.size();", "[line 1] Error[interpreter]: undefined map method: size\n"},
+	}
+	for _, testCase := range testCases {
+		_, stderr := interpretWithMap(testCase.source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+	}
+}
@@ -0,0 +1,200 @@
+package glox
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+func newMathModule() *NativeModule {
+	return NewNativeModule("math", map[string]interface{}{
+		"pi":         math.Pi,
+		"e":          math.E,
+		"floor":      mathRounding("floor", math.Floor),
+		"ceil":       mathRounding("ceil", math.Ceil),
+		"trunc":      mathRounding("trunc", math.Trunc),
+		"abs":        NewNativeCallable("abs", 1, mathAbs),
+		"sqrt":       mathFunction("sqrt", math.Sqrt),
+		"sin":        mathFunction("sin", math.Sin),
+		"cos":        mathFunction("cos", math.Cos),
+		"tan":        mathFunction("tan", math.Tan),
+		"asin":       mathFunction("asin", math.Asin),
+		"acos":       mathFunction("acos", math.Acos),
+		"atan":       mathFunction("atan", math.Atan),
+		"exp":        mathFunction("exp", math.Exp),
+		"log":        mathFunction("log", math.Log),
+		"log2":       mathFunction("log2", math.Log2),
+		"log10":      mathFunction("log10", math.Log10),
+		"atan2":      NewNativeCallable("atan2", 2, mathAtan2),
+		"pow":        NewNativeCallable("pow", 2, mathPow),
+		"round":      NewNativeCallable("round", -1, mathRound),
+		"min":        NewNativeCallable("min", -1, mathMin),
+		"max":        NewNativeCallable("max", -1, mathMax),
+		"isNaN":      NewNativeCallable("isNaN", 1, mathIsNaN),
+		"isInfinite": NewNativeCallable("isInfinite", 1, mathIsInfinite),
+	})
+}
+
+// mathFunction wraps a float64 function of a single argument as a native.
+func mathFunction(name string, function func(float64) float64) NativeCallable {
+	return NewNativeCallable(name, 1, func(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+		x, err := numberArgument(name, arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		return function(x), nil
+	})
+}
+
+// mathRounding wraps a float64 function rounding to an integer as a native,
+// which returns ints as they are.
+func mathRounding(name string, function func(float64) float64) NativeCallable {
+	return NewNativeCallable(name, 1, func(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+		if integer, ok := arguments[0].(int64); ok {
+			return integer, nil
+		}
+		x, err := numberArgument(name, arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		return function(x), nil
+	})
+}
+
+// mathAbs keeps ints exact, failing for the smallest one, whose absolute
+// value is too large.
+func mathAbs(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if integer, ok := arguments[0].(int64); ok {
+		if integer == math.MinInt64 {
+			return nil, fmt.Errorf("abs: integer overflow")
+		}
+		if integer < 0 {
+			return -integer, nil
+		}
+		return integer, nil
+	}
+	x, err := numberArgument("abs", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return math.Abs(x), nil
+}
+
+func mathAtan2(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	y, err := numberArgument("atan2", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	x, err := numberArgument("atan2", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	return math.Atan2(y, x), nil
+}
+
+func mathPow(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	x, err := numberArgument("pow", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	y, err := numberArgument("pow", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	return math.Pow(x, y), nil
+}
+
+// mathRound rounds half away from zero, optionally to a number of decimal
+// places: math.round(2.345, 2) is 2.35. Ints are returned as they are.
+func mathRound(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("round", arguments, 1, 2); err != nil {
+		return nil, err
+	}
+	if len(arguments) == 2 {
+		if _, err := intArgument("round", arguments, 1); err != nil {
+			return nil, err
+		}
+	}
+	if integer, ok := arguments[0].(int64); ok {
+		return integer, nil
+	}
+	x, err := numberArgument("round", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(arguments) == 1 {
+		return math.Round(x), nil
+	}
+	digits, err := intArgument("round", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	if digits < 0 || digits > 15 {
+		return nil, fmt.Errorf("round: digits must be between 0 and 15, got %d", digits)
+	}
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return x, nil
+	}
+	// Round the shortest decimal representation of x, so that 2.345 rounds
+	// to 2.35 even though it is stored as 2.34499999...
+	decimal, _ := new(big.Rat).SetString(strconv.FormatFloat(x, 'f', -1, 64))
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil))
+	decimal.Mul(decimal, scale)
+	half := big.NewRat(1, 2)
+	if decimal.Sign() < 0 {
+		decimal.Sub(decimal, half)
+	} else {
+		decimal.Add(decimal, half)
+	}
+	rounded := new(big.Int).Quo(decimal.Num(), decimal.Denom())
+	result, _ := new(big.Rat).SetFrac(rounded, scale.Num()).Float64()
+	return result, nil
+}
+
+func mathMin(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return mathReduce("min", arguments, TOKEN_LESS)
+}
+
+func mathMax(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return mathReduce("max", arguments, TOKEN_GREATER)
+}
+
+// mathReduce returns the argument no other one compares to with operator,
+// ints and floats compared exactly and returned as they are. Any NaN
+// argument makes the result NaN.
+func mathReduce(name string, arguments []interface{}, operator int) (interface{}, error) {
+	if len(arguments) == 0 {
+		return nil, fmt.Errorf("%s: expected at least 1 argument but got 0", name)
+	}
+	var result interface{}
+	for i, x := range arguments {
+		value, err := numberArgument(name, arguments, i)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(value) {
+			return value, nil
+		}
+		if result == nil || compareNumbers(operator, x, result) {
+			result = x
+		}
+	}
+	return result, nil
+}
+
+func mathIsNaN(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	x, err := numberArgument("isNaN", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return math.IsNaN(x), nil
+}
+
+func mathIsInfinite(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	x, err := numberArgument("isInfinite", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return math.IsInf(x, 0), nil
+}
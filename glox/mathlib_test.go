@@ -0,0 +1,101 @@
+package glox
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMathOperators(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+	}{
+		{"negation", "-3 + 1;", int64(-2)},
+		{"modulo", "7 % 3;", int64(1)},
+		{"floored modulo", "-7 % 3;", int64(2)},
+		{"modulo negative divisor", "7 % -3;", int64(-2)},
+		{"fractional modulo", "5.5 % 2;", 1.5},
+		{"integer division", "7 ~/ 2;", int64(3)},
+		{"floored integer division", "-7 ~/ 2;", int64(-4)},
+		{"exponent", "2 ** 10;", int64(1024)},
+		{"exponent is right-associative", "2 ** 3 ** 2;", int64(512)},
+		{"exponent binds tighter than unary minus", "-2 ** 2;", int64(-4)},
+		{"exponent binds tighter than factor", "3 * 2 ** 2 % 5;", int64(2)},
+	}
+	for _, testCase := range testCases {
+		lastValue, _, stderr := interpretSource(testCase.source)
+		assert.Empty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestMathModule(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+	}{
+		{"floor", "math.floor(-2.5);", -3.0},
+		{"ceil", "math.ceil(2.1);", 3.0},
+		{"trunc", "math.trunc(-2.7);", -2.0},
+		{"round", "math.round(2.5);", 3.0},
+		{"round negative", "math.round(-2.5);", -3.0},
+		{"round to digits", "math.round(2.345, 2);", 2.35},
+		{"round price", "math.round(19.99 * 3, 2);", 59.97},
+		{"floor of an int", "math.floor(9007199254740993);", int64(9007199254740993)},
+		{"ceil of an int", "math.ceil(-3);", int64(-3)},
+		{"trunc of an int", "math.trunc(7);", int64(7)},
+		{"round of an int", "math.round(9007199254740993);", int64(9007199254740993)},
+		{"round an int to digits", "math.round(5, 2);", int64(5)},
+		{"abs", "math.abs(-4);", int64(4)},
+		{"abs of a float", "math.abs(-4.5);", 4.5},
+		{"abs of a big int", "math.abs(-9007199254740993);", int64(9007199254740993)},
+		{"sqrt", "math.sqrt(16);", 4.0},
+		{"pow", "math.pow(2, 0.5) == math.sqrt(2);", true},
+		{"trig", "math.sin(0) + math.cos(0) + math.tan(0);", 1.0},
+		{"inverse trig", "math.atan2(1, 1) * 4 == math.pi;", true},
+		{"log and exp", "math.log(math.exp(2));", 2.0},
+		{"log10", "math.log10(1000);", 3.0},
+		{"log2", "math.log2(8);", 3.0},
+		{"min", "math.min(3, 1, 2);", int64(1)},
+		{"max", "math.max(3, 1, 2);", int64(3)},
+		{"min of ints and floats", "math.min(3, 1.5, 2);", 1.5},
+		{"max of ints and floats", "math.max(2.5, 3, 2);", int64(3)},
+		{"max of big ints", "math.max(9007199254740993, 1);", int64(9007199254740993)},
+		{"max of a big int and a float", "math.max(9007199254740992.0, 9007199254740993);", int64(9007199254740993)},
+		{"min with NaN", "math.isNaN(math.min(1, 0 / 0, 2));", true},
+		{"e", "math.e;", math.E},
+		{"isNaN", "math.isNaN(math.sqrt(-1));", true},
+		{"isInfinite", "math.isInfinite(1 / 0);", true},
+	}
+	for _, testCase := range testCases {
+		lastValue, _, stderr := interpretSource(testCase.source)
+		assert.Empty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestMathErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"modulo by zero", "1 % 0;", "[line 1] Error[interpreter]: operator %: modulo by zero\n"},
+		{"integer division by zero", "1 ~/ 0;", "[line 1] Error[interpreter]: operator ~/: integer division by zero\n"},
+		{"non-number operand", "true ** 2;", "[line 1] Error[interpreter]: operator **: operands must be numbers: cannot convert to float: true\n"},
+		{"negate non-number", "-nil;", "[line 1] Error[interpreter]: operator -: operand must be a number: cannot convert to float: <nil>\n"},
+		{"non-number argument", "math.floor(\"x\");", "[line 1] Error[interpreter]: floor: argument 1 must be a number, got string\n"},
+		{"abs overflow", "math.abs(-9223372036854775807 - 1);", "[line 1] Error[interpreter]: abs: integer overflow\n"},
+		{"min of a non-number", "math.min(1, \"2\");", "[line 1] Error[interpreter]: min: argument 2 must be a number, got string\n"},
+		{"min without arguments", "math.min();", "[line 1] Error[interpreter]: min: expected at least 1 argument but got 0\n"},
+		{"undefined member", "math.tau;", "[line 1] Error[interpreter]: undefined property 'tau' in module math\n"},
+	}
+	for _, testCase := range testCases {
+		_, _, stderr := interpretSource(testCase.source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+	}
+}
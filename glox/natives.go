@@ -0,0 +1,159 @@
+package glox
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// NativeModule groups related natives under a single global name, so that
+// scripts reach them as properties, e.g. math.floor(x).
+type NativeModule struct {
+	name    string
+	members map[string]interface{}
+}
+
+func NewNativeModule(name string, members map[string]interface{}) *NativeModule {
+	return &NativeModule{
+		name:    name,
+		members: members,
+	}
+}
+
+func (m *NativeModule) get(name string) (interface{}, error) {
+	member, ok := m.members[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined property '%s' in module %s", name, m.name)
+	}
+	return member, nil
+}
+
+// nativeReadLine reads the next line from the interpreter's input stream,
+// without the line terminator. It returns nil once the input is exhausted.
+func nativeReadLine(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	line, err := inter.stdin.ReadString('\n')
+	if err == io.EOF {
+		if len(line) == 0 {
+			return nil, nil
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func nativePrintErr(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	fmt.Fprintln(inter.stderr, Stringify(arguments[0]))
+	return nil, nil
+}
+
+func nativeStr(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return Stringify(arguments[0]), nil
+}
+
+func nativeLen(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	switch value := arguments[0].(type) {
+	case string:
+		return int64(utf8.RuneCountInString(value)), nil
+	case *ListValue:
+		return int64(len(value.Elements)), nil
+	case *MapValue:
+		return int64(value.Len()), nil
+	default:
+		return nil, fmt.Errorf("len: expected a string, list or map but got %s", typeName(value))
+	}
+}
+
+func nativeList(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return NewListValue(append([]interface{}{}, arguments...)), nil
+}
+
+func nativeMap(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return NewMapValue(), nil
+}
+
+// typeName returns the Lox name of a runtime value's type, for error messages.
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case float64, int64:
+		return "number"
+	case string:
+		return "string"
+	case Callable:
+		return "function"
+	case *ListValue:
+		return "list"
+	case *MapValue:
+		return "map"
+	case *NativeModule:
+		return "module"
+	case *RegexValue:
+		return "regex"
+	case *TimeValue:
+		return "time"
+	case *HttpServerValue:
+		return "server"
+	case *ListenerValue:
+		return "listener"
+	case *ConnectionValue:
+		return "connection"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func checkArgumentCount(name string, arguments []interface{}, min int, max int) error {
+	if len(arguments) < min || len(arguments) > max {
+		if min == max {
+			return fmt.Errorf("%s: expected %d arguments but got %d", name, min, len(arguments))
+		}
+		return fmt.Errorf("%s: expected %d to %d arguments but got %d", name, min, max, len(arguments))
+	}
+	return nil
+}
+
+func stringArgument(name string, arguments []interface{}, index int) (string, error) {
+	value, ok := arguments[index].(string)
+	if !ok {
+		return "", fmt.Errorf("%s: argument %d must be a string, got %s", name, index+1, typeName(arguments[index]))
+	}
+	return value, nil
+}
+
+// numberArgument returns a number argument as a float, promoting ints.
+func numberArgument(name string, arguments []interface{}, index int) (float64, error) {
+	if !isNumber(arguments[index]) {
+		return 0, fmt.Errorf("%s: argument %d must be a number, got %s", name, index+1, typeName(arguments[index]))
+	}
+	return toFloat64(arguments[index]), nil
+}
+
+// intArgument returns an int argument, also taking floats holding an
+// integer.
+func intArgument(name string, arguments []interface{}, index int) (int, error) {
+	if integer, ok := arguments[index].(int64); ok {
+		return int(integer), nil
+	}
+	value, err := numberArgument(name, arguments, index)
+	if err != nil {
+		return 0, err
+	}
+	if value != math.Trunc(value) {
+		return 0, fmt.Errorf("%s: argument %d must be an integer, got %s", name, index+1, formatNumber(value))
+	}
+	return int(value), nil
+}
+
+func listArgument(name string, arguments []interface{}, index int) (*ListValue, error) {
+	value, ok := arguments[index].(*ListValue)
+	if !ok {
+		return nil, fmt.Errorf("%s: argument %d must be a list, got %s", name, index+1, typeName(arguments[index]))
+	}
+	return value, nil
+}
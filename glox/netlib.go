@@ -0,0 +1,558 @@
+package glox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const NET_DEFAULT_TIMEOUT = 30 * time.Second
+const NET_READ_SIZE = 4096
+
+// HttpServerValue is a running HTTP server, as returned by net.serve. Every
+// request is dispatched to a Lox handler function.
+type HttpServerValue struct {
+	inter    *Interpreter
+	handler  Callable
+	server   *http.Server
+	listener net.Listener
+	done     chan struct{}
+}
+
+// ListenerValue is a TCP listener, as returned by net.listen.
+type ListenerValue struct {
+	listener net.Listener
+}
+
+// ConnectionValue is a TCP connection, as returned by net.connect or
+// listener.accept().
+type ConnectionValue struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func newNetModule() *NativeModule {
+	return NewNativeModule("net", map[string]interface{}{
+		"request": NewNativeCallable("request", -1, netRequest),
+		"get":     NewNativeCallable("get", -1, netGet),
+		"post":    NewNativeCallable("post", -1, netPost),
+		"serve":   NewNativeCallable("serve", 2, netServe),
+		"connect": NewNativeCallable("connect", -1, netConnect),
+		"listen":  NewNativeCallable("listen", 1, netListen),
+	})
+}
+
+/*
+ * netRequest sends an HTTP request: net.request(method, url, options?).
+ * options is a map with optional "headers" (a map), "body" (a string) and
+ * "timeout" (seconds, 30 by default). It returns a map with the response
+ * "status", "ok" when the status is 2xx, "headers" and "body".
+ */
+func netRequest(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("request", arguments, 2, 3); err != nil {
+		return nil, err
+	}
+	method, err := stringArgument("request", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	url, err := stringArgument("request", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	var options interface{} = nil
+	if len(arguments) == 3 {
+		options = arguments[2]
+	}
+	return inter.sendRequest("request", strings.ToUpper(method), url, nil, options)
+}
+
+func netGet(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("get", arguments, 1, 2); err != nil {
+		return nil, err
+	}
+	url, err := stringArgument("get", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	var options interface{} = nil
+	if len(arguments) == 2 {
+		options = arguments[1]
+	}
+	return inter.sendRequest("get", http.MethodGet, url, nil, options)
+}
+
+func netPost(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("post", arguments, 2, 3); err != nil {
+		return nil, err
+	}
+	url, err := stringArgument("post", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	body := Stringify(arguments[1])
+	var options interface{} = nil
+	if len(arguments) == 3 {
+		options = arguments[2]
+	}
+	return inter.sendRequest("post", http.MethodPost, url, &body, options)
+}
+
+func (inter *Interpreter) sendRequest(name string, method string, url string, body *string, options interface{}) (interface{}, error) {
+	headers := http.Header{}
+	timeout := NET_DEFAULT_TIMEOUT
+	if options != nil {
+		optionsMap, ok := options.(*MapValue)
+		if !ok {
+			return nil, fmt.Errorf("%s: options must be a map, got %s", name, typeName(options))
+		}
+		for _, key := range optionsMap.Keys() {
+			value, _ := optionsMap.Get(key)
+			switch key {
+			case "headers":
+				headerMap, ok := value.(*MapValue)
+				if !ok {
+					return nil, fmt.Errorf("%s: option headers must be a map, got %s", name, typeName(value))
+				}
+				for _, header := range headerMap.Keys() {
+					headerValue, _ := headerMap.Get(header)
+					headers.Add(Stringify(header), Stringify(headerValue))
+				}
+			case "body":
+				text := Stringify(value)
+				body = &text
+			case "timeout":
+				if !isNumber(value) || toFloat64(value) < 0 {
+					return nil, fmt.Errorf("%s: option timeout must be a non-negative number, got %s", name, Stringify(value))
+				}
+				timeout = secondsToDuration(toFloat64(value))
+			default:
+				return nil, fmt.Errorf("%s: unknown option %s", name, Stringify(key))
+			}
+		}
+	}
+	var bodyReader io.Reader = nil
+	if body != nil {
+		bodyReader = strings.NewReader(*body)
+	}
+	request, err := http.NewRequest(method, url, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	request.Header = headers
+	client := &http.Client{Timeout: timeout}
+	var response *http.Response
+	var responseBody []byte
+	inter.withoutLock(func() {
+		response, err = client.Do(request)
+		if err != nil {
+			return
+		}
+		defer response.Body.Close()
+		responseBody, err = io.ReadAll(response.Body)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	result := NewMapValue()
+	result.Set("status", int64(response.StatusCode))
+	result.Set("ok", response.StatusCode >= 200 && response.StatusCode < 300)
+	result.Set("headers", headerMap(response.Header))
+	result.Set("body", string(responseBody))
+	return result, nil
+}
+
+// headerMap converts HTTP headers into a map sorted by name, joining
+// repeated headers with commas.
+func headerMap(headers http.Header) *MapValue {
+	names := []string{}
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := NewMapValue()
+	for _, name := range names {
+		result.Set(name, strings.Join(headers[name], ", "))
+	}
+	return result
+}
+
+/*
+ * netServe starts an HTTP server in the background: net.serve(address,
+ * handler). The handler receives a map with the request "method", "path",
+ * "query", "headers", "body" and "remoteAddress", and returns either the
+ * response body or a map with optional "status", "headers" and "body".
+ * Handlers run one at a time, while the script is waiting in a blocking
+ * native such as server.wait() or a network call.
+ */
+func netServe(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	address, err := stringArgument("serve", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	handler, ok := arguments[1].(Callable)
+	if !ok || (handler.getArity() != 1 && handler.getArity() >= 0) {
+		return nil, fmt.Errorf("serve: handler must be a function of 1 argument, got %s", typeName(arguments[1]))
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("serve: %w", err)
+	}
+	server := &HttpServerValue{
+		inter:    inter,
+		handler:  handler,
+		listener: listener,
+		done:     make(chan struct{}),
+	}
+	server.server = &http.Server{Handler: server}
+	go func() {
+		server.server.Serve(listener)
+		close(server.done)
+	}()
+	return server, nil
+}
+
+func (s *HttpServerValue) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := NewMapValue()
+	for name, values := range request.URL.Query() {
+		query.Set(name, values[0])
+	}
+	requestMap := NewMapValue()
+	requestMap.Set("method", request.Method)
+	requestMap.Set("path", request.URL.Path)
+	requestMap.Set("query", query)
+	requestMap.Set("headers", headerMap(request.Header))
+	requestMap.Set("body", string(body))
+	requestMap.Set("remoteAddress", request.RemoteAddr)
+
+	status, headers, responseBody, err := s.handle(requestMap)
+	if err != nil {
+		http.Error(writer, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	for name, value := range headers {
+		writer.Header().Set(name, value)
+	}
+	writer.WriteHeader(status)
+	io.WriteString(writer, responseBody)
+}
+
+// handle calls the handler with the interpreter locked, the lock being
+// released even if the call panics.
+func (s *HttpServerValue) handle(request *MapValue) (int, map[string]string, string, error) {
+	s.inter.lock.Lock()
+	defer s.inter.lock.Unlock()
+	lastValue := s.inter.lastValue
+	result, err := s.inter.callValue(s.handler, []interface{}{request})
+	s.inter.lastValue = lastValue
+	status, headers, responseBody := httpResponse(result)
+	return status, headers, responseBody, err
+}
+
+func httpResponse(result interface{}) (int, map[string]string, string) {
+	status := http.StatusOK
+	headers := map[string]string{}
+	response, ok := result.(*MapValue)
+	if !ok {
+		if result == nil {
+			return status, headers, ""
+		}
+		return status, headers, Stringify(result)
+	}
+	body := ""
+	if value, ok := response.Get("status"); ok {
+		if isNumber(value) {
+			status = int(toFloat64(value))
+		}
+	}
+	if value, ok := response.Get("headers"); ok {
+		if headerMap, ok := value.(*MapValue); ok {
+			for _, name := range headerMap.Keys() {
+				headerValue, _ := headerMap.Get(name)
+				headers[Stringify(name)] = Stringify(headerValue)
+			}
+		}
+	}
+	if value, ok := response.Get("body"); ok && value != nil {
+		body = Stringify(value)
+	}
+	return status, headers, body
+}
+
+type serverMethod struct {
+	arity    int
+	function func(inter *Interpreter, receiver *HttpServerValue, arguments []interface{}) (interface{}, error)
+}
+
+var serverMethods = map[string]serverMethod{
+	"wait":  {-1, serverWait},
+	"close": {0, serverClose},
+}
+
+func getServerMethod(receiver *HttpServerValue, name string) (interface{}, error) {
+	switch name {
+	case "address":
+		return receiver.listener.Addr().String(), nil
+	case "port":
+		return int64(receiver.listener.Addr().(*net.TCPAddr).Port), nil
+	}
+	method, ok := serverMethods[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined server method: %s", name)
+	}
+	return NewNativeCallable(name, method.arity, func(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+		return method.function(inter, receiver, arguments)
+	}), nil
+}
+
+// serverWait serves requests until the server is closed, or until the
+// optional timeout in seconds expires. It returns true if the server was
+// closed.
+func serverWait(inter *Interpreter, receiver *HttpServerValue, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("wait", arguments, 0, 1); err != nil {
+		return nil, err
+	}
+	var timeout <-chan time.Time = nil
+	if len(arguments) == 1 {
+		seconds, err := numberArgument("wait", arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		timeout = time.After(secondsToDuration(seconds))
+	}
+	closed := false
+	inter.withoutLock(func() {
+		select {
+		case <-receiver.done:
+			closed = true
+		case <-timeout:
+		}
+	})
+	return closed, nil
+}
+
+func serverClose(inter *Interpreter, receiver *HttpServerValue, arguments []interface{}) (interface{}, error) {
+	var err error
+	inter.withoutLock(func() {
+		err = receiver.server.Close()
+		<-receiver.done
+	})
+	if err != nil {
+		return nil, fmt.Errorf("close: %w", err)
+	}
+	return nil, nil
+}
+
+func netConnect(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("connect", arguments, 1, 2); err != nil {
+		return nil, err
+	}
+	address, err := stringArgument("connect", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	timeout := NET_DEFAULT_TIMEOUT
+	if len(arguments) == 2 {
+		seconds, err := numberArgument("connect", arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+		timeout = secondsToDuration(seconds)
+	}
+	var conn net.Conn
+	inter.withoutLock(func() {
+		conn, err = net.DialTimeout("tcp", address, timeout)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	return newConnectionValue(conn), nil
+}
+
+func netListen(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	address, err := stringArgument("listen", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	return &ListenerValue{listener: listener}, nil
+}
+
+func newConnectionValue(conn net.Conn) *ConnectionValue {
+	return &ConnectionValue{conn: conn, reader: bufio.NewReader(conn)}
+}
+
+type listenerMethod struct {
+	arity    int
+	function func(inter *Interpreter, receiver *ListenerValue, arguments []interface{}) (interface{}, error)
+}
+
+var listenerMethods = map[string]listenerMethod{
+	"accept": {0, listenerAccept},
+	"close":  {0, listenerClose},
+}
+
+func getListenerMethod(receiver *ListenerValue, name string) (interface{}, error) {
+	switch name {
+	case "address":
+		return receiver.listener.Addr().String(), nil
+	case "port":
+		return int64(receiver.listener.Addr().(*net.TCPAddr).Port), nil
+	}
+	method, ok := listenerMethods[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined listener method: %s", name)
+	}
+	return NewNativeCallable(name, method.arity, func(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+		return method.function(inter, receiver, arguments)
+	}), nil
+}
+
+func listenerAccept(inter *Interpreter, receiver *ListenerValue, arguments []interface{}) (interface{}, error) {
+	var conn net.Conn
+	var err error
+	inter.withoutLock(func() {
+		conn, err = receiver.listener.Accept()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("accept: %w", err)
+	}
+	return newConnectionValue(conn), nil
+}
+
+func listenerClose(inter *Interpreter, receiver *ListenerValue, arguments []interface{}) (interface{}, error) {
+	if err := receiver.listener.Close(); err != nil {
+		return nil, fmt.Errorf("close: %w", err)
+	}
+	return nil, nil
+}
+
+type connectionMethod struct {
+	arity    int
+	function func(inter *Interpreter, receiver *ConnectionValue, arguments []interface{}) (interface{}, error)
+}
+
+var connectionMethods = map[string]connectionMethod{
+	"write":      {1, connectionWrite},
+	"read":       {-1, connectionRead},
+	"readLine":   {0, connectionReadLine},
+	"setTimeout": {1, connectionSetTimeout},
+	"close":      {0, connectionClose},
+}
+
+func getConnectionMethod(receiver *ConnectionValue, name string) (interface{}, error) {
+	switch name {
+	case "localAddress":
+		return receiver.conn.LocalAddr().String(), nil
+	case "remoteAddress":
+		return receiver.conn.RemoteAddr().String(), nil
+	}
+	method, ok := connectionMethods[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined connection method: %s", name)
+	}
+	return NewNativeCallable(name, method.arity, func(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+		return method.function(inter, receiver, arguments)
+	}), nil
+}
+
+// connectionWrite writes the stringified argument and returns the number of
+// bytes written.
+func connectionWrite(inter *Interpreter, receiver *ConnectionValue, arguments []interface{}) (interface{}, error) {
+	var written int
+	var err error
+	inter.withoutLock(func() {
+		written, err = io.WriteString(receiver.conn, Stringify(arguments[0]))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("write: %w", err)
+	}
+	return int64(written), nil
+}
+
+// connectionRead reads whatever data is available, up to the given number of
+// bytes. It returns nil once the peer closes the connection.
+func connectionRead(inter *Interpreter, receiver *ConnectionValue, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("read", arguments, 0, 1); err != nil {
+		return nil, err
+	}
+	size := NET_READ_SIZE
+	if len(arguments) == 1 {
+		requested, err := intArgument("read", arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		if requested <= 0 {
+			return nil, fmt.Errorf("read: size must be positive, got %d", requested)
+		}
+		size = requested
+	}
+	buffer := make([]byte, size)
+	var read int
+	var err error
+	inter.withoutLock(func() {
+		read, err = receiver.reader.Read(buffer)
+	})
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	return string(buffer[:read]), nil
+}
+
+// connectionReadLine reads a line without its line terminator. It returns
+// nil once the peer closes the connection.
+func connectionReadLine(inter *Interpreter, receiver *ConnectionValue, arguments []interface{}) (interface{}, error) {
+	var line string
+	var err error
+	inter.withoutLock(func() {
+		line, err = receiver.reader.ReadString('\n')
+	})
+	if errors.Is(err, io.EOF) {
+		if len(line) == 0 {
+			return nil, nil
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("readLine: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// connectionSetTimeout sets a deadline in seconds from now for reads and
+// writes. A timeout of 0 removes the deadline.
+func connectionSetTimeout(inter *Interpreter, receiver *ConnectionValue, arguments []interface{}) (interface{}, error) {
+	seconds, err := numberArgument("setTimeout", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Time{}
+	if seconds > 0 {
+		deadline = time.Now().Add(secondsToDuration(seconds))
+	}
+	if err := receiver.conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("setTimeout: %w", err)
+	}
+	return nil, nil
+}
+
+func connectionClose(inter *Interpreter, receiver *ConnectionValue, arguments []interface{}) (interface{}, error) {
+	if err := receiver.conn.Close(); err != nil {
+		return nil, fmt.Errorf("close: %w", err)
+	}
+	return nil, nil
+}
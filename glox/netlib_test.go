@@ -0,0 +1,128 @@
+package glox
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetHttpClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		writer.Header().Set("X-Method", request.Method)
+		if request.URL.Path == "/missing" {
+			writer.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprintf(writer, "%s %s %s", request.URL.RequestURI(), request.Header.Get("X-Token"), body)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+	}{
+		{"get body", "net.get(\"URL/hello?x=1\").get(\"body\");", "/hello?x=1  "},
+		{"get status", "var r = net.get(\"URL/missing\"); \"${r.get(\"status\")} ${r.get(\"ok\")}\";", "404 false"},
+		{"response headers", "net.get(\"URL/\").get(\"headers\").get(\"X-Method\");", "GET"},
+		{"post", "net.post(\"URL/submit\", \"payload\").get(\"body\");", "/submit  payload"},
+		{"request headers", "var headers = map(); headers.set(\"X-Token\", \"secret\"); var options = map(); options.set(\"headers\", headers); net.get(\"URL/\", options).get(\"body\");", "/ secret "},
+		{"request method", "var options = map(); options.set(\"body\", \"data\"); var r = net.request(\"put\", \"URL/item\", options); r.get(\"headers\").get(\"X-Method\") + \" \" + r.get(\"body\");", "PUT /item  data"},
+	}
+	for _, testCase := range testCases {
+		source := strings.ReplaceAll(testCase.source, "URL", server.URL)
+		lastValue, _, stderr := interpretSource(source)
+		assert.Empty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestNetHttpServer(t *testing.T) {
+	source := `
+fun handle(request) {
+  if (request.get("path") == "/error") {
+    return undefinedVariable;
+  }
+  if (request.get("path") == "/created") {
+    var response = map();
+    response.set("status", 201);
+    var headers = map();
+    headers.set("X-Handler", "glox");
+    response.set("headers", headers);
+    response.set("body", request.get("body"));
+    return response;
+  }
+  return "${request.get("method")} ${request.get("path")} ${request.get("query").get("name")}";
+}
+var server = net.serve("127.0.0.1:0", handle);
+var url = "http://" + server.address;
+print net.get(url + "/greet?name=ada").get("body");
+var created = net.post(url + "/created", "made");
+print "${created.get("status")} ${created.get("headers").get("X-Handler")} ${created.get("body")}";
+print net.get(url + "/error").get("status");
+server.close();
+server.wait();
+`
+	_, stdout, stderr := interpretSource(source)
+	assert.Equal(t, "GET /greet ada\n201 glox made\n500\n", stdout)
+	assert.Equal(t, "[line 4] Error[interpreter]: undefined variable: undefinedVariable\n", stderr)
+}
+
+func TestNetTcp(t *testing.T) {
+	source := `
+var listener = net.listen("127.0.0.1:0");
+var client = net.connect(listener.address);
+var peer = listener.accept();
+client.write("ping\nrest");
+print peer.readLine();
+client.close();
+print peer.read();
+print peer.read();
+peer.close();
+listener.close();
+`
+	_, stdout, stderr := interpretSource(source)
+	assert.Empty(t, stderr)
+	assert.Equal(t, "ping\nrest\nnil\n", stdout)
+}
+
+func TestNetErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"bad handler", "net.serve(\"127.0.0.1:0\", 1);", "[line 1] Error[interpreter]: serve: handler must be a function of 1 argument, got number\n"},
+		{"unknown option", "var options = map(); options.set(\"retries\", 3); net.get(\"http://127.0.0.1:1/\", options);", "[line 1] Error[interpreter]: get: unknown option retries\n"},
+		{"connection refused", "var listener = net.listen(\"127.0.0.1:0\"); var address = listener.address; listener.close(); net.connect(address);", "[line 1] Error[interpreter]: connect: dial tcp ADDRESS: connect: connection refused\n"},
+	}
+	for _, testCase := range testCases {
+		_, _, stderr := interpretSource(testCase.source)
+		if strings.Contains(testCase.expectedError, "ADDRESS") {
+			assert.Regexp(t, `^\[line 1\] Error\[interpreter\]: connect: dial tcp 127\.0\.0\.1:\d+: connect: connection refused\n$`, stderr, testCase.name)
+		} else {
+			assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+		}
+	}
+}
+
+func TestNetTimeout(t *testing.T) {
+	source := `
+fun slow(request) {
+  time.sleep(1);
+  return "late";
+}
+var server = net.serve("127.0.0.1:0", slow);
+var options = map();
+options.set("timeout", 0.1);
+net.get("http://" + server.address + "/", options);
+`
+	_, _, stderr := interpretSource(source)
+	assert.Contains(t, stderr, "[line 9] Error[interpreter]: get: ")
+	assert.Contains(t, stderr, "Client.Timeout exceeded")
+}
@@ -0,0 +1,336 @@
+package glox
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+/*
+ * Numbers are either ints, held as int64, or floats, held as float64.
+ * Literals without a fractional part are ints.
+ *
+ * Arithmetic on two ints is exact and gives an int, failing when the result
+ * does not fit in 64 bits, but for "/" which always divides as floats, and
+ * "**" with a negative exponent. As soon as one operand is a float, both
+ * are promoted and the result is a float. Comparisons and equality compare
+ * the values, so 1 == 1.0.
+ */
+
+var errIntegerOverflow = errors.New("integer overflow")
+
+// toNumber converts an operand of an arithmetic operator to an int64 or a
+// float64. Strings are parsed, as ints when they are written as one.
+func toNumber(val interface{}) (interface{}, error) {
+	switch val := val.(type) {
+	case int64, float64:
+		return val, nil
+	case string:
+		if integer, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return integer, nil
+		}
+		float, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, err
+		}
+		return float, nil
+	case float32:
+		return float64(val), nil
+	case int32:
+		return int64(val), nil
+	default:
+		return nil, fmt.Errorf("cannot convert to float: %v", val)
+	}
+}
+
+// toFloat64 promotes a number to a float.
+func toFloat64(number interface{}) float64 {
+	if integer, ok := number.(int64); ok {
+		return float64(integer)
+	}
+	return number.(float64)
+}
+
+// bothInts tells whether two numbers are ints, returning them.
+func bothInts(left interface{}, right interface{}) (int64, int64, bool) {
+	leftInt, ok := left.(int64)
+	if !ok {
+		return 0, 0, false
+	}
+	rightInt, ok := right.(int64)
+	return leftInt, rightInt, ok
+}
+
+// arithmetic applies a numeric binary operator to two numbers.
+func arithmetic(operator int, left interface{}, right interface{}) (interface{}, error) {
+	if x, y, ok := bothInts(left, right); ok && operator != TOKEN_SLASH {
+		return intArithmetic(operator, x, y)
+	}
+	x, y := toFloat64(left), toFloat64(right)
+	switch operator {
+	case TOKEN_PLUS:
+		return x + y, nil
+	case TOKEN_MINUS:
+		return x - y, nil
+	case TOKEN_STAR:
+		return x * y, nil
+	case TOKEN_SLASH:
+		return x / y, nil
+	case TOKEN_PERCENT:
+		if y == 0 {
+			return nil, fmt.Errorf("modulo by zero")
+		}
+		return floorMod(x, y), nil
+	case TOKEN_TILDE_SLASH:
+		if y == 0 {
+			return nil, fmt.Errorf("integer division by zero")
+		}
+		return math.Floor(x / y), nil
+	case TOKEN_STAR_STAR:
+		return math.Pow(x, y), nil
+	}
+	return nil, fmt.Errorf("unreachable code")
+}
+
+func intArithmetic(operator int, x int64, y int64) (interface{}, error) {
+	switch operator {
+	case TOKEN_PLUS:
+		sum := x + y
+		if (sum > x) != (y > 0) {
+			return nil, errIntegerOverflow
+		}
+		return sum, nil
+	case TOKEN_MINUS:
+		difference := x - y
+		if (difference < x) != (y > 0) {
+			return nil, errIntegerOverflow
+		}
+		return difference, nil
+	case TOKEN_STAR:
+		return multiplyInts(x, y)
+	case TOKEN_PERCENT:
+		if y == 0 {
+			return nil, fmt.Errorf("modulo by zero")
+		}
+		mod := x % y
+		if mod != 0 && (mod < 0) != (y < 0) {
+			mod += y
+		}
+		return mod, nil
+	case TOKEN_TILDE_SLASH:
+		if y == 0 {
+			return nil, fmt.Errorf("integer division by zero")
+		}
+		if x == math.MinInt64 && y == -1 {
+			return nil, errIntegerOverflow
+		}
+		quotient := x / y
+		if x%y != 0 && (x < 0) != (y < 0) {
+			quotient--
+		}
+		return quotient, nil
+	case TOKEN_STAR_STAR:
+		if y < 0 {
+			return math.Pow(float64(x), float64(y)), nil
+		}
+		return powerInts(x, y)
+	}
+	return nil, fmt.Errorf("unreachable code")
+}
+
+func multiplyInts(x int64, y int64) (int64, error) {
+	if x == 0 || y == 0 {
+		return 0, nil
+	}
+	product := x * y
+	// MinInt64 * -1 wraps to MinInt64, which divided by -1 gives it back.
+	if product/y != x || (y == -1 && x == math.MinInt64) {
+		return 0, errIntegerOverflow
+	}
+	return product, nil
+}
+
+// powerInts raises x to a non-negative power by squaring.
+func powerInts(x int64, y int64) (interface{}, error) {
+	result := int64(1)
+	for y > 0 {
+		var err error
+		if y&1 == 1 {
+			if result, err = multiplyInts(result, x); err != nil {
+				return nil, err
+			}
+		}
+		if y >>= 1; y > 0 {
+			if x, err = multiplyInts(x, x); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// negate returns the opposite of a number.
+func negate(number interface{}) (interface{}, error) {
+	if integer, ok := number.(int64); ok {
+		if integer == math.MinInt64 {
+			return nil, errIntegerOverflow
+		}
+		return -integer, nil
+	}
+	return -number.(float64), nil
+}
+
+// compareNumbers applies a comparison operator to two numbers.
+func compareNumbers(operator int, left interface{}, right interface{}) bool {
+	if x, y, ok := bothInts(left, right); ok {
+		switch operator {
+		case TOKEN_GREATER:
+			return x > y
+		case TOKEN_GREATER_EQUAL:
+			return x >= y
+		case TOKEN_LESS:
+			return x < y
+		}
+		return x <= y
+	}
+	integer, isInt := left.(int64)
+	float, isFloat := right.(float64)
+	order := 1
+	if !isInt {
+		integer, isInt = right.(int64)
+		float, isFloat = left.(float64)
+		order = -1
+	}
+	if isInt && isFloat {
+		// floats do not hold every int beyond 2^53, so ints are compared
+		// with the integer part of the float rather than turned into floats.
+		// order is the sign of left - right.
+		if math.IsNaN(float) {
+			return false
+		}
+		order *= compareIntFloat(integer, float)
+		switch operator {
+		case TOKEN_GREATER:
+			return order > 0
+		case TOKEN_GREATER_EQUAL:
+			return order >= 0
+		case TOKEN_LESS:
+			return order < 0
+		}
+		return order <= 0
+	}
+	x, y := left.(float64), right.(float64)
+	switch operator {
+	case TOKEN_GREATER:
+		return x > y
+	case TOKEN_GREATER_EQUAL:
+		return x >= y
+	case TOKEN_LESS:
+		return x < y
+	}
+	return x <= y
+}
+
+// compareIntFloat returns -1, 0 or 1 as an int is less than, equal to or
+// greater than a float that is not NaN.
+func compareIntFloat(integer int64, float float64) int {
+	truncated := math.Trunc(float)
+	converted, ok := floatToInt(truncated)
+	switch {
+	case !ok && float > 0:
+		return -1
+	case !ok:
+		return 1
+	case integer < converted:
+		return -1
+	case integer > converted:
+		return 1
+	case float > truncated:
+		return -1
+	case float < truncated:
+		return 1
+	}
+	return 0
+}
+
+// numbersEqual tells whether two numbers have the same value. An int equals
+// a float only when the float holds exactly that integer.
+func numbersEqual(left interface{}, right interface{}) bool {
+	if x, y, ok := bothInts(left, right); ok {
+		return x == y
+	}
+	integer, isInt := left.(int64)
+	float, isFloat := right.(float64)
+	if !isInt {
+		integer, isInt = right.(int64)
+		float, isFloat = left.(float64)
+	}
+	if isInt && isFloat {
+		converted, ok := floatToInt(float)
+		return ok && converted == integer
+	}
+	return left.(float64) == right.(float64)
+}
+
+// floatToInt converts a float with no fractional part to an int, failing
+// when it is out of range.
+func floatToInt(float float64) (int64, bool) {
+	if float != math.Trunc(float) || float < math.MinInt64 || float >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(float), true
+}
+
+// mapKeyNumber turns floats holding an integer into ints, for equal numbers
+// to be the same map key.
+func mapKeyNumber(number interface{}) interface{} {
+	if float, ok := number.(float64); ok {
+		if integer, ok := floatToInt(float); ok {
+			return integer
+		}
+	}
+	return number
+}
+
+// nativeInt converts a number or a string to an int, truncating floats
+// towards zero.
+func nativeInt(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	switch value := arguments[0].(type) {
+	case int64:
+		return value, nil
+	case float64:
+		truncated := math.Trunc(value)
+		integer, ok := floatToInt(truncated)
+		if !ok {
+			return nil, fmt.Errorf("int: %s is out of the range of ints", formatNumber(value))
+		}
+		return integer, nil
+	case string:
+		integer, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("int: cannot convert %q to an int", value)
+		}
+		return integer, nil
+	default:
+		return nil, fmt.Errorf("int: expected a number or a string but got %s", typeName(value))
+	}
+}
+
+// nativeFloat converts a number or a string to a float.
+func nativeFloat(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	switch value := arguments[0].(type) {
+	case int64:
+		return float64(value), nil
+	case float64:
+		return value, nil
+	case string:
+		float, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("float: cannot convert %q to a float", value)
+		}
+		return float, nil
+	default:
+		return nil, fmt.Errorf("float: expected a number or a string but got %s", typeName(value))
+	}
+}
@@ -0,0 +1,80 @@
+package glox
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumbers(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+	}{
+		{"int literal", "42;", int64(42)},
+		{"float literal", "42.0;", 42.0},
+		{"int arithmetic", "7 * 6 - 2 + 2;", int64(42)},
+		{"float promotion", "1 + 0.5;", 1.5},
+		{"float promotion in products", "2.0 * 3;", 6.0},
+		{"division gives a float", "6 / 3;", 2.0},
+		{"integer division", "-7 ~/ 2;", int64(-4)},
+		{"float integer division", "7.0 ~/ 2;", 3.0},
+		{"modulo", "-7 % 3;", int64(2)},
+		{"exponent", "3 ** 39;", int64(4052555153018976267)},
+		{"negative exponent", "2 ** -1;", 0.5},
+		{"beyond 2^53", "9007199254740993 + 2;", int64(9007199254740995)},
+		{"max int", "9223372036854775806 + 1;", int64(math.MaxInt64)},
+		{"min int", "-9223372036854775807 - 1;", int64(math.MinInt64)},
+		{"int equals float", "1 == 1.0;", true},
+		{"int differs from fraction", "1 == 1.5;", false},
+		{"int compares to float", "2 < 2.5 and 3 >= 3.0;", true},
+		{"big ints compare exactly", "9007199254740993 > 9007199254740992;", true},
+		{"big int compares exactly to a float", "9007199254740993 > 9007199254740992.0;", true},
+		{"float compares exactly to a big int", "9007199254740992.0 < 9007199254740993 and 9007199254740992.0 >= 9007199254740992;", true},
+		{"big int below a float", "9007199254740993 <= 9007199254740992.0;", false},
+		{"int compares to a fraction", "-3 < -2.5 and 2 > 1.5 and 2 <= 2.0;", true},
+		{"int compares to floats out of range", "9223372036854775807 < 9223372036854775808.0 and -9223372036854775807 - 1 > -10000000000000000000.0;", true},
+		{"int compares to infinities", "9223372036854775807 < 1 / 0 and -9223372036854775807 - 1 > -1 / 0;", true},
+		{"string to int", "\"12\" - 2;", int64(10)},
+		{"string to float", "\"1.5\" * 2;", 3.0},
+		{"concatenation", "\"n = \" + 2 + \" \" + 2.0 + \" \" + 2.5;", "n = 2 2 2.5"},
+		{"int of float", "int(-2.7);", int64(-2)},
+		{"int of string", "int(\"9007199254740993\");", int64(9007199254740993)},
+		{"int of int", "int(5);", int64(5)},
+		{"float of int", "float(5);", 5.0},
+		{"float of string", "float(\"2.5\");", 2.5},
+		{"len is an int", "len(\"abc\");", int64(3)},
+		{"map keys", "var m = map(); m.set(1.0, \"a\"); m.get(1);", "a"},
+	}
+	for _, testCase := range testCases {
+		lastValue, _, stderr := interpretSource(testCase.source)
+		assert.Empty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestNumberErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"addition overflow", "9223372036854775807 + 1;", "[line 1] Error[interpreter]: operator +: integer overflow\n"},
+		{"subtraction overflow", "-9223372036854775807 - 2;", "[line 1] Error[interpreter]: operator -: integer overflow\n"},
+		{"multiplication overflow", "4294967296 * 4294967296;", "[line 1] Error[interpreter]: operator *: integer overflow\n"},
+		{"exponent overflow", "2 ** 63;", "[line 1] Error[interpreter]: operator **: integer overflow\n"},
+		{"negation overflow", "var min = -9223372036854775807 - 1; -min;", "[line 1] Error[interpreter]: operator -: integer overflow\n"},
+		{"integer division overflow", "(-9223372036854775807 - 1) ~/ -1;", "[line 1] Error[interpreter]: operator ~/: integer overflow\n"},
+		{"literal too large", "9223372036854775808;", "[line 1] Error: Integer too large: 9223372036854775808\n"},
+		{"int of huge float", "int(10000000000000000000.0);", "[line 1] Error[interpreter]: int: 10000000000000000000 is out of the range of ints\n"},
+		{"int of bad string", "int(\"1.5\");", "[line 1] Error[interpreter]: int: cannot convert \"1.5\" to an int\n"},
+		{"int of nil", "int(nil);", "[line 1] Error[interpreter]: int: expected a number or a string but got nil\n"},
+		{"float of bad string", "float(\"x\");", "[line 1] Error[interpreter]: float: cannot convert \"x\" to a float\n"},
+	}
+	for _, testCase := range testCases {
+		_, _, stderr := interpretSource(testCase.source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+	}
+}
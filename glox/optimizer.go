@@ -0,0 +1,150 @@
+package glox
+
+import (
+	"io"
+	"strings"
+)
+
+/*
+ * Optimizer simplifies a resolved program before it is interpreted:
+ *
+ * - unary, binary and logical expressions of literals are folded into the
+ *   literal they evaluate to, such as 2 * 3, "a" + 1 or !nil;
+ * - conditional expressions, ifs and whiles with a literal condition are
+ *   replaced by the branch taken, dropping the others;
+ * - statements following a return, a break or a continue in the same block
+ *   are dropped, as are the empty blocks left behind;
+ * - groupings around literals, variables, calls and property accesses are
+ *   replaced by what they group.
+ *
+ * Expressions are folded by evaluating them the way the Interpreter does, so
+ * they keep their value, and those that fail are left for the Interpreter to
+ * report the error at runtime. Folded literals keep the line of the
+ * expression they replace.
+ */
+type Optimizer struct {
+	interpreter *Interpreter
+}
+
+func NewOptimizer() Optimizer {
+	errorReporter := NewConsoleErrorReporterWithWriter(io.Discard)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), io.Discard, io.Discard)
+	return Optimizer{interpreter: &interpreter}
+}
+
+// Optimize returns the optimized copy of a program, leaving it untouched.
+func (o Optimizer) Optimize(statements []Stmt) []Stmt {
+	return reachable(RewriteStatements(statements, o.optimize))
+}
+
+// OptimizeExpr returns the optimized copy of an expression.
+func (o Optimizer) OptimizeExpr(expr Expr) Expr {
+	return Rewrite(expr, o.optimize)
+}
+
+func (o Optimizer) optimize(node Node) Node {
+	switch node := node.(type) {
+	case BlockStmt:
+		return NewBlockStmt(reachable(node.Statements))
+	case FunctionStmt:
+		return NewFunctionStmt(node.Name, node.Params, reachable(node.Body))
+	case IfStmt:
+		condition, ok := o.truthiness(node.Condition)
+		if !ok {
+			return node
+		}
+		if condition {
+			return node.ThenBranch
+		} else if node.ElseBranch != nil {
+			return node.ElseBranch
+		}
+		return NewBlockStmt([]Stmt{})
+	case WhileStmt:
+		if condition, ok := o.truthiness(node.Condition); ok && !condition {
+			return NewBlockStmt([]Stmt{})
+		}
+	case ConditionalExpr:
+		condition, ok := o.truthiness(node.Condition)
+		if !ok {
+			return node
+		}
+		if condition {
+			return node.Left
+		}
+		return node.Right
+	case GroupingExpr:
+		// negative literals print with the parentheses they need.
+		if precedence(node.Expression) >= PRECEDENCE_CALL || isLiteral(node.Expression) {
+			return node.Expression
+		}
+	case LogicalExpr:
+		left, ok := o.truthiness(node.Left)
+		if !ok {
+			return node
+		}
+		if shortCircuit := left == (node.Operator.Type == TOKEN_OR); !shortCircuit {
+			return node.Right
+		}
+		// the right operand is never evaluated, so any literal will do.
+		return o.fold(NewLogicalExpr(node.Left, node.Operator, NewLiteralExpr(nil, node.Left.getLine())))
+	case UnaryExpr:
+		if isLiteral(node.Right) {
+			return o.fold(node)
+		}
+	case BinaryExpr:
+		if isLiteral(node.Left) && isLiteral(node.Right) {
+			return o.fold(node)
+		}
+	}
+	return node
+}
+
+// fold returns the literal an expression of literals evaluates to, or the
+// expression if it fails.
+func (o Optimizer) fold(expr Expr) Expr {
+	value, err := o.interpreter.evaluate(expr)
+	if err != nil {
+		return expr
+	}
+	switch value.(type) {
+	case nil, bool, int64, float64, string:
+		return NewLiteralExpr(value, expr.getLine())
+	}
+	return expr
+}
+
+// truthiness tells whether an expression is a literal and if it is truthy.
+func (o Optimizer) truthiness(expr Expr) (bool, bool) {
+	literal, ok := expr.(LiteralExpr)
+	if !ok {
+		return false, false
+	}
+	truthy, err := isTruthy(literal.Value)
+	if err != nil {
+		return false, false
+	}
+	return truthy, true
+}
+
+func isLiteral(expr Expr) bool {
+	_, ok := expr.(LiteralExpr)
+	return ok
+}
+
+// reachable drops the statements after a return, a break or a continue,
+// and empty blocks.
+func reachable(statements []Stmt) []Stmt {
+	kept := []Stmt{}
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case BlockStmt:
+			if len(stmt.Statements) == 0 {
+				continue
+			}
+		case ReturnStmt, BreakStmt, ContinueStmt:
+			return append(kept, stmt)
+		}
+		kept = append(kept, stmt)
+	}
+	return kept
+}
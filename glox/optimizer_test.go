@@ -0,0 +1,64 @@
+package glox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptimizer(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		optimized string
+	}{
+		{"arithmetic", "print 1 + 2 * 3 - 2 ** 3 ~/ 3;", "print 5;\n"},
+		{"float arithmetic", "print 1.5 + 1.5 * 1;", "print 3.0;\n"},
+		{"comparison", "print 1 + 1 == 2 and 3 >= 4;", "print false;\n"},
+		{"concatenation", `print "n = " + 2 * 3 + "!";`, "print \"n = 6!\";\n"},
+		{"interpolation", `var a; print "a ${1 + 1} ${a}";`, "var a;\nprint \"a 2 \" + a;\n"},
+		{"unary", "print -(3) * 2 == -6 and !nil;", "print true;\n"},
+		{"negative operand", "var x = 2; print (-2) ** x - 1 / 0;", "var x = 2;\nprint (-2) ** x - (1 / 0);\n"},
+		{"failing operand", "print -(2 - 3) + !nil;", "print 1 + true;\n"},
+		{"partial", "var a; print a + 2 * 3;", "var a;\nprint a + 6;\n"},
+		{"logical left", "var a; print true and a; print nil or a; print false and a; print 0 or a;",
+			"var a;\nprint a;\nprint a;\nprint false;\nprint a;\n"},
+		{"logical short-circuit", "var a; print 1 or a; print nil and a;", "var a;\nprint 1;\nprint false;\n"},
+		{"conditional", `var a; print 1 > 2 ? a : "no"; print "" ? 1 : a;`, "var a;\nprint \"no\";\nprint a;\n"},
+		{"grouping", "var a; print (a) * (a + 1) + (2) + (f)(a);", "var a;\nprint a * (a + 1) + 2 + f(a);\n"},
+		{"if true", "if (1 < 2) print 1; else print 2;", "print 1;\n"},
+		{"if false", "if (nil) print 1; else { print 2; }", "{\n  print 2;\n}\n"},
+		{"if without else", "{ if (false) print 1; }\nprint 2;", "print 2;\n"},
+		{"nested if", "var a; if (a) if (false) print 1; else print 2;", "var a;\nif (a) print 2;\n"},
+		{"while false", "while (1 > 2) print 1;", ""},
+		{"while true", "while (true) break;", "while (true) break;\n"},
+		{"after return", "fun f() { print 1; return 2; print 3; }", "fun f() {\n  print 1;\n  return 2;\n}\n"},
+		{"after break", "while (true) { print 1; break; print 2; { print 3; } }", "while (true) {\n  print 1;\n  break;\n}\n"},
+		{"after continue", "var a; while (a) { continue; a = false; }", "var a;\nwhile (a) {\n  continue;\n}\n"},
+		{"runtime errors", `print 1 % 0; print "a" - 1; print -nil;`, "print 1 % 0;\nprint \"a\" - 1;\nprint -nil;\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			optimized := NewOptimizer().Optimize(parseSource(t, tt.source))
+			assert.Equal(t, tt.optimized, SourcePrinter{}.Print(optimized))
+		})
+	}
+}
+
+func TestOptimizerKeepsTree(t *testing.T) {
+	statements := parseSource(t, "if (true) print 1 + 2;")
+	source := SourcePrinter{}.Print(statements)
+	NewOptimizer().Optimize(statements)
+	assert.Equal(t, source, SourcePrinter{}.Print(statements))
+}
+
+func TestOptimizerLines(t *testing.T) {
+	statements := NewOptimizer().Optimize(parseSource(t, "print\n  1 +\n  2;\nprint (\n  3);\n"))
+	assert.Equal(t, NewLiteralExpr(int64(3), 2), statements[0].(PrintStmt).Print)
+	assert.Equal(t, NewLiteralExpr(int64(3), 5), statements[1].(PrintStmt).Print)
+}
+
+func TestOptimizeExpr(t *testing.T) {
+	expr := NewBinaryExpr(NewLiteralExpr(2.0, 1), NewToken(TOKEN_STAR_STAR, "**", nil, 1), NewGroupingExpr(NewLiteralExpr(10.0, 1)))
+	assert.Equal(t, NewLiteralExpr(1024.0, 1), NewOptimizer().OptimizeExpr(expr))
+}
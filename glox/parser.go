@@ -609,7 +609,7 @@ func (p *Parser) term() (Expr, error) {
 }
 
 /*
- * factor -> unary ( ( "/" | "*" ) unary )* ;
+ * factor -> unary ( ( "/" | "*" | "%" | "~/" ) unary )* ;
  */
 func (p *Parser) factor() (Expr, error) {
 	expr, err := p.unary()
@@ -617,7 +617,7 @@ func (p *Parser) factor() (Expr, error) {
 		return nil, err
 	}
 
-	for p.match(TOKEN_SLASH, TOKEN_STAR) {
+	for p.match(TOKEN_SLASH, TOKEN_STAR, TOKEN_PERCENT, TOKEN_TILDE_SLASH) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -630,7 +630,7 @@ func (p *Parser) factor() (Expr, error) {
 }
 
 /*
- * unary -> ( "!" | "-" ) unary | call ;
+ * unary -> ( "!" | "-" ) unary | power ;
  */
 func (p *Parser) unary() (Expr, error) {
 	if p.match(TOKEN_BANG, TOKEN_MINUS) {
@@ -642,11 +642,35 @@ func (p *Parser) unary() (Expr, error) {
 		return NewUnaryExpr(operator, right), nil
 	}
 
-	return p.call()
+	return p.power()
 }
 
 /*
- * call -> primary ( "(" arguments? ")" )* ;
+ * power -> call ( "**" unary )? ;
+ *
+ * "**" binds tighter than a unary operator on its left, and is
+ * right-associative: -2 ** 2 is -(2 ** 2) and 2 ** 3 ** 2 is 2 ** (3 ** 2).
+ */
+func (p *Parser) power() (Expr, error) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(TOKEN_STAR_STAR) {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		expr = NewBinaryExpr(expr, operator, right)
+	}
+
+	return expr, nil
+}
+
+/*
+ * call -> primary ( "(" arguments? ")" | "." IDENTIFIER )* ;
  */
 func (p *Parser) call() (Expr, error) {
 	expr, err := p.primary()
@@ -660,6 +684,12 @@ func (p *Parser) call() (Expr, error) {
 			if err != nil {
 				return nil, err
 			}
+		} else if p.match(TOKEN_DOT) {
+			name, err := p.consume(TOKEN_IDENTIFIER, "Expect property name after '.'.")
+			if err != nil {
+				return nil, err
+			}
+			expr = NewGetExpr(expr, name)
 		} else {
 			break
 		}
@@ -697,7 +727,7 @@ func (p *Parser) finishCall(callee Expr) (Expr, error) {
 }
 
 /*
- * primary -> NUMBER | STRING | "true" | "false" | "nil" | "(" expression ")" | IDENTIFIER ;
+ * primary -> NUMBER | STRING | interpolation | "true" | "false" | "nil" | "(" expression ")" | IDENTIFIER ;
  */
 func (p *Parser) primary() (Expr, error) {
 	if p.match(TOKEN_FALSE) {
@@ -712,6 +742,9 @@ func (p *Parser) primary() (Expr, error) {
 	if p.match(TOKEN_NUMBER, TOKEN_STRING) {
 		return NewLiteralExpr(p.previous().Literal, p.currentLine()), nil
 	}
+	if p.match(TOKEN_INTERPOLATION) {
+		return p.interpolation()
+	}
 	if p.match(TOKEN_LEFT_PAREN) {
 		expr, err := p.expression()
 		if err != nil {
@@ -730,6 +763,35 @@ func (p *Parser) primary() (Expr, error) {
 	return nil, NewParseError("Expected expression.", p.peek())
 }
 
+/*
+ * interpolation -> ( INTERPOLATION expression )+ STRING ;
+ *
+ * An interpolated string is lowered into a left-associative chain of "+",
+ * starting with a string, so every embedded value gets stringified.
+ */
+func (p *Parser) interpolation() (Expr, error) {
+	var expr Expr = NewLiteralExpr(p.previous().Literal, p.currentLine())
+	for {
+		plus := NewToken(TOKEN_PLUS, "+", nil, p.currentLine())
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		expr = NewBinaryExpr(expr, plus, value)
+		if p.match(TOKEN_INTERPOLATION) {
+			expr = NewBinaryExpr(expr, plus, NewLiteralExpr(p.previous().Literal, p.currentLine()))
+			continue
+		}
+		if p.match(TOKEN_STRING) {
+			if p.previous().Literal != "" {
+				expr = NewBinaryExpr(expr, plus, NewLiteralExpr(p.previous().Literal, p.currentLine()))
+			}
+			return expr, nil
+		}
+		return nil, NewParseError("Expect '}' after interpolated expression.", p.peek())
+	}
+}
+
 func (p *Parser) match(types ...int) bool {
 	for _, tokenType := range types {
 		if p.check(tokenType) {
@@ -766,7 +828,12 @@ func (p *Parser) previous() Token {
 	return p.tokens[p.current-1]
 }
 
+// currentLine is the line of the last token consumed, or of the first one
+// when the error is found before consuming any.
 func (p *Parser) currentLine() int {
+	if p.current == 0 {
+		return p.peek().Line
+	}
 	return p.previous().Line
 }
 
@@ -19,20 +19,40 @@ func TestParserStatements(t *testing.T) {
 				NewExpressionStmt(
 					NewBinaryExpr(
 						NewBinaryExpr(
-							NewLiteralExpr(1.0, 1),
+							NewLiteralExpr(int64(1), 1),
 							NewToken(TOKEN_STAR, "*", nil, 1),
-							NewLiteralExpr(2.0, 1),
+							NewLiteralExpr(int64(2), 1),
 						),
 						NewToken(TOKEN_PLUS, "+", nil, 1),
 						NewBinaryExpr(
-							NewLiteralExpr(3.0, 1),
+							NewLiteralExpr(int64(3), 1),
 							NewToken(TOKEN_SLASH, "/", nil, 1),
-							NewLiteralExpr(4.0, 1),
+							NewLiteralExpr(int64(4), 1),
 						),
 					),
 				),
 			},
 		},
+		{
+			"numeric operators",
+			"-2**3%4;",
+			[]Stmt{
+				NewExpressionStmt(
+					NewBinaryExpr(
+						NewUnaryExpr(
+							NewToken(TOKEN_MINUS, "-", nil, 1),
+							NewBinaryExpr(
+								NewLiteralExpr(int64(2), 1),
+								NewToken(TOKEN_STAR_STAR, "**", nil, 1),
+								NewLiteralExpr(int64(3), 1),
+							),
+						),
+						NewToken(TOKEN_PERCENT, "%", nil, 1),
+						NewLiteralExpr(int64(4), 1),
+					),
+				),
+			},
+		},
 		{
 			"ternary expression",
 			"2>=1?\"2\"==2?true:false:false;",
@@ -40,15 +60,15 @@ func TestParserStatements(t *testing.T) {
 				NewExpressionStmt(
 					NewConditionalExpr(
 						NewBinaryExpr(
-							NewLiteralExpr(2.0, 1),
+							NewLiteralExpr(int64(2), 1),
 							NewToken(TOKEN_GREATER_EQUAL, ">=", nil, 1),
-							NewLiteralExpr(1.0, 1),
+							NewLiteralExpr(int64(1), 1),
 						),
 						NewConditionalExpr(
 							NewBinaryExpr(
 								NewLiteralExpr("2", 1),
 								NewToken(TOKEN_EQUAL_EQUAL, "==", nil, 1),
-								NewLiteralExpr(2.0, 1),
+								NewLiteralExpr(int64(2), 1),
 							),
 							NewLiteralExpr(true, 1),
 							NewLiteralExpr(false, 1),
@@ -64,7 +84,7 @@ func TestParserStatements(t *testing.T) {
 			[]Stmt{
 				NewVarStmt(
 					NewToken(TOKEN_IDENTIFIER, "test", "test", 1),
-					NewLiteralExpr(1.0, 1),
+					NewLiteralExpr(int64(1), 1),
 				),
 				NewExpressionStmt(
 					NewAssignExpr(
@@ -75,10 +95,10 @@ func TestParserStatements(t *testing.T) {
 									NewToken(TOKEN_IDENTIFIER, "test", "test", 1),
 								),
 								NewToken(TOKEN_STAR, "*", nil, 1),
-								NewLiteralExpr(2.0, 1),
+								NewLiteralExpr(int64(2), 1),
 							),
 							NewToken(TOKEN_PLUS, "+", nil, 1),
-							NewLiteralExpr(1.0, 1),
+							NewLiteralExpr(int64(1), 1),
 						),
 					),
 				),
@@ -92,12 +112,12 @@ func TestParserStatements(t *testing.T) {
 					NewLiteralExpr(true, 1),
 					NewBlockStmt([]Stmt{
 						NewPrintStmt(
-							NewLiteralExpr(1.0, 1),
+							NewLiteralExpr(int64(1), 1),
 						),
 					}),
 					NewBlockStmt([]Stmt{
 						NewPrintStmt(
-							NewLiteralExpr(2.0, 1),
+							NewLiteralExpr(int64(2), 1),
 						),
 					}),
 				),
@@ -111,32 +131,32 @@ func TestParserStatements(t *testing.T) {
 					NewLogicalExpr(
 						NewLogicalExpr(
 							NewBinaryExpr(
-								NewLiteralExpr(3.0, 1),
+								NewLiteralExpr(int64(3), 1),
 								NewToken(TOKEN_LESS, "<", nil, 1),
-								NewLiteralExpr(1.0, 1),
+								NewLiteralExpr(int64(1), 1),
 							),
 							NewToken(TOKEN_OR, "or", nil, 1),
 							NewBinaryExpr(
-								NewLiteralExpr(1.0, 1),
+								NewLiteralExpr(int64(1), 1),
 								NewToken(TOKEN_GREATER_EQUAL, ">=", nil, 1),
-								NewLiteralExpr(3.0, 1),
+								NewLiteralExpr(int64(3), 1),
 							),
 						),
 						NewToken(TOKEN_OR, "or", nil, 1),
 						NewBinaryExpr(
-							NewLiteralExpr(1.0, 1),
+							NewLiteralExpr(int64(1), 1),
 							NewToken(TOKEN_EQUAL_EQUAL, "==", nil, 1),
-							NewLiteralExpr(1.0, 1),
+							NewLiteralExpr(int64(1), 1),
 						),
 					),
 					NewBlockStmt([]Stmt{
 						NewPrintStmt(
-							NewLiteralExpr(1.0, 1),
+							NewLiteralExpr(int64(1), 1),
 						),
 					}),
 					NewBlockStmt([]Stmt{
 						NewPrintStmt(
-							NewLiteralExpr(2.0, 1),
+							NewLiteralExpr(int64(2), 1),
 						),
 					}),
 				),
@@ -150,11 +170,11 @@ func TestParserStatements(t *testing.T) {
 					NewBinaryExpr(
 						NewLiteralExpr(true, 1),
 						NewToken(TOKEN_EQUAL_EQUAL, "==", nil, 1),
-						NewLiteralExpr(1.0, 1),
+						NewLiteralExpr(int64(1), 1),
 					),
 					NewBlockStmt([]Stmt{
 						NewPrintStmt(
-							NewLiteralExpr(1.0, 1),
+							NewLiteralExpr(int64(1), 1),
 						),
 						NewBreakStmt(
 							NewToken(TOKEN_BREAK, "break", nil, 1),
@@ -173,7 +193,7 @@ func TestParserStatements(t *testing.T) {
 				NewBlockStmt([]Stmt{
 					NewVarStmt(
 						NewToken(TOKEN_IDENTIFIER, "i", "i", 1),
-						NewLiteralExpr(0.0, 1),
+						NewLiteralExpr(int64(0), 1),
 					),
 					NewWhileStmt(
 						NewBinaryExpr(
@@ -181,12 +201,12 @@ func TestParserStatements(t *testing.T) {
 								NewToken(TOKEN_IDENTIFIER, "i", "i", 1),
 							),
 							NewToken(TOKEN_LESS, "<", nil, 1),
-							NewLiteralExpr(10.0, 1),
+							NewLiteralExpr(int64(10), 1),
 						),
 						NewBlockStmt([]Stmt{
 							NewBlockStmt([]Stmt{
 								NewPrintStmt(
-									NewLiteralExpr(1.0, 1),
+									NewLiteralExpr(int64(1), 1),
 								),
 							}),
 							NewExpressionStmt(
@@ -197,7 +217,7 @@ func TestParserStatements(t *testing.T) {
 											NewToken(TOKEN_IDENTIFIER, "i", "i", 1),
 										),
 										NewToken(TOKEN_PLUS, "+", nil, 1),
-										NewLiteralExpr(1.0, 1),
+										NewLiteralExpr(int64(1), 1),
 									),
 								),
 							),
@@ -230,6 +250,56 @@ func TestParserStatements(t *testing.T) {
 				),
 			},
 		},
+		{
+			"Property Access Expression",
+			"name.trim().split(\",\");",
+			[]Stmt{
+				NewExpressionStmt(
+					NewCallExpr(
+						NewGetExpr(
+							NewCallExpr(
+								NewGetExpr(
+									NewVariableExpr(
+										NewToken(TOKEN_IDENTIFIER, "name", "name", 1),
+									),
+									NewToken(TOKEN_IDENTIFIER, "trim", "trim", 1),
+								),
+								NewToken(TOKEN_RIGHT_PAREN, ")", nil, 1),
+								[]Expr{},
+							),
+							NewToken(TOKEN_IDENTIFIER, "split", "split", 1),
+						),
+						NewToken(TOKEN_RIGHT_PAREN, ")", nil, 1),
+						[]Expr{
+							NewLiteralExpr(",", 1),
+						},
+					),
+				),
+			},
+		},
+		{
+			"String Interpolation",
+			"\"n=${n + 1}!\";",
+			[]Stmt{
+				NewExpressionStmt(
+					NewBinaryExpr(
+						NewBinaryExpr(
+							NewLiteralExpr("n=", 1),
+							NewToken(TOKEN_PLUS, "+", nil, 1),
+							NewBinaryExpr(
+								NewVariableExpr(
+									NewToken(TOKEN_IDENTIFIER, "n", "n", 1),
+								),
+								NewToken(TOKEN_PLUS, "+", nil, 1),
+								NewLiteralExpr(int64(1), 1),
+							),
+						),
+						NewToken(TOKEN_PLUS, "+", nil, 1),
+						NewLiteralExpr("!", 1),
+					),
+				),
+			},
+		},
 		{
 			"Function Declaration Statement",
 			"fun testFunction(arg1,arg2,arg3){print 1;}",
@@ -243,7 +313,7 @@ func TestParserStatements(t *testing.T) {
 					},
 					[]Stmt{
 						NewPrintStmt(
-							NewLiteralExpr(1.0, 1),
+							NewLiteralExpr(int64(1), 1),
 						),
 					},
 				),
@@ -256,7 +326,7 @@ func TestParserStatements(t *testing.T) {
 				NewReturnStmt(
 					NewToken(TOKEN_RETURN, "return", nil, 1),
 					NewBinaryExpr(
-						NewLiteralExpr(2.0, 1),
+						NewLiteralExpr(int64(2), 1),
 						NewToken(TOKEN_STAR, "*", nil, 1),
 						NewVariableExpr(
 							NewToken(TOKEN_IDENTIFIER, "testVar", "testVar", 1),
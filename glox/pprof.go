@@ -0,0 +1,184 @@
+package glox
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+// Field numbers of the pprof profile.proto messages.
+const (
+	PPROF_PROFILE_SAMPLE_TYPE    = 1
+	PPROF_PROFILE_SAMPLE         = 2
+	PPROF_PROFILE_LOCATION       = 4
+	PPROF_PROFILE_FUNCTION       = 5
+	PPROF_PROFILE_STRING_TABLE   = 6
+	PPROF_PROFILE_TIME_NANOS     = 9
+	PPROF_PROFILE_DURATION_NANOS = 10
+	PPROF_VALUE_TYPE_TYPE        = 1
+	PPROF_VALUE_TYPE_UNIT        = 2
+	PPROF_SAMPLE_LOCATION_ID     = 1
+	PPROF_SAMPLE_VALUE           = 2
+	PPROF_LOCATION_ID            = 1
+	PPROF_LOCATION_LINE          = 4
+	PPROF_LINE_FUNCTION_ID       = 1
+	PPROF_LINE_LINE              = 2
+	PPROF_FUNCTION_ID            = 1
+	PPROF_FUNCTION_NAME          = 2
+	PPROF_FUNCTION_SYSTEM_NAME   = 3
+	PPROF_FUNCTION_FILENAME      = 4
+	PPROF_FUNCTION_START_LINE    = 5
+)
+
+const (
+	protoWireVarint = 0
+	protoWireBytes  = 2
+)
+
+// protoBuffer encodes protocol buffer messages, which is all pprof needs
+// of the protobuf library.
+type protoBuffer struct {
+	bytes.Buffer
+}
+
+func (b *protoBuffer) varint(value uint64) {
+	for value >= 0x80 {
+		b.WriteByte(byte(value) | 0x80)
+		value >>= 7
+	}
+	b.WriteByte(byte(value))
+}
+
+func (b *protoBuffer) key(field int, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (b *protoBuffer) int64Field(field int, value int64) {
+	if value == 0 {
+		return
+	}
+	b.key(field, protoWireVarint)
+	b.varint(uint64(value))
+}
+
+func (b *protoBuffer) bytesField(field int, value []byte) {
+	b.key(field, protoWireBytes)
+	b.varint(uint64(len(value)))
+	b.Write(value)
+}
+
+func (b *protoBuffer) messageField(field int, message *protoBuffer) {
+	b.bytesField(field, message.Bytes())
+}
+
+func (b *protoBuffer) packedField(field int, values []int64) {
+	packed := &protoBuffer{}
+	for _, value := range values {
+		packed.varint(uint64(value))
+	}
+	b.bytesField(field, packed.Bytes())
+}
+
+type pprofLocationKey struct {
+	function *ProfileFunction
+	line     int
+}
+
+// pprofWriter builds the tables of a profile: strings, functions and
+// locations are referenced by index or id.
+type pprofWriter struct {
+	profile   protoBuffer
+	strings   map[string]int64
+	functions map[*ProfileFunction]int64
+	locations map[pprofLocationKey]int64
+	filename  string
+}
+
+func (w *pprofWriter) stringIndex(value string) int64 {
+	index, ok := w.strings[value]
+	if !ok {
+		index = int64(len(w.strings))
+		w.strings[value] = index
+		w.profile.bytesField(PPROF_PROFILE_STRING_TABLE, []byte(value))
+	}
+	return index
+}
+
+func (w *pprofWriter) functionId(function *ProfileFunction) int64 {
+	id, ok := w.functions[function]
+	if !ok {
+		id = int64(len(w.functions) + 1)
+		w.functions[function] = id
+		// pprof drops names in angle brackets, like C++ template arguments.
+		name := strings.Trim(function.Name, "<>")
+		message := &protoBuffer{}
+		message.int64Field(PPROF_FUNCTION_ID, id)
+		message.int64Field(PPROF_FUNCTION_NAME, w.stringIndex(name))
+		message.int64Field(PPROF_FUNCTION_SYSTEM_NAME, w.stringIndex(function.Name))
+		message.int64Field(PPROF_FUNCTION_FILENAME, w.stringIndex(w.filename))
+		message.int64Field(PPROF_FUNCTION_START_LINE, int64(function.Line))
+		w.profile.messageField(PPROF_PROFILE_FUNCTION, message)
+	}
+	return id
+}
+
+func (w *pprofWriter) locationId(function *ProfileFunction, line int) int64 {
+	key := pprofLocationKey{function: function, line: line}
+	id, ok := w.locations[key]
+	if !ok {
+		functionId := w.functionId(function)
+		id = int64(len(w.locations) + 1)
+		w.locations[key] = id
+		lineMessage := &protoBuffer{}
+		lineMessage.int64Field(PPROF_LINE_FUNCTION_ID, functionId)
+		lineMessage.int64Field(PPROF_LINE_LINE, int64(line))
+		message := &protoBuffer{}
+		message.int64Field(PPROF_LOCATION_ID, id)
+		message.messageField(PPROF_LOCATION_LINE, lineMessage)
+		w.profile.messageField(PPROF_PROFILE_LOCATION, message)
+	}
+	return id
+}
+
+func (w *pprofWriter) valueType(valueType string, unit string) {
+	message := &protoBuffer{}
+	message.int64Field(PPROF_VALUE_TYPE_TYPE, w.stringIndex(valueType))
+	message.int64Field(PPROF_VALUE_TYPE_UNIT, w.stringIndex(unit))
+	w.profile.messageField(PPROF_PROFILE_SAMPLE_TYPE, message)
+}
+
+// WritePprof writes the profile in the gzipped protocol buffer format of
+// pprof. Each line of each calling context is a sample, valued with the
+// times its statements were executed and the self time they took.
+func (p *Profiler) WritePprof(writer io.Writer) error {
+	w := &pprofWriter{
+		strings:   map[string]int64{},
+		functions: map[*ProfileFunction]int64{},
+		locations: map[pprofLocationKey]int64{},
+		filename:  p.filename,
+	}
+	w.stringIndex("")
+	w.valueType("executions", "count")
+	w.valueType("time", "nanoseconds")
+	p.walk(p.root, func(node *profileNode) {
+		for _, line := range sortedLines(node.self) {
+			locations := []int64{w.locationId(node.function, line)}
+			for n := node; n.parent != nil; n = n.parent {
+				locations = append(locations, w.locationId(n.parent.function, n.callLine))
+			}
+			sample := &protoBuffer{}
+			sample.packedField(PPROF_SAMPLE_LOCATION_ID, locations)
+			sample.packedField(PPROF_SAMPLE_VALUE, []int64{int64(node.count[line]), int64(node.self[line])})
+			w.profile.messageField(PPROF_PROFILE_SAMPLE, sample)
+		}
+	})
+	w.profile.int64Field(PPROF_PROFILE_TIME_NANOS, p.start.UnixNano())
+	w.profile.int64Field(PPROF_PROFILE_DURATION_NANOS, int64(p.duration))
+
+	compressed := gzip.NewWriter(writer)
+	if _, err := compressed.Write(w.profile.Bytes()); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
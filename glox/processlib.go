@@ -0,0 +1,194 @@
+package glox
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func newProcessModule() *NativeModule {
+	return NewNativeModule("process", map[string]interface{}{
+		"run":    NewNativeCallable("run", -1, processRun),
+		"stream": NewNativeCallable("stream", -1, processStream),
+	})
+}
+
+/*
+ * processRun runs a command to completion: process.run(argv, options?).
+ * argv is a list with the program and its arguments, options is a map with
+ * optional "cwd", "env" (a map of variables added to the current
+ * environment) and "stdin" (a string). It returns a map with the captured
+ * "stdout" and "stderr", the exit "status" and "ok" when the status is 0.
+ */
+func processRun(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("run", arguments, 1, 2); err != nil {
+		return nil, err
+	}
+	cmd, err := inter.newCommand("run", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// other goroutines, such as net.serve handlers, run while it waits.
+	var runErr error
+	inter.withoutLock(func() {
+		runErr = cmd.Run()
+	})
+	status, err := waitStatus(runErr)
+	if err != nil {
+		return nil, fmt.Errorf("run: %w", err)
+	}
+	return newProcessResult(status, stdout.String(), stderr.String()), nil
+}
+
+/*
+ * processStream runs a command calling a Lox function with every line the
+ * command writes to its stdout, as soon as it is written:
+ * process.stream(argv, callback, options?). It returns the same map as
+ * process.run, without "stdout".
+ */
+func processStream(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("stream", arguments, 2, 3); err != nil {
+		return nil, err
+	}
+	cmd, err := inter.newCommand("stream", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stream: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("stream: %w", err)
+	}
+	reader := bufio.NewReader(stdout)
+	kill := func() {
+		inter.withoutLock(func() {
+			cmd.Process.Kill()
+			cmd.Wait()
+		})
+	}
+	for {
+		// the lock is only held to call the callback, as in process.run.
+		var line string
+		var readErr error
+		inter.withoutLock(func() {
+			line, readErr = reader.ReadString('\n')
+		})
+		if len(line) > 0 {
+			if _, err := inter.callValue(arguments[1], []interface{}{strings.TrimRight(line, "\r\n")}); err != nil {
+				kill()
+				return nil, fmt.Errorf("stream: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		} else if readErr != nil {
+			kill()
+			return nil, fmt.Errorf("stream: %w", readErr)
+		}
+	}
+	var waitErr error
+	inter.withoutLock(func() {
+		waitErr = cmd.Wait()
+	})
+	status, err := waitStatus(waitErr)
+	if err != nil {
+		return nil, fmt.Errorf("stream: %w", err)
+	}
+	result := newProcessResult(status, "", stderr.String())
+	result.Delete("stdout")
+	return result, nil
+}
+
+// newCommand builds the command described by the argv list in arguments[0]
+// and the options map in arguments[optionsIndex], if present.
+func (inter *Interpreter) newCommand(name string, arguments []interface{}, optionsIndex int) (*exec.Cmd, error) {
+	if !inter.allowProcesses {
+		return nil, fmt.Errorf("%s: process execution is disabled", name)
+	}
+	argv, err := listArgument(name, arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(argv.Elements) == 0 {
+		return nil, fmt.Errorf("%s: argv must not be empty", name)
+	}
+	args := []string{}
+	for i, element := range argv.Elements {
+		arg, ok := element.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: argv element %d must be a string, got %s", name, i, typeName(element))
+		}
+		args = append(args, arg)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	if optionsIndex >= len(arguments) || arguments[optionsIndex] == nil {
+		return cmd, nil
+	}
+	options, ok := arguments[optionsIndex].(*MapValue)
+	if !ok {
+		return nil, fmt.Errorf("%s: argument %d must be a map, got %s", name, optionsIndex+1, typeName(arguments[optionsIndex]))
+	}
+	for _, key := range options.Keys() {
+		value, _ := options.Get(key)
+		switch key {
+		case "cwd":
+			cwd, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: option cwd must be a string, got %s", name, typeName(value))
+			}
+			cmd.Dir = cwd
+		case "stdin":
+			stdin, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: option stdin must be a string, got %s", name, typeName(value))
+			}
+			cmd.Stdin = strings.NewReader(stdin)
+		case "env":
+			env, ok := value.(*MapValue)
+			if !ok {
+				return nil, fmt.Errorf("%s: option env must be a map, got %s", name, typeName(value))
+			}
+			cmd.Env = os.Environ()
+			for _, variable := range env.Keys() {
+				variableValue, _ := env.Get(variable)
+				cmd.Env = append(cmd.Env, Stringify(variable)+"="+Stringify(variableValue))
+			}
+		default:
+			return nil, fmt.Errorf("%s: unknown option %s", name, Stringify(key))
+		}
+	}
+	return cmd, nil
+}
+
+// waitStatus extracts the exit status of a finished command. Exiting with a
+// non-zero status is not an error, failing to run the command is.
+func waitStatus(err error) (int, error) {
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		return exitError.ExitCode(), nil
+	} else if err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+func newProcessResult(status int, stdout string, stderr string) *MapValue {
+	result := NewMapValue()
+	result.Set("status", int64(status))
+	result.Set("ok", status == 0)
+	result.Set("stdout", stdout)
+	result.Set("stderr", stderr)
+	return result
+}
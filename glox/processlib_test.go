@@ -0,0 +1,105 @@
+package glox
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessModule(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+	}{
+		{"stdout", "process.run(list(\"echo\", \"hello\", \"world\")).get(\"stdout\");", "hello world\n"},
+		{"stderr", "process.run(list(\"sh\", \"-c\", \"echo oops >&2\")).get(\"stderr\");", "oops\n"},
+		{"status", "var r = process.run(list(\"sh\", \"-c\", \"exit 3\")); \"${r.get(\"status\")} ${r.get(\"ok\")}\";", "3 false"},
+		{"ok", "process.run(list(\"true\")).get(\"ok\");", true},
+		{"stdin", "var options = map(); options.set(\"stdin\", \"piped\"); process.run(list(\"cat\"), options).get(\"stdout\");", "piped"},
+		{"env", "var env = map(); env.set(\"GLOX_TEST\", 42); var options = map(); options.set(\"env\", env); process.run(list(\"sh\", \"-c\", \"echo $GLOX_TEST\"), options).get(\"stdout\");", "42\n"},
+		{"cwd", "var options = map(); options.set(\"cwd\", \"/\"); process.run(list(\"pwd\"), options).get(\"stdout\");", "/\n"},
+		{"stream", "var lines = list(); fun collect(line) { lines.push(line); } var r = process.stream(list(\"sh\", \"-c\", \"echo a; echo b; echo c\"), collect); \"${lines} ${r.get(\"status\")}\";", "[\"a\", \"b\", \"c\"] 0"},
+	}
+	for _, testCase := range testCases {
+		lastValue, _, stderr := interpretSource(testCase.source)
+		assert.Empty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestProcessModuleErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"empty argv", "process.run(list());", "[line 1] Error[interpreter]: run: argv must not be empty\n"},
+		{"non-string argv", "process.run(list(\"echo\", 1));", "[line 1] Error[interpreter]: run: argv element 1 must be a string, got number\n"},
+		{"unknown option", "var options = map(); options.set(\"shell\", true); process.run(list(\"true\"), options);", "[line 1] Error[interpreter]: run: unknown option shell\n"},
+		{"missing executable", "process.run(list(\"glox-no-such-command\"));", "[line 1] Error[interpreter]: run: exec: \"glox-no-such-command\": executable file not found in $PATH\n"},
+	}
+	for _, testCase := range testCases {
+		_, _, stderr := interpretSource(testCase.source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+	}
+}
+
+func TestProcessModuleDenied(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	errorReporter := NewConsoleErrorReporterWithWriter(&stderr)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), &stdout, &stderr)
+	interpreter.SetAllowProcesses(false)
+	scanner := NewScanner("process.run(list(\"true\"));", errorReporter)
+	parser := NewParser(scanner.ScanTokens(), errorReporter)
+	interpreter.Interpret(parser.Parse())
+	assert.Equal(t, "[line 1] Error[interpreter]: run: process execution is disabled\n", stderr.String())
+}
+
+// TestProcessRunReleasesLock serves requests while process.run waits for a
+// process, which only exits once the test has been answered.
+func TestProcessRunReleasesLock(t *testing.T) {
+	dir := t.TempDir()
+	addressFile, doneFile := filepath.Join(dir, "address"), filepath.Join(dir, "done")
+	source := `
+fun handle(request) {
+  return "served";
+}
+var server = net.serve("127.0.0.1:0", handle);
+fs.writeFile("ADDRESS", server.address);
+process.run(list("sh", "-c", "while [ ! -e DONE ]; do sleep 0.01; done"));
+server.close();
+server.wait();
+`
+	source = strings.ReplaceAll(strings.ReplaceAll(source, "ADDRESS", addressFile), "DONE", doneFile)
+	finished := make(chan string)
+	go func() {
+		_, _, stderr := interpretSource(source)
+		finished <- stderr
+	}()
+	defer func() {
+		os.WriteFile(doneFile, nil, 0644)
+		assert.Empty(t, <-finished)
+	}()
+
+	var address []byte
+	for deadline := time.Now().Add(5 * time.Second); len(address) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		address, _ = os.ReadFile(addressFile)
+	}
+	client := &http.Client{Timeout: 2 * time.Second}
+	response, err := client.Get("http://" + string(address))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "served", string(body))
+}
@@ -0,0 +1,381 @@
+package glox
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	PROFILE_SORT_SELF  = "self"
+	PROFILE_SORT_TOTAL = "total"
+)
+
+const (
+	PROFILE_FORMAT_TEXT   = "text"
+	PROFILE_FORMAT_FOLDED = "folded"
+	PROFILE_FORMAT_PPROF  = "pprof"
+)
+
+// ProfileFunction is the time spent in a function, identified by its name
+// and the line it is declared on. Self is the time spent in its own
+// statements and Total includes the functions it called. The time of
+// recursive calls is only counted once in Total.
+type ProfileFunction struct {
+	Name  string
+	Line  int
+	Calls int
+	Self  time.Duration
+	Total time.Duration
+}
+
+// ProfileLine is the time spent in the statements of a line, executed Count
+// times.
+type ProfileLine struct {
+	Line  int
+	Count int
+	Self  time.Duration
+	Total time.Duration
+}
+
+type profileFunctionKey struct {
+	name string
+	line int
+}
+
+// profileNode is a function in a calling context: the node of its caller
+// and the line it was called from. Time and executions are kept by the
+// line of the function's statement that was running.
+type profileNode struct {
+	function *ProfileFunction
+	parent   *profileNode
+	callLine int
+	children map[profileNodeKey]*profileNode
+	self     map[int]time.Duration
+	count    map[int]int
+}
+
+type profileNodeKey struct {
+	function *ProfileFunction
+	callLine int
+}
+
+// profileFrame is a running call. line is the line of the statement the
+// call is running, or 0 between statements.
+type profileFrame struct {
+	node  *profileNode
+	line  int
+	start time.Time
+}
+
+type profileStatement struct {
+	line  int
+	depth int
+	start time.Time
+}
+
+/*
+ * Profiler measures where an Interpreter spends its time, by function and by
+ * line, timing every statement and function call. Time is charged to the
+ * statement running at the moment, and to the function it belongs to in its
+ * calling context, which gives the self time of lines and functions, the
+ * folded stacks of flame graphs and the samples of a pprof profile. Time
+ * spent in natives is charged to the statement calling them.
+ */
+type Profiler struct {
+	filename   string
+	clock      func() time.Time
+	start      time.Time
+	last       time.Time
+	duration   time.Duration
+	root       *profileNode
+	frames     []profileFrame
+	statements []profileStatement
+	functions  map[profileFunctionKey]*ProfileFunction
+	lines      map[int]*ProfileLine
+	// active counts the running calls of each function and statements of
+	// each line, so that recursion is only counted once in totals.
+	activeFunctions map[*ProfileFunction]int
+	activeLines     map[int]int
+}
+
+func NewProfiler(filename string) *Profiler {
+	return NewProfilerWithClock(filename, time.Now)
+}
+
+func NewProfilerWithClock(filename string, clock func() time.Time) *Profiler {
+	profiler := &Profiler{
+		filename:        filename,
+		clock:           clock,
+		functions:       map[profileFunctionKey]*ProfileFunction{},
+		lines:           map[int]*ProfileLine{},
+		activeFunctions: map[*ProfileFunction]int{},
+		activeLines:     map[int]int{},
+	}
+	profiler.root = profiler.newNode(profiler.function(DEBUG_SCRIPT_FRAME, 0), nil, 0)
+	profiler.root.function.Calls = 1
+	return profiler
+}
+
+// SetProfiler makes the interpreter time its statements and function calls
+// from now on, until the profiler is stopped.
+func (inter *Interpreter) SetProfiler(profiler *Profiler) {
+	inter.profiler = profiler
+	profiler.start = profiler.clock()
+	profiler.last = profiler.start
+	profiler.frames = []profileFrame{{node: profiler.root, start: profiler.start}}
+}
+
+// Stop ends the profile. Statements and calls still running, as after a
+// runtime error, are counted up to now.
+func (p *Profiler) Stop() {
+	now := p.charge()
+	for len(p.statements) > 0 {
+		p.exitStatement()
+	}
+	for len(p.frames) > 1 {
+		p.exitFunction()
+	}
+	p.root.function.Total = now.Sub(p.start)
+	p.duration = now.Sub(p.start)
+}
+
+func (p *Profiler) function(name string, line int) *ProfileFunction {
+	key := profileFunctionKey{name: name, line: line}
+	function, ok := p.functions[key]
+	if !ok {
+		function = &ProfileFunction{Name: name, Line: line}
+		p.functions[key] = function
+	}
+	return function
+}
+
+func (p *Profiler) newNode(function *ProfileFunction, parent *profileNode, callLine int) *profileNode {
+	return &profileNode{
+		function: function,
+		parent:   parent,
+		callLine: callLine,
+		children: map[profileNodeKey]*profileNode{},
+		self:     map[int]time.Duration{},
+		count:    map[int]int{},
+	}
+}
+
+// charge adds the time since the last event to the running statement.
+func (p *Profiler) charge() time.Time {
+	now := p.clock()
+	elapsed := now.Sub(p.last)
+	p.last = now
+	frame := p.frames[len(p.frames)-1]
+	frame.node.self[frame.line] += elapsed
+	frame.node.function.Self += elapsed
+	if line, ok := p.lines[frame.line]; ok {
+		line.Self += elapsed
+	}
+	return now
+}
+
+func (p *Profiler) enterStatement(stmt Stmt) {
+	now := p.charge()
+	line := stmt.getLine()
+	frame := &p.frames[len(p.frames)-1]
+	frame.line = line
+	frame.node.count[line]++
+	entry, ok := p.lines[line]
+	if !ok {
+		entry = &ProfileLine{Line: line}
+		p.lines[line] = entry
+	}
+	entry.Count++
+	p.activeLines[line]++
+	p.statements = append(p.statements, profileStatement{line: line, depth: len(p.frames), start: now})
+}
+
+func (p *Profiler) exitStatement() {
+	now := p.charge()
+	statement := p.statements[len(p.statements)-1]
+	p.statements = p.statements[:len(p.statements)-1]
+	p.activeLines[statement.line]--
+	if p.activeLines[statement.line] == 0 {
+		p.lines[statement.line].Total += now.Sub(statement.start)
+	}
+	// back to the statement that encloses it in the same frame, if any.
+	frame := &p.frames[len(p.frames)-1]
+	frame.line = 0
+	if len(p.statements) > 0 {
+		enclosing := p.statements[len(p.statements)-1]
+		if enclosing.depth == len(p.frames) {
+			frame.line = enclosing.line
+		}
+	}
+}
+
+// enterFunction pushes the frame of a call. Until its first statement, and
+// after its last one, time is charged to the function but to none of its
+// lines.
+func (p *Profiler) enterFunction(name string, line int) {
+	now := p.charge()
+	caller := p.frames[len(p.frames)-1]
+	function := p.function(name, line)
+	function.Calls++
+	key := profileNodeKey{function: function, callLine: caller.line}
+	node, ok := caller.node.children[key]
+	if !ok {
+		node = p.newNode(function, caller.node, caller.line)
+		caller.node.children[key] = node
+	}
+	p.activeFunctions[function]++
+	p.frames = append(p.frames, profileFrame{node: node, start: now})
+}
+
+func (p *Profiler) exitFunction() {
+	now := p.charge()
+	frame := p.frames[len(p.frames)-1]
+	p.frames = p.frames[:len(p.frames)-1]
+	function := frame.node.function
+	p.activeFunctions[function]--
+	if p.activeFunctions[function] == 0 {
+		function.Total += now.Sub(frame.start)
+	}
+}
+
+// Duration returns the time the profile covers.
+func (p *Profiler) Duration() time.Duration {
+	return p.duration
+}
+
+// Functions returns the functions called, sorted by self or total time,
+// most expensive first.
+func (p *Profiler) Functions(sortBy string) []ProfileFunction {
+	functions := []ProfileFunction{}
+	for _, function := range p.functions {
+		functions = append(functions, *function)
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		a, b := functions[i], functions[j]
+		if profileLess(a.Self, a.Total, b.Self, b.Total, sortBy) {
+			return false
+		}
+		if profileLess(b.Self, b.Total, a.Self, a.Total, sortBy) {
+			return true
+		}
+		return a.Line < b.Line
+	})
+	return functions
+}
+
+// Lines returns the lines executed, sorted by self or total time, most
+// expensive first.
+func (p *Profiler) Lines(sortBy string) []ProfileLine {
+	lines := []ProfileLine{}
+	for _, line := range p.lines {
+		lines = append(lines, *line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		a, b := lines[i], lines[j]
+		if profileLess(a.Self, a.Total, b.Self, b.Total, sortBy) {
+			return false
+		}
+		if profileLess(b.Self, b.Total, a.Self, a.Total, sortBy) {
+			return true
+		}
+		return a.Line < b.Line
+	})
+	return lines
+}
+
+func profileLess(selfA time.Duration, totalA time.Duration, selfB time.Duration, totalB time.Duration, sortBy string) bool {
+	if sortBy == PROFILE_SORT_TOTAL {
+		return totalA < totalB || (totalA == totalB && selfA < selfB)
+	}
+	return selfA < selfB || (selfA == selfB && totalA < totalB)
+}
+
+// WriteReport writes tables of the functions and lines of the profile,
+// sorted by self or total time.
+func (p *Profiler) WriteReport(writer io.Writer, sortBy string) {
+	fmt.Fprintf(writer, "Profile of %s: %s\n", p.filename, formatProfileTime(p.duration))
+	fmt.Fprintf(writer, "\nFunctions by %s time:\n", sortBy)
+	fmt.Fprintf(writer, "%10s %6s %10s %6s %8s  %s\n", "self", "self%", "total", "total%", "calls", "function")
+	for _, function := range p.Functions(sortBy) {
+		name := function.Name
+		if function.Line > 0 {
+			name = fmt.Sprintf("%s (line %d)", function.Name, function.Line)
+		}
+		fmt.Fprintf(writer, "%10s %6s %10s %6s %8d  %s\n",
+			formatProfileTime(function.Self), p.percent(function.Self),
+			formatProfileTime(function.Total), p.percent(function.Total), function.Calls, name)
+	}
+	fmt.Fprintf(writer, "\nLines by %s time:\n", sortBy)
+	fmt.Fprintf(writer, "%10s %6s %10s %6s %8s  %s\n", "self", "self%", "total", "total%", "count", "line")
+	for _, line := range p.Lines(sortBy) {
+		fmt.Fprintf(writer, "%10s %6s %10s %6s %8d  %d\n",
+			formatProfileTime(line.Self), p.percent(line.Self),
+			formatProfileTime(line.Total), p.percent(line.Total), line.Count, line.Line)
+	}
+}
+
+func formatProfileTime(duration time.Duration) string {
+	return fmt.Sprintf("%.3fms", float64(duration)/float64(time.Millisecond))
+}
+
+func (p *Profiler) percent(duration time.Duration) string {
+	if p.duration == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(duration)/float64(p.duration))
+}
+
+// WriteFolded writes the self time of each call stack, in microseconds, in
+// the folded format read by flame graph tools: the functions from the
+// outermost, separated by semicolons, and the time.
+func (p *Profiler) WriteFolded(writer io.Writer) {
+	stacks := map[string]time.Duration{}
+	p.walk(p.root, func(node *profileNode) {
+		names := []string{}
+		for n := node; n != nil; n = n.parent {
+			names = append([]string{n.function.Name}, names...)
+		}
+		for _, self := range node.self {
+			stacks[strings.Join(names, ";")] += self
+		}
+	})
+	keys := []string{}
+	for stack := range stacks {
+		keys = append(keys, stack)
+	}
+	sort.Strings(keys)
+	for _, stack := range keys {
+		fmt.Fprintf(writer, "%s %d\n", stack, stacks[stack].Microseconds())
+	}
+}
+
+// walk calls visit on node and its descendants, in order of call line.
+func (p *Profiler) walk(node *profileNode, visit func(node *profileNode)) {
+	visit(node)
+	children := []*profileNode{}
+	for _, child := range node.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].callLine != children[j].callLine {
+			return children[i].callLine < children[j].callLine
+		}
+		return children[i].function.Line < children[j].function.Line
+	})
+	for _, child := range children {
+		p.walk(child, visit)
+	}
+}
+
+// sortedLines returns the lines a map is keyed by, in order.
+func sortedLines(lines map[int]time.Duration) []int {
+	sorted := []int{}
+	for line := range lines {
+		sorted = append(sorted, line)
+	}
+	sort.Ints(sorted)
+	return sorted
+}
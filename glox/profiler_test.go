@@ -0,0 +1,247 @@
+package glox
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// profileSource runs source with a profiler whose clock advances one
+// millisecond each time it is read.
+func profileSource(source string) (*Profiler, string) {
+	var stdout, stderr bytes.Buffer
+	errorReporter := NewConsoleErrorReporterWithWriter(&stderr)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), &stdout, &stderr)
+	now := time.Unix(0, 0)
+	profiler := NewProfilerWithClock("test.glox", func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	})
+	interpreter.SetProfiler(profiler)
+	parser := NewParser(NewScanner(source, errorReporter).ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	resolver := NewResolver(&interpreter)
+	resolver.ResolveStatements(statements)
+	interpreter.Interpret(statements)
+	profiler.Stop()
+	return profiler, stderr.String()
+}
+
+const profileTestSource = `fun f() {
+  return 1;
+}
+f();
+f();
+`
+
+func TestProfilerTimes(t *testing.T) {
+	profiler, _ := profileSource(profileTestSource)
+	assert.Equal(t, 15*time.Millisecond, profiler.Duration())
+	assert.Equal(t, []ProfileFunction{
+		{Name: "<script>", Line: 0, Calls: 1, Self: 9 * time.Millisecond, Total: 15 * time.Millisecond},
+		{Name: "f", Line: 1, Calls: 2, Self: 6 * time.Millisecond, Total: 6 * time.Millisecond},
+	}, profiler.Functions(PROFILE_SORT_SELF))
+	assert.Equal(t, []ProfileLine{
+		{Line: 4, Count: 1, Self: 2 * time.Millisecond, Total: 5 * time.Millisecond},
+		{Line: 5, Count: 1, Self: 2 * time.Millisecond, Total: 5 * time.Millisecond},
+		{Line: 2, Count: 2, Self: 2 * time.Millisecond, Total: 2 * time.Millisecond},
+		{Line: 1, Count: 1, Self: 1 * time.Millisecond, Total: 1 * time.Millisecond},
+	}, profiler.Lines(PROFILE_SORT_SELF))
+}
+
+func TestProfilerReport(t *testing.T) {
+	profiler, _ := profileSource(profileTestSource)
+	var report bytes.Buffer
+	profiler.WriteReport(&report, PROFILE_SORT_TOTAL)
+	assert.Equal(t, `Profile of test.glox: 15.000ms
+
+Functions by total time:
+      self  self%      total total%    calls  function
+   9.000ms  60.0%   15.000ms 100.0%        1  <script>
+   6.000ms  40.0%    6.000ms  40.0%        2  f (line 1)
+
+Lines by total time:
+      self  self%      total total%    count  line
+   2.000ms  13.3%    5.000ms  33.3%        1  4
+   2.000ms  13.3%    5.000ms  33.3%        1  5
+   2.000ms  13.3%    2.000ms  13.3%        2  2
+   1.000ms   6.7%    1.000ms   6.7%        1  1
+`, report.String())
+}
+
+func TestProfilerFolded(t *testing.T) {
+	profiler, _ := profileSource(`fun leaf() {
+  return 1;
+}
+fun middle() {
+  return leaf();
+}
+middle();
+leaf();
+`)
+	var folded bytes.Buffer
+	profiler.WriteFolded(&folded)
+	assert.Equal(t, "<script> 11000\n<script>;leaf 3000\n<script>;middle 4000\n<script>;middle;leaf 3000\n", folded.String())
+}
+
+func TestProfilerTailCall(t *testing.T) {
+	profiler, _ := profileSource(`fun leaf() {
+  return 1;
+}
+fun middle() {
+  return leaf();
+}
+middle();
+`)
+	var folded bytes.Buffer
+	profiler.WriteFolded(&folded)
+	// the tail call is profiled under middle, though it replaces it on the
+	// Go stack.
+	assert.Equal(t, "<script> 8000\n<script>;middle 4000\n<script>;middle;leaf 3000\n", folded.String())
+}
+
+func TestProfilerDeepTailRecursion(t *testing.T) {
+	profiler, _ := profileSource(`fun isEven(n) {
+  if (n == 0) return true;
+  return isOdd(n - 1);
+}
+fun isOdd(n) {
+  if (n == 0) return false;
+  return isEven(n - 1);
+}
+isEven(100000);
+`)
+	// the mutual tail recursion is profiled as two frames, not 100000.
+	var folded bytes.Buffer
+	profiler.WriteFolded(&folded)
+	stacks := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(folded.String()), "\n") {
+		stacks = append(stacks, strings.Fields(line)[0])
+	}
+	assert.Equal(t, []string{"<script>", "<script>;isEven", "<script>;isEven;isOdd"}, stacks)
+	var pprof bytes.Buffer
+	assert.NoError(t, profiler.WritePprof(&pprof))
+	assert.Less(t, pprof.Len(), 1000)
+	calls := map[string]int{}
+	for _, function := range profiler.Functions(PROFILE_SORT_TOTAL) {
+		calls[function.Name] = function.Calls
+	}
+	assert.Equal(t, map[string]int{"<script>": 1, "isEven": 50001, "isOdd": 50000}, calls)
+}
+
+func TestProfilerRecursion(t *testing.T) {
+	profiler, _ := profileSource(`fun r(n) {
+  if (n > 0) r(n - 1);
+}
+r(3);
+`)
+	functions := profiler.Functions(PROFILE_SORT_TOTAL)
+	script, r := functions[0], functions[1]
+	assert.Equal(t, 4, r.Calls)
+	// recursive calls are counted once in totals.
+	assert.Equal(t, profiler.Duration(), script.Self+r.Total)
+	lines := profiler.Lines(PROFILE_SORT_TOTAL)
+	assert.Equal(t, 4, lines[0].Line)
+	assert.Equal(t, 2, lines[1].Line)
+	// the if statement and the call in it, but for the last call.
+	assert.Equal(t, 7, lines[1].Count)
+	assert.Equal(t, r.Total-2*time.Millisecond, lines[1].Total)
+}
+
+func TestProfilerRuntimeError(t *testing.T) {
+	profiler, stderr := profileSource("fun f() {\n  return 1 / nil;\n}\nf();\nprint 1;\n")
+	assert.Equal(t, "[line 2] Error[interpreter]: operator /: operands must be numbers: cannot convert to float: <nil>\n", stderr)
+	functions := profiler.Functions(PROFILE_SORT_SELF)
+	assert.Len(t, functions, 2)
+	lines := profiler.Lines(PROFILE_SORT_SELF)
+	assert.Len(t, lines, 3, "line 5 never runs")
+}
+
+// protoFields decodes the fields of a protocol buffer message, as varints
+// or as bytes.
+func protoFields(t *testing.T, message []byte) map[int][]interface{} {
+	fields := map[int][]interface{}{}
+	varint := func() uint64 {
+		var value uint64
+		for shift := 0; ; shift += 7 {
+			b := message[0]
+			message = message[1:]
+			value |= uint64(b&0x7f) << shift
+			if b < 0x80 {
+				return value
+			}
+		}
+	}
+	for len(message) > 0 {
+		key := varint()
+		field := int(key >> 3)
+		switch key & 7 {
+		case protoWireVarint:
+			fields[field] = append(fields[field], varint())
+		case protoWireBytes:
+			length := varint()
+			fields[field] = append(fields[field], message[:length])
+			message = message[length:]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+	}
+	return fields
+}
+
+func packedValues(packed []byte) []uint64 {
+	values := []uint64{}
+	var value uint64
+	shift := 0
+	for _, b := range packed {
+		value |= uint64(b&0x7f) << shift
+		shift += 7
+		if b < 0x80 {
+			values = append(values, value)
+			value, shift = 0, 0
+		}
+	}
+	return values
+}
+
+func TestProfilerPprof(t *testing.T) {
+	profiler, _ := profileSource(profileTestSource)
+	var output bytes.Buffer
+	assert.NoError(t, profiler.WritePprof(&output))
+	reader, err := gzip.NewReader(&output)
+	if !assert.NoError(t, err) {
+		return
+	}
+	content, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+
+	fields := protoFields(t, content)
+	stringTable := []string{}
+	for _, value := range fields[PPROF_PROFILE_STRING_TABLE] {
+		stringTable = append(stringTable, string(value.([]byte)))
+	}
+	assert.Equal(t, []string{"", "executions", "count", "time", "nanoseconds", "script", "<script>", "test.glox", "f"}, stringTable)
+	assert.Len(t, fields[PPROF_PROFILE_SAMPLE_TYPE], 2)
+	assert.Len(t, fields[PPROF_PROFILE_FUNCTION], 2)
+	assert.Equal(t, []interface{}{uint64(15 * time.Millisecond)}, fields[PPROF_PROFILE_DURATION_NANOS])
+
+	executions, nanoseconds := uint64(0), uint64(0)
+	stacks := [][]uint64{}
+	for _, sample := range fields[PPROF_PROFILE_SAMPLE] {
+		sampleFields := protoFields(t, sample.([]byte))
+		values := packedValues(sampleFields[PPROF_SAMPLE_VALUE][0].([]byte))
+		executions += values[0]
+		nanoseconds += values[1]
+		stacks = append(stacks, packedValues(sampleFields[PPROF_SAMPLE_LOCATION_ID][0].([]byte)))
+	}
+	assert.Equal(t, uint64(5), executions)
+	assert.Equal(t, uint64(15*time.Millisecond), nanoseconds)
+	// the script at lines 0, 1, 4 and 5, and f at lines 0 and 2 called from
+	// lines 4 and 5.
+	assert.Equal(t, [][]uint64{{1}, {2}, {3}, {4}, {5, 3}, {6, 3}, {5, 4}, {6, 4}}, stacks)
+}
@@ -0,0 +1,126 @@
+package glox
+
+import (
+	"fmt"
+	"math"
+)
+
+// REPRODUCIBLE_RANDOM_SEED seeds the random module of interpreters in
+// reproducible mode, so that scripts draw the same numbers on every run.
+const REPRODUCIBLE_RANDOM_SEED = 1
+
+func newRandomModule() *NativeModule {
+	return NewNativeModule("random", map[string]interface{}{
+		"seed":     NewNativeCallable("seed", 1, randomSeed),
+		"random":   NewNativeCallable("random", 0, randomRandom),
+		"uniform":  NewNativeCallable("uniform", 2, randomUniform),
+		"int":      NewNativeCallable("int", 2, randomInt),
+		"choice":   NewNativeCallable("choice", 1, randomChoice),
+		"shuffle":  NewNativeCallable("shuffle", 1, randomShuffle),
+		"gaussian": NewNativeCallable("gaussian", -1, randomGaussian),
+	})
+}
+
+func randomSeed(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	seed, err := intArgument("seed", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	inter.random.Seed(int64(seed))
+	return nil, nil
+}
+
+// randomRandom returns a uniform float in [0, 1).
+func randomRandom(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return inter.random.Float64(), nil
+}
+
+// randomUniform returns a uniform float in [low, high).
+func randomUniform(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	low, err := numberArgument("uniform", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	high, err := numberArgument("uniform", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	if low > high {
+		return nil, fmt.Errorf("uniform: low %s is greater than high %s", formatNumber(low), formatNumber(high))
+	}
+	return low + inter.random.Float64()*(high-low), nil
+}
+
+// randomInt returns a uniform integer in [low, high], both ends included.
+func randomInt(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	low, err := intArgument("int", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	high, err := intArgument("int", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	if low > high {
+		return nil, fmt.Errorf("int: low %d is greater than high %d", low, high)
+	}
+	// the span is done in uint64, as high - low overflows an int64 for ranges
+	// wider than half of the ints.
+	span := uint64(high) - uint64(low)
+	if span < math.MaxInt64 {
+		return int64(low) + inter.random.Int63n(int64(span)+1), nil
+	}
+	for {
+		offset := inter.random.Uint64()
+		if offset <= span {
+			return int64(uint64(low) + offset), nil
+		}
+	}
+}
+
+func randomChoice(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	list, err := listArgument("choice", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Elements) == 0 {
+		return nil, fmt.Errorf("choice: list is empty")
+	}
+	return list.Elements[inter.random.Intn(len(list.Elements))], nil
+}
+
+// randomShuffle shuffles a list in place and returns it.
+func randomShuffle(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	list, err := listArgument("shuffle", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	inter.random.Shuffle(len(list.Elements), func(i, j int) {
+		list.Elements[i], list.Elements[j] = list.Elements[j], list.Elements[i]
+	})
+	return list, nil
+}
+
+// randomGaussian samples a normal distribution, by default the standard one:
+// random.gaussian(mean?, stddev?).
+func randomGaussian(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("gaussian", arguments, 0, 2); err != nil {
+		return nil, err
+	}
+	mean, stddev := 0.0, 1.0
+	var err error
+	if len(arguments) > 0 {
+		if mean, err = numberArgument("gaussian", arguments, 0); err != nil {
+			return nil, err
+		}
+	}
+	if len(arguments) > 1 {
+		if stddev, err = numberArgument("gaussian", arguments, 1); err != nil {
+			return nil, err
+		}
+		if stddev < 0 || math.IsNaN(stddev) {
+			return nil, fmt.Errorf("gaussian: standard deviation must not be negative, got %s", formatNumber(stddev))
+		}
+	}
+	return mean + inter.random.NormFloat64()*stddev, nil
+}
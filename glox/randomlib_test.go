@@ -0,0 +1,74 @@
+package glox
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func interpretReproducible(source string) string {
+	var stdout bytes.Buffer
+	errorReporter := NewConsoleErrorReporterWithWriter(&stdout)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), &stdout, &stdout)
+	interpreter.SetReproducible(true)
+	scanner := NewScanner(source, errorReporter)
+	parser := NewParser(scanner.ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	resolver := NewResolver(&interpreter)
+	resolver.ResolveStatements(statements)
+	interpreter.Interpret(statements)
+	return stdout.String()
+}
+
+func TestRandomReproducible(t *testing.T) {
+	source := "print random.random(); print random.int(1, 100); print random.gaussian(); print random.shuffle(list(1, 2, 3, 4, 5));"
+	first := interpretReproducible(source)
+	assert.Equal(t, first, interpretReproducible(source))
+	assert.Equal(t, 4, strings.Count(first, "\n"))
+	assert.NotContains(t, first, "Error")
+}
+
+func TestRandomModule(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+	}{
+		{"seed replays", "random.seed(42); var a = random.random(); random.seed(42); a == random.random();", true},
+		{"different seeds", "random.seed(1); var a = random.random(); random.seed(2); a != random.random();", true},
+		{"random range", "var ok = true; var i; for (i = 0; i < 1000; i = i + 1) { var r = random.random(); ok = ok and r >= 0 and r < 1; } ok;", true},
+		{"uniform range", "var ok = true; var i; for (i = 0; i < 1000; i = i + 1) { var r = random.uniform(-2, 3); ok = ok and r >= -2 and r < 3; } ok;", true},
+		{"int covers inclusive range", "var seen = map(); var i; for (i = 0; i < 1000; i = i + 1) { seen.set(random.int(1, 3), true); } str(seen.has(1) and seen.has(2) and seen.has(3) and seen.length() == 3);", "true"},
+		{"int single value", "random.int(7, 7);", int64(7)},
+		{"int range of all positive ints", "random.int(0, 9223372036854775807) >= 0;", true},
+		{"int range at the top of the ints", "random.int(9223372036854775806, 9223372036854775807) >= 9223372036854775806;", true},
+		{"int range of all ints", "var n = random.int(-9223372036854775807 - 1, 9223372036854775807); n == int(n);", true},
+		{"choice", "var l = list(\"a\", \"b\"); var c = random.choice(l); c == \"a\" or c == \"b\";", true},
+		{"shuffle keeps elements", "var l = list(); var i; for (i = 0; i < 50; i = i + 1) { l.push(i); } random.shuffle(l); var sum = 0; for (i = 0; i < 50; i = i + 1) { sum = sum + l.get(i); } sum;", int64(1225)},
+		{"gaussian", "random.seed(7); var sum = 0; var i; for (i = 0; i < 10000; i = i + 1) { sum = sum + random.gaussian(10, 2); } math.abs(sum / 10000 - 10) < 0.1;", true},
+	}
+	for _, testCase := range testCases {
+		lastValue, _, stderr := interpretSource(testCase.source)
+		assert.Empty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestRandomModuleErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"empty choice", "random.choice(list());", "[line 1] Error[interpreter]: choice: list is empty\n"},
+		{"inverted int range", "random.int(5, 1);", "[line 1] Error[interpreter]: int: low 5 is greater than high 1\n"},
+		{"non-integer seed", "random.seed(1.5);", "[line 1] Error[interpreter]: seed: argument 1 must be an integer, got 1.5\n"},
+		{"negative stddev", "random.gaussian(0, -1);", "[line 1] Error[interpreter]: gaussian: standard deviation must not be negative, got -1\n"},
+	}
+	for _, testCase := range testCases {
+		_, _, stderr := interpretSource(testCase.source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+	}
+}
@@ -0,0 +1,167 @@
+package glox
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// RegexValue is a compiled regular expression, as returned by regex.compile.
+type RegexValue struct {
+	regexp *regexp.Regexp
+}
+
+func newRegexModule() *NativeModule {
+	return NewNativeModule("regex", map[string]interface{}{
+		"compile": NewNativeCallable("compile", 1, regexCompile),
+		"escape":  NewNativeCallable("escape", 1, regexEscape),
+	})
+}
+
+func regexCompile(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	pattern, err := stringArgument("compile", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("compile: %w", err)
+	}
+	return &RegexValue{regexp: compiled}, nil
+}
+
+func regexEscape(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	text, err := stringArgument("escape", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.QuoteMeta(text), nil
+}
+
+type regexMethod struct {
+	arity    int
+	function func(inter *Interpreter, receiver *RegexValue, arguments []interface{}) (interface{}, error)
+}
+
+var regexMethods = map[string]regexMethod{
+	"test":    {1, regexTest},
+	"match":   {1, regexMatch},
+	"findAll": {1, regexFindAll},
+	"replace": {2, regexReplace},
+	"split":   {1, regexSplit},
+}
+
+func getRegexMethod(receiver *RegexValue, name string) (interface{}, error) {
+	if name == "pattern" {
+		return receiver.regexp.String(), nil
+	}
+	method, ok := regexMethods[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined regex method: %s", name)
+	}
+	return NewNativeCallable(name, method.arity, func(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+		return method.function(inter, receiver, arguments)
+	}), nil
+}
+
+func regexTest(inter *Interpreter, receiver *RegexValue, arguments []interface{}) (interface{}, error) {
+	text, err := stringArgument("test", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return receiver.regexp.MatchString(text), nil
+}
+
+// regexMatch returns the first match in a string, or nil when there is none.
+func regexMatch(inter *Interpreter, receiver *RegexValue, arguments []interface{}) (interface{}, error) {
+	text, err := stringArgument("match", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	indices := receiver.regexp.FindStringSubmatchIndex(text)
+	if indices == nil {
+		return nil, nil
+	}
+	return receiver.newMatch(text, indices), nil
+}
+
+func regexFindAll(inter *Interpreter, receiver *RegexValue, arguments []interface{}) (interface{}, error) {
+	text, err := stringArgument("findAll", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	matches := []interface{}{}
+	for _, indices := range receiver.regexp.FindAllStringSubmatchIndex(text, -1) {
+		matches = append(matches, receiver.newMatch(text, indices))
+	}
+	return NewListValue(matches), nil
+}
+
+// regexReplace replaces every match, either with a template string where
+// $1 or ${name} expand to capture groups (the latter written "\${name}" in
+// a Lox string literal), or with the result of calling a Lox function with
+// each match.
+func regexReplace(inter *Interpreter, receiver *RegexValue, arguments []interface{}) (interface{}, error) {
+	text, err := stringArgument("replace", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if template, ok := arguments[1].(string); ok {
+		return receiver.regexp.ReplaceAllString(text, template), nil
+	}
+	if _, ok := arguments[1].(Callable); !ok {
+		return nil, fmt.Errorf("replace: argument 2 must be a string or a function, got %s", typeName(arguments[1]))
+	}
+	result := []byte{}
+	last := 0
+	for _, indices := range receiver.regexp.FindAllStringSubmatchIndex(text, -1) {
+		replacement, err := inter.callValue(arguments[1], []interface{}{receiver.newMatch(text, indices)})
+		if err != nil {
+			return nil, fmt.Errorf("replace: %w", err)
+		}
+		result = append(result, text[last:indices[0]]...)
+		result = append(result, Stringify(replacement)...)
+		last = indices[1]
+	}
+	result = append(result, text[last:]...)
+	return string(result), nil
+}
+
+func regexSplit(inter *Interpreter, receiver *RegexValue, arguments []interface{}) (interface{}, error) {
+	text, err := stringArgument("split", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	parts := []interface{}{}
+	for _, part := range receiver.regexp.Split(text, -1) {
+		parts = append(parts, part)
+	}
+	return NewListValue(parts), nil
+}
+
+// newMatch describes a match as a map with the matched "text", its rune
+// "index" in the input, the list of positional "groups" (nil for groups
+// that did not participate) and a map of "named" groups.
+func (r *RegexValue) newMatch(text string, indices []int) *MapValue {
+	groups := []interface{}{}
+	named := NewMapValue()
+	for i, name := range r.regexp.SubexpNames() {
+		if i == 0 {
+			continue
+		}
+		var group interface{} = nil
+		if indices[2*i] >= 0 {
+			group = text[indices[2*i]:indices[2*i+1]]
+		}
+		groups = append(groups, group)
+		if len(name) > 0 {
+			named.Set(name, group)
+		}
+	}
+	match := NewMapValue()
+	match.Set("text", text[indices[0]:indices[1]])
+	match.Set("index", int64(utf8.RuneCountInString(text[:indices[0]])))
+	match.Set("groups", NewListValue(groups))
+	match.Set("named", named)
+	return match
+}
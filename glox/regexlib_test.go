@@ -0,0 +1,49 @@
+package glox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexModule(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+	}{
+		{"test", "regex.compile(\"^[a-z]+$\").test(\"glox\");", true},
+		{"pattern", "var r = regex.compile(\"a+\"); r.pattern + \" \" + str(r);", "a+ <regex a+>"},
+		{"match", "var m = regex.compile(\"(\\\\d+)-(\\\\d+)?\").match(\"ñ 12-\"); str(m);", "{\"text\": \"12-\", \"index\": 2, \"groups\": [\"12\", nil], \"named\": {}}"},
+		{"no match", "regex.compile(\"x\").match(\"abc\");", nil},
+		{"named groups", "var m = regex.compile(\"(?P<key>\\\\w+)=(?P<value>\\\\w*)\").match(\"level=warn\"); m.get(\"named\").get(\"key\") + \":\" + m.get(\"named\").get(\"value\");", "level:warn"},
+		{"findAll", "var all = regex.compile(\"(\\\\w)(\\\\d)\").findAll(\"a1 b2 c3\"); var out = \"\"; var i; for (i = 0; i < all.length(); i = i + 1) { out = out + all.get(i).get(\"groups\").get(1); } out;", "123"},
+		{"findAll no match", "regex.compile(\"z\").findAll(\"abc\").length();", int64(0)},
+		{"replace template", "regex.compile(\"(\\\\w+)@(\\\\w+)\").replace(\"bob@example\", \"$2 at \\${1}\");", "example at bob"},
+		{"replace callback", "fun double(m) { return m.get(\"text\").toNumber() * 2; } regex.compile(\"\\\\d+\").replace(\"a1 b20\", double);", "a2 b40"},
+		{"split", "\"|\".join(regex.compile(\"\\\\s*,\\\\s*\").split(\"a , b,c\"));", "a|b|c"},
+		{"escape", "regex.compile(regex.escape(\"1+1=2?\")).test(\"is 1+1=2?\");", true},
+	}
+	for _, testCase := range testCases {
+		lastValue, _, stderr := interpretSource(testCase.source)
+		assert.Empty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestRegexModuleErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"compile error", "\nregex.compile(\"a(b\");", "[line 2] Error[interpreter]: compile: error parsing regexp: missing closing ): `a(b`\n"},
+		{"bad replacement", "regex.compile(\"a\").replace(\"a\", 1);", "[line 1] Error[interpreter]: replace: argument 2 must be a string or a function, got number\n"},
+		{"callback error", "fun bad(m) {\n  return m.missing(); }\nregex.compile(\"a\").replace(\"a\", bad);", "[line 2] Error[interpreter]: undefined map method: missing\n"},
+		{"undefined method", "regex.compile(\"a\").exec(\"a\");", "[line 1] Error[interpreter]: undefined regex method: exec\n"},
+	}
+	for _, testCase := range testCases {
+		_, _, stderr := interpretSource(testCase.source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+	}
+}
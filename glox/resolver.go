@@ -2,6 +2,8 @@ package glox
 
 import "fmt"
 
+const RESOLVER_WHERE = "resolver"
+
 type Resolver struct {
 	inter  *Interpreter
 	scopes []map[string]bool
@@ -14,6 +16,20 @@ func NewResolver(inter *Interpreter) Resolver {
 	}
 }
 
+// newResolverInEnvironment returns a Resolver for source run in environment,
+// with a scope for each of its environments but the globals.
+func newResolverInEnvironment(inter *Interpreter, environment *Environment) Resolver {
+	resolver := NewResolver(inter)
+	for ; environment != nil && environment != inter.globals; environment = environment.enclosing {
+		scope := map[string]bool{}
+		for name := range environment.values {
+			scope[name] = true
+		}
+		resolver.scopes = append([]map[string]bool{scope}, resolver.scopes...)
+	}
+	return resolver
+}
+
 func (r *Resolver) beginScope() {
 	r.scopes = append(r.scopes, map[string]bool{})
 }
@@ -60,11 +76,17 @@ func (r *Resolver) declare(name Token) {
 		return
 	}
 	if _, ok := r.scopes[len(r.scopes)-1][name.Lexeme]; ok {
-		panic(fmt.Errorf("variable: %v already exists in this scope", name.Lexeme))
+		r.error(name, fmt.Errorf("variable: %v already exists in this scope", name.Lexeme))
 	}
 	r.scopes[len(r.scopes)-1][name.Lexeme] = false
 }
 
+// error reports a static error through the interpreter's ErrorReporter,
+// resolution goes on to find any further errors.
+func (r *Resolver) error(name Token, err error) {
+	r.inter.errorReporter.Push(name.Line, RESOLVER_WHERE, err)
+}
+
 func (r *Resolver) define(name Token) {
 	if len(r.scopes) == 0 {
 		return
@@ -142,6 +164,9 @@ func (r *Resolver) visitBinaryExpr(expr BinaryExpr) (interface{}, error) {
 }
 
 func (r *Resolver) visitConditionalExpr(expr ConditionalExpr) (interface{}, error) {
+	r.resolveExpression(expr.Condition)
+	r.resolveExpression(expr.Left)
+	r.resolveExpression(expr.Right)
 	return nil, nil
 }
 
@@ -166,8 +191,10 @@ func (r *Resolver) visitUnaryExpr(expr UnaryExpr) (interface{}, error) {
 }
 
 func (r *Resolver) visitVariableExpr(expr VariableExpr) (interface{}, error) {
-	if len(r.scopes) > 0 && !r.scopes[len(r.scopes)-1][expr.Name.Lexeme] {
-		return nil, fmt.Errorf("can't read local variable in its own initializer")
+	if len(r.scopes) > 0 {
+		if defined, ok := r.scopes[len(r.scopes)-1][expr.Name.Lexeme]; ok && !defined {
+			r.error(expr.Name, fmt.Errorf("can't read local variable in its own initializer"))
+		}
 	}
 	r.resolveLocal(expr, expr.Name)
 	return nil, nil
@@ -186,3 +213,8 @@ func (r *Resolver) visitCallExpr(expr CallExpr) (interface{}, error) {
 	}
 	return nil, nil
 }
+
+func (r *Resolver) visitGetExpr(expr GetExpr) (interface{}, error) {
+	r.resolveExpression(expr.Object)
+	return nil, nil
+}
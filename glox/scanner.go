@@ -3,6 +3,7 @@ package glox
 import (
 	"fmt"
 	"strconv"
+	"unicode"
 )
 
 type Scanner interface {
@@ -37,24 +38,55 @@ type SimpleScanner struct {
 	start         int
 	current       int
 	line          int
+	// interpolations holds, for every "${" currently open, how many "{"
+	// have been opened inside it and not yet closed.
+	interpolations []int
+	// keepComments makes the scanner emit comments as TOKEN_COMMENT
+	// tokens, for tools such as the formatter. The parser does not accept
+	// them.
+	keepComments bool
+	// trackColumns makes the scanner set Token.Column, for tools that need
+	// exact positions such as the language server.
+	trackColumns bool
+	// lineStart is the offset of the first rune of the current line and
+	// startColumn the column of the token being scanned.
+	lineStart   int
+	startColumn int
 }
 
 func NewScanner(source string, errorReporter ErrorReporter) *SimpleScanner {
 	return &SimpleScanner{
-		source:        []rune(source),
-		errorReporter: errorReporter,
-		tokens:        []Token{},
-		start:         0,
-		current:       0,
-		line:          1,
+		source:         []rune(source),
+		errorReporter:  errorReporter,
+		tokens:         []Token{},
+		start:          0,
+		current:        0,
+		line:           1,
+		interpolations: []int{},
 	}
 }
 
+func NewScannerWithComments(source string, errorReporter ErrorReporter) *SimpleScanner {
+	scanner := NewScanner(source, errorReporter)
+	scanner.keepComments = true
+	return scanner
+}
+
+func NewScannerWithColumns(source string, errorReporter ErrorReporter) *SimpleScanner {
+	scanner := NewScanner(source, errorReporter)
+	scanner.trackColumns = true
+	return scanner
+}
+
 func (s *SimpleScanner) ScanTokens() []Token {
 	for !s.isAtEnd() {
 		s.start = s.current
+		s.startColumn = s.current - s.lineStart + 1
 		s.scanToken()
 	}
+	if len(s.interpolations) > 0 {
+		s.errorReporter.Error(s.line, "Unterminated string interpolation.")
+	}
 	s.tokens = append(s.tokens, NewToken(TOKEN_EOF, "", nil, s.line))
 	return s.tokens
 }
@@ -67,11 +99,26 @@ func (s *SimpleScanner) scanToken() {
 	case ')':
 		s.addToken(TOKEN_RIGHT_PAREN)
 	case '{':
+		if len(s.interpolations) > 0 {
+			s.interpolations[len(s.interpolations)-1]++
+		}
 		s.addToken(TOKEN_LEFT_BRACE)
 	case '}':
+		if len(s.interpolations) > 0 {
+			depth := len(s.interpolations) - 1
+			if s.interpolations[depth] == 0 {
+				// closes "${", the string literal goes on.
+				s.interpolations = s.interpolations[:depth]
+				s.string()
+				return
+			}
+			s.interpolations[depth]--
+		}
 		s.addToken(TOKEN_RIGHT_BRACE)
 	case ',':
 		s.addToken(TOKEN_COMMA)
+	case '.':
+		s.addToken(TOKEN_DOT)
 	case '-':
 		s.addToken(TOKEN_MINUS)
 	case '+':
@@ -79,7 +126,19 @@ func (s *SimpleScanner) scanToken() {
 	case ';':
 		s.addToken(TOKEN_SEMICOLON)
 	case '*':
-		s.addToken(TOKEN_STAR)
+		if s.match('*') {
+			s.addToken(TOKEN_STAR_STAR)
+		} else {
+			s.addToken(TOKEN_STAR)
+		}
+	case '%':
+		s.addToken(TOKEN_PERCENT)
+	case '~':
+		if s.match('/') {
+			s.addToken(TOKEN_TILDE_SLASH)
+		} else {
+			s.errorReporter.Error(s.line, "Unexpected character: '~'")
+		}
 	case '?':
 		s.addToken(TOKEN_QUESTION)
 	case ':':
@@ -114,8 +173,10 @@ func (s *SimpleScanner) scanToken() {
 			for s.peek() != '\n' && !s.isAtEnd() {
 				s.advance()
 			}
+			s.addComment()
 		} else if s.match('*') {
 			s.multiLineComment()
+			s.addComment()
 		} else {
 			s.addToken(TOKEN_SLASH)
 		}
@@ -124,7 +185,7 @@ func (s *SimpleScanner) scanToken() {
 	case '\t':
 		// ignore whitespace
 	case '\n':
-		s.line++
+		s.newLine()
 	case '"':
 		s.string()
 	default:
@@ -179,27 +240,99 @@ func (s *SimpleScanner) addToken(tokenType int) {
 
 func (s *SimpleScanner) addTokenWithLiteral(tokenType int, literal interface{}) {
 	text := s.source[s.start:s.current]
-	s.tokens = append(s.tokens, NewToken(tokenType, string(text), literal, s.line))
+	token := NewToken(tokenType, string(text), literal, s.line)
+	if s.trackColumns {
+		token.Column = s.startColumn
+	}
+	s.tokens = append(s.tokens, token)
 }
 
+// newLine counts a newline the scanner has just consumed.
+func (s *SimpleScanner) newLine() {
+	s.line++
+	s.lineStart = s.current
+}
+
+/*
+ * string scans a string literal up to its closing quote, or up to the next
+ * "${" when the literal is interpolated. In the latter case the text so far
+ * becomes a TOKEN_INTERPOLATION, the embedded expression is scanned as
+ * regular tokens, and scanning resumes here after its closing "}".
+ */
 func (s *SimpleScanner) string() {
-	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
-			s.line++
+	var value []rune
+	for !s.isAtEnd() {
+		c := s.advance()
+		switch {
+		case c == '"':
+			s.addTokenWithLiteral(TOKEN_STRING, string(value))
+			return
+		case c == '$' && s.peek() == '{':
+			s.advance()
+			s.addTokenWithLiteral(TOKEN_INTERPOLATION, string(value))
+			s.interpolations = append(s.interpolations, 0)
+			return
+		case c == '\\':
+			if escaped, ok := s.escapeSequence(); ok {
+				value = append(value, escaped)
+			}
+		default:
+			if c == '\n' {
+				s.newLine()
+			}
+			value = append(value, c)
 		}
-		s.advance()
 	}
+	s.errorReporter.Error(s.line, "Unterminated string.")
+}
 
+func (s *SimpleScanner) escapeSequence() (rune, bool) {
 	if s.isAtEnd() {
-		s.errorReporter.Error(s.line, "Unterminated string.")
-		return
+		return 0, false
 	}
+	c := s.advance()
+	switch c {
+	case 'n':
+		return '\n', true
+	case 't':
+		return '\t', true
+	case 'r':
+		return '\r', true
+	case '0':
+		return 0, true
+	case '"', '\\', '$':
+		return c, true
+	case 'u':
+		return s.unicodeEscape()
+	}
+	s.errorReporter.Error(s.line, fmt.Sprintf("Invalid escape sequence: '\\%c'", c))
+	return 0, false
+}
 
-	// the closing ".
-	s.advance()
-
-	value := s.source[s.start+1 : s.current-1]
-	s.addTokenWithLiteral(TOKEN_STRING, string(value))
+/*
+ * unicodeEscape scans the "{XXXX}" part of a "\u{XXXX}" escape, with one to
+ * six hexadecimal digits naming a Unicode code point.
+ */
+func (s *SimpleScanner) unicodeEscape() (rune, bool) {
+	if !s.match('{') {
+		s.errorReporter.Error(s.line, "Invalid unicode escape: expect '{' after '\\u'.")
+		return 0, false
+	}
+	digitsStart := s.current
+	for isHexDigit(s.peek()) {
+		s.advance()
+	}
+	digits := string(s.source[digitsStart:s.current])
+	if !s.match('}') || len(digits) == 0 || len(digits) > 6 {
+		s.errorReporter.Error(s.line, fmt.Sprintf("Invalid unicode escape: '\\u{%s'", digits))
+		return 0, false
+	}
+	codePoint, _ := strconv.ParseInt(digits, 16, 32)
+	if codePoint > unicode.MaxRune || (codePoint >= 0xD800 && codePoint <= 0xDFFF) {
+		s.errorReporter.Error(s.line, fmt.Sprintf("Invalid unicode code point: U+%s", digits))
+		return 0, false
+	}
+	return rune(codePoint), true
 }
 
 func (s *SimpleScanner) number() {
@@ -215,6 +348,17 @@ func (s *SimpleScanner) number() {
 		for isDigit(s.peek()) {
 			s.advance()
 		}
+	} else {
+		// numbers without one are ints.
+		numberStr := string(s.source[s.start:s.current])
+		if number, err := strconv.ParseInt(numberStr, 10, 64); err == nil {
+			s.addTokenWithLiteral(TOKEN_NUMBER, number)
+		} else {
+			s.errorReporter.Error(s.line, fmt.Sprintf("Integer too large: %v", numberStr))
+			// keep the token, for the parser not to report the statement as well.
+			s.addTokenWithLiteral(TOKEN_NUMBER, nil)
+		}
+		return
 	}
 
 	numberStr := string(s.source[s.start:s.current])
@@ -238,12 +382,19 @@ func (s *SimpleScanner) identifier() {
 	}
 }
 
+func (s *SimpleScanner) addComment() {
+	if s.keepComments {
+		s.addToken(TOKEN_COMMENT)
+	}
+}
+
 func (s *SimpleScanner) multiLineComment() {
 	// comment goes until terminator "*/"
 	commentNesting := 0
 	for !s.isAtEnd() {
 		if s.peek() == '\n' {
 			s.line++
+			s.lineStart = s.current + 1
 		} else if s.peek() == '/' && s.peekNext() == '*' {
 			commentNesting++
 			s.advance()
@@ -268,6 +419,10 @@ func isDigit(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
+func isHexDigit(c rune) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
 func isAlpha(c rune) bool {
 	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
 }
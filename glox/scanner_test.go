@@ -1,6 +1,7 @@
 package glox
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,9 +41,9 @@ func TestScanTokens(t *testing.T) {
 			NewToken(TOKEN_MINUS, "-", nil, 1),
 			NewToken(TOKEN_NUMBER, "12345.67890", 12345.6789, 1),
 			NewToken(TOKEN_STAR, "*", nil, 1),
-			NewToken(TOKEN_NUMBER, "1", 1.0, 1),
+			NewToken(TOKEN_NUMBER, "1", int64(1), 1),
 			NewToken(TOKEN_SLASH, "/", nil, 1),
-			NewToken(TOKEN_NUMBER, "2", 2.0, 1),
+			NewToken(TOKEN_NUMBER, "2", int64(2), 1),
 			NewToken(TOKEN_EOF, "", nil, 1),
 		},
 		},
@@ -52,7 +53,7 @@ func TestScanTokens(t *testing.T) {
 			[]Token{
 				NewToken(TOKEN_STRING, "\"test\"", "test", 1),
 				NewToken(TOKEN_PLUS, "+", nil, 1),
-				NewToken(TOKEN_NUMBER, "2", 2.0, 1),
+				NewToken(TOKEN_NUMBER, "2", int64(2), 1),
 				NewToken(TOKEN_PLUS, "+", nil, 1),
 				NewToken(TOKEN_STRING, "\"test2\"", "test2", 1),
 				NewToken(TOKEN_EOF, "", nil, 1),
@@ -68,7 +69,7 @@ func TestScanTokens(t *testing.T) {
 				NewToken(TOKEN_RIGHT_PAREN, ")", nil, 1),
 				NewToken(TOKEN_LEFT_BRACE, "{", nil, 1),
 				NewToken(TOKEN_RETURN, "return", nil, 1),
-				NewToken(TOKEN_NUMBER, "2", 2.0, 1),
+				NewToken(TOKEN_NUMBER, "2", int64(2), 1),
 				NewToken(TOKEN_STAR, "*", nil, 1),
 				NewToken(TOKEN_IDENTIFIER, "testFunc", "testFunc", 1),
 				NewToken(TOKEN_LEFT_PAREN, "(", nil, 1),
@@ -107,6 +108,56 @@ func TestScanTokens(t *testing.T) {
 				NewToken(TOKEN_EOF, "", nil, 1),
 			},
 		},
+		{
+			"string escapes",
+			`"tab\there\n\"quoted\" \\ \$ \u{48}\u{1F600}"`,
+			[]Token{
+				NewToken(TOKEN_STRING, `"tab\there\n\"quoted\" \\ \$ \u{48}\u{1F600}"`, "tab\there\n\"quoted\" \\ $ H\U0001F600", 1),
+				NewToken(TOKEN_EOF, "", nil, 1),
+			},
+		},
+		{
+			"string interpolation",
+			`"Hi ${name}, ${ "${n}" }!"`,
+			[]Token{
+				NewToken(TOKEN_INTERPOLATION, `"Hi ${`, "Hi ", 1),
+				NewToken(TOKEN_IDENTIFIER, "name", "name", 1),
+				NewToken(TOKEN_INTERPOLATION, `}, ${`, ", ", 1),
+				NewToken(TOKEN_INTERPOLATION, `"${`, "", 1),
+				NewToken(TOKEN_IDENTIFIER, "n", "n", 1),
+				NewToken(TOKEN_STRING, `}"`, "", 1),
+				NewToken(TOKEN_STRING, `}!"`, "!", 1),
+				NewToken(TOKEN_EOF, "", nil, 1),
+			},
+		},
+		{
+			"numeric operators",
+			"a%b**c~/d*e",
+			[]Token{
+				NewToken(TOKEN_IDENTIFIER, "a", "a", 1),
+				NewToken(TOKEN_PERCENT, "%", nil, 1),
+				NewToken(TOKEN_IDENTIFIER, "b", "b", 1),
+				NewToken(TOKEN_STAR_STAR, "**", nil, 1),
+				NewToken(TOKEN_IDENTIFIER, "c", "c", 1),
+				NewToken(TOKEN_TILDE_SLASH, "~/", nil, 1),
+				NewToken(TOKEN_IDENTIFIER, "d", "d", 1),
+				NewToken(TOKEN_STAR, "*", nil, 1),
+				NewToken(TOKEN_IDENTIFIER, "e", "e", 1),
+				NewToken(TOKEN_EOF, "", nil, 1),
+			},
+		},
+		{
+			"property access",
+			"name.upper()",
+			[]Token{
+				NewToken(TOKEN_IDENTIFIER, "name", "name", 1),
+				NewToken(TOKEN_DOT, ".", nil, 1),
+				NewToken(TOKEN_IDENTIFIER, "upper", "upper", 1),
+				NewToken(TOKEN_LEFT_PAREN, "(", nil, 1),
+				NewToken(TOKEN_RIGHT_PAREN, ")", nil, 1),
+				NewToken(TOKEN_EOF, "", nil, 1),
+			},
+		},
 		{
 			"single line comment",
 			"//test",
@@ -138,3 +189,68 @@ func TestScanTokens(t *testing.T) {
 		assert.Equal(t, testCase.expectedTokens, currentTokens, "Failed %s: Source: %s", testCase.name, testCase.source)
 	}
 }
+
+func TestScanStringErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"unterminated string", "\"abc", "[line 1] Error: Unterminated string.\n"},
+		{"invalid escape", "\"a\\qb\";", "[line 1] Error: Invalid escape sequence: '\\q'\n"},
+		{"unicode escape without brace", "\"\\u0041\";", "[line 1] Error: Invalid unicode escape: expect '{' after '\\u'.\n"},
+		{"unicode escape too long", "\"\\u{1234567}\";", "[line 1] Error: Invalid unicode escape: '\\u{1234567'\n"},
+		{"surrogate code point", "\"\\u{D800}\";", "[line 1] Error: Invalid unicode code point: U+D800\n"},
+		{"unterminated interpolation", "\"a ${b", "[line 1] Error: Unterminated string interpolation.\n"},
+	}
+	for _, testCase := range testCases {
+		var output bytes.Buffer
+		errorReporter := NewConsoleErrorReporterWithWriter(&output)
+		scanner := NewScanner(testCase.source, errorReporter)
+		scanner.ScanTokens()
+		assert.True(t, errorReporter.HasError(), testCase.name)
+		assert.Equal(t, testCase.expectedError, output.String(), testCase.name)
+	}
+}
+
+func TestScanTokensWithComments(t *testing.T) {
+	errorReporter := NewConsoleErrorReporter()
+	scanner := NewScannerWithComments("a; // line\n/* block\n */ b", errorReporter)
+	assert.Equal(t, []Token{
+		NewToken(TOKEN_IDENTIFIER, "a", "a", 1),
+		NewToken(TOKEN_SEMICOLON, ";", nil, 1),
+		NewToken(TOKEN_COMMENT, "// line", nil, 1),
+		NewToken(TOKEN_COMMENT, "/* block\n */", nil, 3),
+		NewToken(TOKEN_IDENTIFIER, "b", "b", 3),
+		NewToken(TOKEN_EOF, "", nil, 3),
+	}, scanner.ScanTokens())
+	assert.False(t, errorReporter.HasError())
+}
+
+func TestScanTokensWithColumns(t *testing.T) {
+	errorReporter := NewConsoleErrorReporter()
+	scanner := NewScannerWithColumns("var a = \"é\"; b = \"x\ny\" + a;\n  /* c\n */ a.x;", errorReporter)
+	columns := [][3]interface{}{}
+	for _, token := range scanner.ScanTokens() {
+		columns = append(columns, [3]interface{}{token.Lexeme, token.Line, token.Column})
+	}
+	assert.Equal(t, [][3]interface{}{
+		{"var", 1, 1},
+		{"a", 1, 5},
+		{"=", 1, 7},
+		{"\"é\"", 1, 9},
+		{";", 1, 12},
+		{"b", 1, 14},
+		{"=", 1, 16},
+		{"\"x\ny\"", 2, 18},
+		{"+", 2, 4},
+		{"a", 2, 6},
+		{";", 2, 7},
+		{"a", 4, 5},
+		{".", 4, 6},
+		{"x", 4, 7},
+		{";", 4, 8},
+		{"", 4, 0},
+	}, columns)
+	assert.False(t, errorReporter.HasError())
+}
@@ -0,0 +1,370 @@
+package glox
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Precedence levels of expressions, from the loosest to the tightest, after
+// the rules of the Parser's grammar.
+const (
+	PRECEDENCE_ASSIGNMENT = iota
+	PRECEDENCE_CONDITIONAL
+	PRECEDENCE_OR
+	PRECEDENCE_AND
+	PRECEDENCE_EQUALITY
+	PRECEDENCE_COMPARISON
+	PRECEDENCE_TERM
+	PRECEDENCE_FACTOR
+	PRECEDENCE_UNARY
+	PRECEDENCE_POWER
+	PRECEDENCE_CALL
+	PRECEDENCE_PRIMARY
+)
+
+var binaryPrecedences = map[int]int{
+	TOKEN_OR:            PRECEDENCE_OR,
+	TOKEN_AND:           PRECEDENCE_AND,
+	TOKEN_BANG_EQUAL:    PRECEDENCE_EQUALITY,
+	TOKEN_EQUAL_EQUAL:   PRECEDENCE_EQUALITY,
+	TOKEN_GREATER:       PRECEDENCE_COMPARISON,
+	TOKEN_GREATER_EQUAL: PRECEDENCE_COMPARISON,
+	TOKEN_LESS:          PRECEDENCE_COMPARISON,
+	TOKEN_LESS_EQUAL:    PRECEDENCE_COMPARISON,
+	TOKEN_MINUS:         PRECEDENCE_TERM,
+	TOKEN_PLUS:          PRECEDENCE_TERM,
+	TOKEN_SLASH:         PRECEDENCE_FACTOR,
+	TOKEN_STAR:          PRECEDENCE_FACTOR,
+	TOKEN_PERCENT:       PRECEDENCE_FACTOR,
+	TOKEN_TILDE_SLASH:   PRECEDENCE_FACTOR,
+	TOKEN_STAR_STAR:     PRECEDENCE_POWER,
+}
+
+// precedence returns the precedence level of an expression, the loosest
+// level it can appear at without parentheses.
+func precedence(expr Expr) int {
+	switch expr := expr.(type) {
+	case AssignExpr:
+		return PRECEDENCE_ASSIGNMENT
+	case ConditionalExpr:
+		return PRECEDENCE_CONDITIONAL
+	case BinaryExpr:
+		return binaryPrecedences[expr.Operator.Type]
+	case LogicalExpr:
+		return binaryPrecedences[expr.Operator.Type]
+	case UnaryExpr:
+		return PRECEDENCE_UNARY
+	case CallExpr, GetExpr:
+		return PRECEDENCE_CALL
+	case LiteralExpr:
+		return literalPrecedence(expr.Value)
+	}
+	return PRECEDENCE_PRIMARY
+}
+
+// literalPrecedence is unary for negative numbers, printed with a minus,
+// while numbers with no literal are printed in parentheses.
+func literalPrecedence(value interface{}) int {
+	switch value := value.(type) {
+	case int64:
+		if value < 0 && value != math.MinInt64 {
+			return PRECEDENCE_UNARY
+		}
+	case float64:
+		if math.Signbit(value) && !math.IsInf(value, -1) && !math.IsNaN(value) {
+			return PRECEDENCE_UNARY
+		}
+	}
+	return PRECEDENCE_PRIMARY
+}
+
+/*
+ * SourcePrinter turns an AST back into glox source that parses into the same
+ * tree, laid out in the style of Format. Grouping expressions are printed as
+ * the parentheses they were parsed from, and any other parentheses needed
+ * to keep the shape of trees built by hand are added following the
+ * precedence and associativity of operators, and only where needed.
+ *
+ * For loops and interpolated strings are printed as the while loops and
+ * concatenations the Parser lowers them into. Comments are lost: use Format
+ * to lay out source keeping them.
+ */
+type SourcePrinter struct {
+}
+
+// Print returns the source of statements, one per line.
+func (p SourcePrinter) Print(statements []Stmt) string {
+	var builder strings.Builder
+	for _, stmt := range statements {
+		builder.WriteString(p.statement(stmt))
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// PrintExpr returns the source of an expression.
+func (p SourcePrinter) PrintExpr(expr Expr) string {
+	return p.expression(expr)
+}
+
+func (p SourcePrinter) statement(stmt Stmt) string {
+	source, _ := stmt.accept(p)
+	return source.(string)
+}
+
+func (p SourcePrinter) expression(expr Expr) string {
+	source, _ := expr.accept(p)
+	return source.(string)
+}
+
+// operand prints expr, in parentheses if it binds looser than level.
+func (p SourcePrinter) operand(expr Expr, level int) string {
+	if precedence(expr) < level {
+		return "(" + p.expression(expr) + ")"
+	}
+	return p.expression(expr)
+}
+
+func (p SourcePrinter) block(statements []Stmt) string {
+	if len(statements) == 0 {
+		return "{}"
+	}
+	lines := []string{"{"}
+	for _, stmt := range statements {
+		for _, line := range strings.Split(p.statement(stmt), "\n") {
+			lines = append(lines, FORMAT_INDENT+line)
+		}
+	}
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n")
+}
+
+// danglingIf tells whether an else following stmt would be taken as the
+// else of an if nested in it.
+func danglingIf(stmt Stmt) bool {
+	switch stmt := stmt.(type) {
+	case IfStmt:
+		return stmt.ElseBranch == nil || danglingIf(stmt.ElseBranch)
+	case WhileStmt:
+		return danglingIf(stmt.Body)
+	}
+	return false
+}
+
+func (p SourcePrinter) visitBlockStmt(stmt BlockStmt) (interface{}, error) {
+	return p.block(stmt.Statements), nil
+}
+
+func (p SourcePrinter) visitExpressionStmt(stmt ExpressionStmt) (interface{}, error) {
+	return p.expression(stmt.Expression) + ";", nil
+}
+
+func (p SourcePrinter) visitPrintStmt(stmt PrintStmt) (interface{}, error) {
+	return "print " + p.expression(stmt.Print) + ";", nil
+}
+
+func (p SourcePrinter) visitVarStmt(stmt VarStmt) (interface{}, error) {
+	if stmt.Initializer == nil {
+		return "var " + stmt.Name.Lexeme + ";", nil
+	}
+	return "var " + stmt.Name.Lexeme + " = " + p.expression(stmt.Initializer) + ";", nil
+}
+
+func (p SourcePrinter) visitIfStmt(stmt IfStmt) (interface{}, error) {
+	thenBranch := p.statement(stmt.ThenBranch)
+	if stmt.ElseBranch != nil && danglingIf(stmt.ThenBranch) {
+		thenBranch = p.block([]Stmt{stmt.ThenBranch})
+	}
+	source := "if (" + p.expression(stmt.Condition) + ") " + thenBranch
+	if stmt.ElseBranch != nil {
+		source += " else " + p.statement(stmt.ElseBranch)
+	}
+	return source, nil
+}
+
+func (p SourcePrinter) visitWhileStmt(stmt WhileStmt) (interface{}, error) {
+	return "while (" + p.expression(stmt.Condition) + ") " + p.statement(stmt.Body), nil
+}
+
+func (p SourcePrinter) visitBreakStmt(stmt BreakStmt) (interface{}, error) {
+	return "break;", nil
+}
+
+func (p SourcePrinter) visitContinueStmt(stmt ContinueStmt) (interface{}, error) {
+	return "continue;", nil
+}
+
+func (p SourcePrinter) visitFunctionStmt(stmt FunctionStmt) (interface{}, error) {
+	params := []string{}
+	for _, param := range stmt.Params {
+		params = append(params, param.Lexeme)
+	}
+	return fmt.Sprintf("fun %s(%s) %s", stmt.Name.Lexeme, strings.Join(params, ", "), p.block(stmt.Body)), nil
+}
+
+func (p SourcePrinter) visitReturnStmt(stmt ReturnStmt) (interface{}, error) {
+	if stmt.Value == nil {
+		return "return;", nil
+	}
+	return "return " + p.expression(stmt.Value) + ";", nil
+}
+
+func (p SourcePrinter) visitBinaryExpr(expr BinaryExpr) (interface{}, error) {
+	level := binaryPrecedences[expr.Operator.Type]
+	if expr.Operator.Type == TOKEN_PLUS && precedence(expr.Right) <= level {
+		if source, ok := p.interpolation(expr); ok {
+			return source, nil
+		}
+	}
+	// operators associate to the left, but for "**", which takes a call on
+	// its left and a unary expression on its right.
+	leftLevel, rightLevel := level, level+1
+	if expr.Operator.Type == TOKEN_STAR_STAR {
+		leftLevel, rightLevel = PRECEDENCE_CALL, PRECEDENCE_UNARY
+	}
+	return p.operand(expr.Left, leftLevel) + " " + expr.Operator.Lexeme + " " + p.operand(expr.Right, rightLevel), nil
+}
+
+// interpolation prints a concatenation as the interpolated string it was
+// lowered from, when its right operand would need parentheses otherwise. It
+// must be a chain of "+" starting with a string, alternating expressions
+// and the strings between them.
+func (p SourcePrinter) interpolation(expr BinaryExpr) (string, bool) {
+	operands := []Expr{expr.Right}
+	left := expr.Left
+	for {
+		binary, ok := left.(BinaryExpr)
+		if !ok || binary.Operator.Type != TOKEN_PLUS {
+			break
+		}
+		operands = append([]Expr{binary.Right}, operands...)
+		left = binary.Left
+	}
+	operands = append([]Expr{left}, operands...)
+	if len(operands)%2 != 0 {
+		return "", false
+	}
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for i, operand := range operands {
+		if i%2 == 1 {
+			builder.WriteString("${" + p.expression(operand) + "}")
+			continue
+		}
+		literal, ok := operand.(LiteralExpr)
+		if !ok {
+			return "", false
+		}
+		text, ok := literal.Value.(string)
+		if !ok {
+			return "", false
+		}
+		builder.WriteString(escapeSource(text))
+	}
+	builder.WriteByte('"')
+	return builder.String(), true
+}
+
+func (p SourcePrinter) visitConditionalExpr(expr ConditionalExpr) (interface{}, error) {
+	return p.operand(expr.Condition, PRECEDENCE_OR) + " ? " + p.expression(expr.Left) + " : " + p.expression(expr.Right), nil
+}
+
+func (p SourcePrinter) visitGroupingExpr(expr GroupingExpr) (interface{}, error) {
+	return "(" + p.expression(expr.Expression) + ")", nil
+}
+
+func (p SourcePrinter) visitLiteralExpr(expr LiteralExpr) (interface{}, error) {
+	switch value := expr.Value.(type) {
+	case nil:
+		return "nil", nil
+	case string:
+		return quoteSource(value), nil
+	case int64:
+		// the smallest int has no literal, as its negation is too large.
+		if value == math.MinInt64 {
+			return "(-9223372036854775807 - 1)", nil
+		}
+	case float64:
+		switch {
+		case math.IsInf(value, 1):
+			return "(1 / 0)", nil
+		case math.IsInf(value, -1):
+			return "(-1 / 0)", nil
+		case math.IsNaN(value):
+			return "(0 / 0)", nil
+		}
+		// floats holding an integer keep a fraction, not to be read back as ints.
+		text := strconv.FormatFloat(value, 'f', -1, 64)
+		if !strings.Contains(text, ".") {
+			text += ".0"
+		}
+		return text, nil
+	}
+	return fmt.Sprintf("%v", expr.Value), nil
+}
+
+func (p SourcePrinter) visitLogicalExpr(expr LogicalExpr) (interface{}, error) {
+	level := binaryPrecedences[expr.Operator.Type]
+	return p.operand(expr.Left, level) + " " + expr.Operator.Lexeme + " " + p.operand(expr.Right, level+1), nil
+}
+
+func (p SourcePrinter) visitUnaryExpr(expr UnaryExpr) (interface{}, error) {
+	return expr.Operator.Lexeme + p.operand(expr.Right, PRECEDENCE_UNARY), nil
+}
+
+func (p SourcePrinter) visitVariableExpr(expr VariableExpr) (interface{}, error) {
+	return expr.Name.Lexeme, nil
+}
+
+func (p SourcePrinter) visitAssignExpr(expr AssignExpr) (interface{}, error) {
+	return expr.Name.Lexeme + " = " + p.expression(expr.Value), nil
+}
+
+func (p SourcePrinter) visitCallExpr(expr CallExpr) (interface{}, error) {
+	arguments := []string{}
+	for _, argument := range expr.Arguments {
+		arguments = append(arguments, p.expression(argument))
+	}
+	return p.operand(expr.Callee, PRECEDENCE_CALL) + "(" + strings.Join(arguments, ", ") + ")", nil
+}
+
+func (p SourcePrinter) visitGetExpr(expr GetExpr) (interface{}, error) {
+	return p.operand(expr.Object, PRECEDENCE_CALL) + "." + expr.Name.Lexeme, nil
+}
+
+// quoteSource quotes text as a glox string literal.
+func quoteSource(text string) string {
+	return "\"" + escapeSource(text) + "\""
+}
+
+// escapeSource escapes what the Scanner would read differently in a string
+// literal, and control characters.
+func escapeSource(text string) string {
+	var builder strings.Builder
+	runes := []rune(text)
+	for i, r := range runes {
+		switch {
+		case r == '"' || r == '\\':
+			builder.WriteRune('\\')
+			builder.WriteRune(r)
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '{':
+			builder.WriteString("\\$")
+		case r == '\n':
+			builder.WriteString("\\n")
+		case r == '\t':
+			builder.WriteString("\\t")
+		case r == '\r':
+			builder.WriteString("\\r")
+		case r == 0:
+			builder.WriteString("\\0")
+		case !unicode.IsPrint(r):
+			fmt.Fprintf(&builder, "\\u{%x}", r)
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
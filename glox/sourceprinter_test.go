@@ -0,0 +1,373 @@
+package glox
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseSource(t *testing.T, source string) []Stmt {
+	errorReporter := NewConsoleErrorReporter()
+	parser := NewParser(NewScanner(source, errorReporter).ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	assert.False(t, errorReporter.HasError(), source)
+	return statements
+}
+
+// astShape returns the JSON form of statements without positions, which
+// depend on the layout of the source, and optionally without groupings.
+func astShape(t *testing.T, statements []Stmt, withoutGroupings bool) interface{} {
+	data, err := AstToJson(statements)
+	assert.NoError(t, err)
+	var document interface{}
+	assert.NoError(t, json.Unmarshal(data, &document))
+	var strip func(value interface{}) interface{}
+	strip = func(value interface{}) interface{} {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if withoutGroupings && value["node"] == "Grouping" {
+				return strip(value["expression"])
+			}
+			stripped := map[string]interface{}{}
+			for key, member := range value {
+				if key != "line" && key != "column" {
+					stripped[key] = strip(member)
+				}
+			}
+			return stripped
+		case []interface{}:
+			stripped := []interface{}{}
+			for _, element := range value {
+				stripped = append(stripped, strip(element))
+			}
+			return stripped
+		}
+		return value
+	}
+	return strip(document)
+}
+
+func TestSourcePrinter(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"statements", "var a;var b=1;print a;a;", "var a;\nvar b = 1;\nprint a;\na;\n"},
+		{"function", "fun f(a,b){return a;}fun g(){return;}", "fun f(a, b) {\n  return a;\n}\nfun g() {\n  return;\n}\n"},
+		{"empty blocks", "fun f(){}\nif(true){}else{}", "fun f() {}\nif (true) {} else {}\n"},
+		{"blocks", "if(a){print 1;}else{print 2;}", "if (a) {\n  print 1;\n} else {\n  print 2;\n}\n"},
+		{"single statement bodies", "if (a) print 1; else print 2;\nwhile(a)a=a-1;", "if (a) print 1; else print 2;\nwhile (a) a = a - 1;\n"},
+		{"nested blocks", "while(a){{break;}continue;}", "while (a) {\n  {\n    break;\n  }\n  continue;\n}\n"},
+		{"for loop", "for(var i=0;i<2;i=i+1)print i;", "{\n  var i = 0;\n  while (i < 2) {\n    print i;\n    i = i + 1;\n  }\n}\n"},
+		{"infinite for loop", "for(;;)break;", "while (true) break;\n"},
+		{"groupings", "print (1+2)*((3));", "print (1 + 2) * ((3));\n"},
+		{"operators", "print !a==-b and c<=d or e~/f%g**h**i;", "print !a == -b and c <= d or e ~/ f % g ** h ** i;\n"},
+		{"conditional", "x=a?b:c?d:e;", "x = a ? b : c ? d : e;\n"},
+		{"calls", "print m.f(a,b)(c).d;", "print m.f(a, b)(c).d;\n"},
+		{"literals", "print nil;print true;print false;print 1.25;print 10;print 10.0;", "print nil;\nprint true;\nprint false;\nprint 1.25;\nprint 10;\nprint 10.0;\n"},
+		{"strings", `print "a\"b\\c\n\t$x\${y}é\u{1}";`, `print "a\"b\\c\n\t$x\${y}é\u{1}";` + "\n"},
+		{"interpolation", `print "a ${b} c";`, `print "a " + b + " c";` + "\n"},
+		{"interpolated concatenation", `print "a ${b + 1} c ${d}${"e"}";`, `print "a ${b + 1}" + " c " + d + "" + "e";` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SourcePrinter{}.Print(parseSource(t, tt.source)))
+		})
+	}
+}
+
+func variable(name string) VariableExpr {
+	return NewVariableExpr(NewToken(TOKEN_IDENTIFIER, name, name, 1))
+}
+
+func binary(left Expr, operator int, lexeme string, right Expr) BinaryExpr {
+	return NewBinaryExpr(left, NewToken(operator, lexeme, nil, 1), right)
+}
+
+func TestSourcePrinterParentheses(t *testing.T) {
+	a, b, c := variable("a"), variable("b"), variable("c")
+	minus := func(left Expr, right Expr) Expr { return binary(left, TOKEN_MINUS, "-", right) }
+	power := func(left Expr, right Expr) Expr { return binary(left, TOKEN_STAR_STAR, "**", right) }
+	negate := func(right Expr) Expr { return NewUnaryExpr(NewToken(TOKEN_MINUS, "-", nil, 1), right) }
+	tests := []struct {
+		name string
+		expr Expr
+		want string
+	}{
+		{"left associative", minus(minus(a, b), c), "a - b - c"},
+		{"right operand", minus(a, minus(b, c)), "a - (b - c)"},
+		{"looser operand", binary(minus(a, b), TOKEN_STAR, "*", c), "(a - b) * c"},
+		{"tighter operand", minus(a, binary(b, TOKEN_STAR, "*", c)), "a - b * c"},
+		{"right associative power", power(a, power(b, c)), "a ** b ** c"},
+		{"power on the left", power(power(a, b), c), "(a ** b) ** c"},
+		{"unary under power", power(negate(a), negate(b)), "(-a) ** -b"},
+		{"power under unary", negate(power(a, b)), "-a ** b"},
+		{"logical", NewLogicalExpr(a, NewToken(TOKEN_AND, "and", nil, 1), NewLogicalExpr(b, NewToken(TOKEN_OR, "or", nil, 1), c)), "a and (b or c)"},
+		{"conditional condition", NewConditionalExpr(NewConditionalExpr(a, b, c), a, b), "(a ? b : c) ? a : b"},
+		{"conditional branches", NewConditionalExpr(a, NewConditionalExpr(a, b, c), NewAssignExpr(NewToken(TOKEN_IDENTIFIER, "c", "c", 1), b)), "a ? a ? b : c : c = b"},
+		{"assignment operand", minus(NewAssignExpr(NewToken(TOKEN_IDENTIFIER, "a", "a", 1), b), c), "(a = b) - c"},
+		{"callee", NewCallExpr(minus(a, b), NewToken(TOKEN_RIGHT_PAREN, ")", nil, 1), []Expr{minus(b, c)}), "(a - b)(b - c)"},
+		{"object", NewGetExpr(negate(a), NewToken(TOKEN_IDENTIFIER, "b", "b", 1)), "(-a).b"},
+		{"negative literal under power", power(NewLiteralExpr(int64(-2), 1), a), "(-2) ** a"},
+		{"negative float under unary", negate(NewLiteralExpr(-0.5, 1)), "--0.5"},
+		{"smallest int", power(NewLiteralExpr(int64(math.MinInt64), 1), a), "(-9223372036854775807 - 1) ** a"},
+		{"infinity", minus(a, NewLiteralExpr(math.Inf(1), 1)), "a - (1 / 0)"},
+		{"negative infinity", NewLiteralExpr(math.Inf(-1), 1), "(-1 / 0)"},
+		{"not a number", NewLiteralExpr(math.NaN(), 1), "(0 / 0)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SourcePrinter{}.PrintExpr(tt.expr))
+		})
+	}
+}
+
+func TestSourcePrinterDanglingElse(t *testing.T) {
+	a := variable("a")
+	inner := NewIfStmt(a, NewBreakStmt(NewToken(TOKEN_BREAK, "break", nil, 1)), nil)
+	stmt := NewIfStmt(a, NewWhileStmt(a, inner), NewContinueStmt(NewToken(TOKEN_CONTINUE, "continue", nil, 1)))
+	source := SourcePrinter{}.Print([]Stmt{stmt})
+	assert.Equal(t, "if (a) {\n  while (a) if (a) break;\n} else continue;\n", source)
+}
+
+func TestSourcePrinterRoundTripCorpus(t *testing.T) {
+	files := []string{}
+	err := filepath.WalkDir("testdata", func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, ".glox") {
+			files = append(files, path)
+		}
+		return err
+	})
+	assert.NoError(t, err)
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			source, err := os.ReadFile(file)
+			assert.NoError(t, err)
+			errorReporter := NewConsoleErrorReporterWithWriter(io.Discard)
+			parser := NewParser(NewScanner(string(source), errorReporter).ScanTokens(), errorReporter)
+			statements := parser.Parse()
+			if errorReporter.HasError() {
+				return
+			}
+			printed := SourcePrinter{}.Print(statements)
+			reparsed := parseSource(t, printed)
+			assert.Equal(t, astShape(t, statements, false), astShape(t, reparsed, false))
+			assert.Equal(t, printed, SourcePrinter{}.Print(reparsed))
+		})
+	}
+}
+
+// astGenerator builds random trees of every kind of node, without
+// groupings.
+type astGenerator struct {
+	random *rand.Rand
+	loop   bool
+}
+
+var generatedOperators = []Token{
+	NewToken(TOKEN_BANG_EQUAL, "!=", nil, 1),
+	NewToken(TOKEN_EQUAL_EQUAL, "==", nil, 1),
+	NewToken(TOKEN_GREATER, ">", nil, 1),
+	NewToken(TOKEN_LESS_EQUAL, "<=", nil, 1),
+	NewToken(TOKEN_MINUS, "-", nil, 1),
+	NewToken(TOKEN_PLUS, "+", nil, 1),
+	NewToken(TOKEN_SLASH, "/", nil, 1),
+	NewToken(TOKEN_STAR, "*", nil, 1),
+	NewToken(TOKEN_PERCENT, "%", nil, 1),
+	NewToken(TOKEN_TILDE_SLASH, "~/", nil, 1),
+	NewToken(TOKEN_STAR_STAR, "**", nil, 1),
+}
+
+var generatedStrings = []string{"", "text", "quote \" and \\", "${not} interpolated", "$", "line\nbreak", "tab\t", "é ✓"}
+
+var generatedNumbers = []interface{}{int64(-7), -0.5, int64(math.MinInt64), math.Inf(1), math.Inf(-1), math.NaN()}
+
+// foldPrintedNumbers folds the expressions SourcePrinter prints numbers with
+// no literal as back into literals, for trees to be compared with those the
+// numbers were printed from. Non-finite numbers become the strings they
+// print as, which JSON can hold.
+func foldPrintedNumbers(statements []Stmt) []Stmt {
+	return RewriteStatements(statements, func(node Node) Node {
+		switch node := node.(type) {
+		case GroupingExpr:
+			if literal, ok := node.Expression.(LiteralExpr); ok {
+				return literal
+			}
+		case UnaryExpr:
+			if literal, ok := node.Right.(LiteralExpr); ok && node.Operator.Type == TOKEN_MINUS {
+				switch value := literal.Value.(type) {
+				case int64:
+					return NewLiteralExpr(-value, literal.Line)
+				case float64:
+					return NewLiteralExpr(-value, literal.Line)
+				}
+			}
+		case BinaryExpr:
+			left, leftOk := node.Left.(LiteralExpr)
+			right, rightOk := node.Right.(LiteralExpr)
+			if !leftOk || !rightOk {
+				break
+			}
+			if node.Operator.Type == TOKEN_SLASH && right.Value == int64(0) {
+				switch left.Value {
+				case int64(1):
+					return NewLiteralExpr("(1 / 0)", left.Line)
+				case int64(-1):
+					return NewLiteralExpr("(-1 / 0)", left.Line)
+				case int64(0):
+					return NewLiteralExpr("(0 / 0)", left.Line)
+				}
+			}
+			if node.Operator.Type == TOKEN_MINUS && left.Value == int64(-math.MaxInt64) && right.Value == int64(1) {
+				return NewLiteralExpr(int64(math.MinInt64), left.Line)
+			}
+		case LiteralExpr:
+			if value, ok := node.Value.(float64); ok && (math.IsInf(value, 0) || math.IsNaN(value)) {
+				return NewLiteralExpr(SourcePrinter{}.PrintExpr(node), node.Line)
+			}
+		}
+		return node
+	})
+}
+
+func (g astGenerator) name() Token {
+	name := string(rune('a' + g.random.Intn(4)))
+	return NewToken(TOKEN_IDENTIFIER, name, name, 1)
+}
+
+func (g astGenerator) expression(depth int) Expr {
+	if depth == 0 {
+		switch g.random.Intn(6) {
+		case 0:
+			return NewLiteralExpr(float64(g.random.Intn(1000))/4, 1)
+		case 3:
+			// ints of every size, up to the largest one.
+			return NewLiteralExpr(g.random.Int63()>>g.random.Intn(64), 1)
+		case 4:
+			// numbers printed as expressions rather than literals.
+			return NewLiteralExpr(generatedNumbers[g.random.Intn(len(generatedNumbers))], 1)
+		case 1:
+			return NewLiteralExpr(generatedStrings[g.random.Intn(len(generatedStrings))], 1)
+		case 2:
+			return NewLiteralExpr([]interface{}{true, false, nil}[g.random.Intn(3)], 1)
+		}
+		return NewVariableExpr(g.name())
+	}
+	switch g.random.Intn(8) {
+	case 0:
+		return NewAssignExpr(g.name(), g.expression(depth-1))
+	case 1:
+		return NewConditionalExpr(g.expression(depth-1), g.expression(depth-1), g.expression(depth-1))
+	case 2:
+		operator := NewToken(TOKEN_AND, "and", nil, 1)
+		if g.random.Intn(2) == 0 {
+			operator = NewToken(TOKEN_OR, "or", nil, 1)
+		}
+		return NewLogicalExpr(g.expression(depth-1), operator, g.expression(depth-1))
+	case 3:
+		operator := NewToken(TOKEN_MINUS, "-", nil, 1)
+		if g.random.Intn(2) == 0 {
+			operator = NewToken(TOKEN_BANG, "!", nil, 1)
+		}
+		return NewUnaryExpr(operator, g.expression(depth-1))
+	case 4:
+		arguments := []Expr{}
+		for i := g.random.Intn(3); i > 0; i-- {
+			arguments = append(arguments, g.expression(depth-1))
+		}
+		return NewCallExpr(g.expression(depth-1), NewToken(TOKEN_RIGHT_PAREN, ")", nil, 1), arguments)
+	case 5:
+		return NewGetExpr(g.expression(depth-1), g.name())
+	}
+	operator := generatedOperators[g.random.Intn(len(generatedOperators))]
+	return NewBinaryExpr(g.expression(depth-1), operator, g.expression(depth-1))
+}
+
+func (g astGenerator) statement(depth int) Stmt {
+	if depth == 0 {
+		switch g.random.Intn(5) {
+		case 0:
+			return NewPrintStmt(g.expression(2))
+		case 1:
+			return NewVarStmt(g.name(), nil)
+		case 2:
+			if g.loop {
+				return NewBreakStmt(NewToken(TOKEN_BREAK, "break", nil, 1))
+			}
+		case 3:
+			return NewReturnStmt(NewToken(TOKEN_RETURN, "return", nil, 1), g.expression(2))
+		}
+		return NewExpressionStmt(g.expression(3))
+	}
+	switch g.random.Intn(5) {
+	case 0:
+		thenBranch := g.body(depth - 1)
+		var elseBranch Stmt
+		if g.random.Intn(2) == 0 {
+			elseBranch = g.body(depth - 1)
+			// an else always goes to the innermost if when parsed.
+			if danglingIf(thenBranch) {
+				thenBranch = NewBlockStmt([]Stmt{thenBranch})
+			}
+		}
+		return NewIfStmt(g.expression(2), thenBranch, elseBranch)
+	case 1:
+		loop := g
+		loop.loop = true
+		return NewWhileStmt(g.expression(2), loop.body(depth-1))
+	case 2:
+		return NewBlockStmt(g.statements(depth - 1))
+	case 3:
+		params := []Token{}
+		for i := g.random.Intn(3); i > 0; i-- {
+			params = append(params, g.name())
+		}
+		return NewFunctionStmt(g.name(), params, g.statements(depth-1))
+	}
+	return NewVarStmt(g.name(), g.expression(3))
+}
+
+// body returns a statement for the body of an if or a while, where the
+// grammar does not allow declarations.
+func (g astGenerator) body(depth int) Stmt {
+	stmt := g.statement(depth)
+	switch stmt.(type) {
+	case VarStmt, FunctionStmt:
+		return NewBlockStmt([]Stmt{stmt})
+	}
+	return stmt
+}
+
+func (g astGenerator) statements(depth int) []Stmt {
+	statements := []Stmt{}
+	for i := g.random.Intn(3); i > 0; i-- {
+		statements = append(statements, g.statement(depth))
+	}
+	return statements
+}
+
+func TestSourcePrinterRoundTripProperty(t *testing.T) {
+	generator := astGenerator{random: rand.New(rand.NewSource(1))}
+	for i := 0; i < 500; i++ {
+		statements := generator.statements(3)
+		printed := SourcePrinter{}.Print(statements)
+		reparsed := parseSource(t, printed)
+		// parentheses added to keep the shape are parsed as groupings.
+		if !assert.Equal(t, astShape(t, foldPrintedNumbers(statements), true), astShape(t, foldPrintedNumbers(reparsed), true), printed) {
+			return
+		}
+		// once parsed, trees print and parse back identically.
+		reprinted := SourcePrinter{}.Print(reparsed)
+		assert.Equal(t, printed, reprinted)
+		assert.Equal(t, astShape(t, reparsed, false), astShape(t, parseSource(t, reprinted), false))
+	}
+}
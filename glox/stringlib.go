@@ -0,0 +1,268 @@
+package glox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// STRING_MAX_REPEAT_LENGTH caps the length in bytes of the strings repeat
+// builds, so that a large count is an error rather than running the process
+// out of memory.
+const STRING_MAX_REPEAT_LENGTH = 1 << 30
+
+type stringMethod struct {
+	arity    int
+	function func(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error)
+}
+
+// stringMethods are the natives available on every string value. All
+// indices and lengths are expressed in runes, not bytes.
+var stringMethods = map[string]stringMethod{
+	"length":      {0, stringLength},
+	"at":          {1, stringAt},
+	"substring":   {-1, stringSubstring},
+	"indexOf":     {1, stringIndexOf},
+	"lastIndexOf": {1, stringLastIndexOf},
+	"contains":    {1, stringContains},
+	"startsWith":  {1, stringStartsWith},
+	"endsWith":    {1, stringEndsWith},
+	"split":       {1, stringSplit},
+	"join":        {1, stringJoin},
+	"replace":     {2, stringReplace},
+	"trim":        {0, stringTrim},
+	"trimStart":   {0, stringTrimStart},
+	"trimEnd":     {0, stringTrimEnd},
+	"upper":       {0, stringUpper},
+	"lower":       {0, stringLower},
+	"repeat":      {1, stringRepeat},
+	"format":      {-1, stringFormat},
+	"toNumber":    {0, stringToNumber},
+}
+
+func getStringMethod(receiver string, name string) (interface{}, error) {
+	method, ok := stringMethods[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined string method: %s", name)
+	}
+	return NewNativeCallable(name, method.arity, func(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+		return method.function(inter, receiver, arguments)
+	}), nil
+}
+
+func stringLength(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	return int64(utf8.RuneCountInString(receiver)), nil
+}
+
+func stringAt(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	index, err := intArgument("at", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(receiver)
+	if index < 0 || index >= len(runes) {
+		return nil, fmt.Errorf("at: index %d out of range for string of length %d", index, len(runes))
+	}
+	return string(runes[index]), nil
+}
+
+func stringSubstring(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("substring", arguments, 1, 2); err != nil {
+		return nil, err
+	}
+	runes := []rune(receiver)
+	start, err := intArgument("substring", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	end := len(runes)
+	if len(arguments) > 1 {
+		end, err = intArgument("substring", arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if start < 0 || end > len(runes) || start > end {
+		return nil, fmt.Errorf("substring: range [%d, %d) out of bounds for string of length %d", start, end, len(runes))
+	}
+	return string(runes[start:end]), nil
+}
+
+func stringIndexOf(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	substr, err := stringArgument("indexOf", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return runeIndex(receiver, strings.Index(receiver, substr)), nil
+}
+
+func stringLastIndexOf(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	substr, err := stringArgument("lastIndexOf", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return runeIndex(receiver, strings.LastIndex(receiver, substr)), nil
+}
+
+// runeIndex converts a byte offset into str, as returned by the strings
+// package, into a rune offset. Negative offsets mean "not found".
+func runeIndex(str string, byteIndex int) int64 {
+	if byteIndex < 0 {
+		return -1
+	}
+	return int64(utf8.RuneCountInString(str[:byteIndex]))
+}
+
+func stringContains(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	substr, err := stringArgument("contains", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Contains(receiver, substr), nil
+}
+
+func stringStartsWith(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	prefix, err := stringArgument("startsWith", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasPrefix(receiver, prefix), nil
+}
+
+func stringEndsWith(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	suffix, err := stringArgument("endsWith", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasSuffix(receiver, suffix), nil
+}
+
+func stringSplit(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	separator, err := stringArgument("split", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	elements := []interface{}{}
+	for _, part := range strings.Split(receiver, separator) {
+		elements = append(elements, part)
+	}
+	return NewListValue(elements), nil
+}
+
+func stringJoin(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	list, err := listArgument("join", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	parts := []string{}
+	for _, element := range list.Elements {
+		parts = append(parts, Stringify(element))
+	}
+	return strings.Join(parts, receiver), nil
+}
+
+func stringReplace(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	old, err := stringArgument("replace", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := stringArgument("replace", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(receiver, old, replacement), nil
+}
+
+func stringTrim(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	return strings.TrimSpace(receiver), nil
+}
+
+func stringTrimStart(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	return strings.TrimLeftFunc(receiver, unicode.IsSpace), nil
+}
+
+func stringTrimEnd(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	return strings.TrimRightFunc(receiver, unicode.IsSpace), nil
+}
+
+func stringUpper(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	return strings.ToUpper(receiver), nil
+}
+
+func stringLower(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	return strings.ToLower(receiver), nil
+}
+
+func stringRepeat(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	count, err := intArgument("repeat", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("repeat: count must not be negative, got %d", count)
+	}
+	// checked by division, as the length itself may overflow.
+	if len(receiver) > 0 && count > STRING_MAX_REPEAT_LENGTH/len(receiver) {
+		return nil, fmt.Errorf("repeat: result would be longer than %d bytes", STRING_MAX_REPEAT_LENGTH)
+	}
+	return strings.Repeat(receiver, count), nil
+}
+
+// stringFormat replaces "{}" placeholders with the stringified arguments in
+// order, and "{N}" placeholders with the N-th argument. "{{" and "}}" produce
+// literal braces.
+func stringFormat(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	var builder strings.Builder
+	runes := []rune(receiver)
+	nextArgument := 0
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '{' && i+1 < len(runes) && runes[i+1] == '{':
+			builder.WriteRune('{')
+			i++
+		case c == '}' && i+1 < len(runes) && runes[i+1] == '}':
+			builder.WriteRune('}')
+			i++
+		case c == '{':
+			end := i + 1
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("format: unclosed placeholder at position %d", i)
+			}
+			argumentIndex := nextArgument
+			spec := string(runes[i+1 : end])
+			if len(spec) == 0 {
+				nextArgument++
+			} else {
+				index, err := strconv.Atoi(spec)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("format: invalid placeholder {%s} at position %d", spec, i)
+				}
+				argumentIndex = index
+			}
+			if argumentIndex >= len(arguments) {
+				return nil, fmt.Errorf("format: placeholder at position %d refers to argument %d but only %d given", i, argumentIndex, len(arguments))
+			}
+			builder.WriteString(Stringify(arguments[argumentIndex]))
+			i = end
+		case c == '}':
+			return nil, fmt.Errorf("format: unmatched '}' at position %d", i)
+		default:
+			builder.WriteRune(c)
+		}
+	}
+	return builder.String(), nil
+}
+
+func stringToNumber(inter *Interpreter, receiver string, arguments []interface{}) (interface{}, error) {
+	number, err := strconv.ParseFloat(strings.TrimSpace(receiver), 64)
+	if err != nil {
+		return nil, fmt.Errorf("toNumber: cannot parse %q as a number", receiver)
+	}
+	return number, nil
+}
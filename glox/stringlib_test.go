@@ -0,0 +1,68 @@
+package glox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringMethods(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+	}{
+		{"len", "len(\"héllo\");", int64(5)},
+		{"length", "\"日本語\".length();", int64(3)},
+		{"at", "\"日本語\".at(1);", "本"},
+		{"substring", "\"héllo wörld\".substring(6);", "wörld"},
+		{"substring with end", "\"héllo wörld\".substring(1, 5);", "éllo"},
+		{"indexOf", "\"héllo wörld\".indexOf(\"wö\");", int64(6)},
+		{"indexOf missing", "\"hello\".indexOf(\"z\");", int64(-1)},
+		{"lastIndexOf", "\"ñaña\".lastIndexOf(\"ñ\");", int64(2)},
+		{"contains", "\"hello\".contains(\"ell\");", true},
+		{"startsWith", "\"hello\".startsWith(\"he\");", true},
+		{"endsWith", "\"hello\".endsWith(\"he\");", false},
+		{"split", "var parts = \"a,b,,c\".split(\",\"); parts.length() + parts.get(3);", "4c"},
+		{"split runes", "\"añb\".split(\"\").get(1);", "ñ"},
+		{"join", "\"-\".join(\"a b c\".split(\" \"));", "a-b-c"},
+		{"replace", "\"a.b.c\".replace(\".\", \"::\");", "a::b::c"},
+		{"trim", "\"  padded \t\".trim();", "padded"},
+		{"trimStart", "\"  padded  \".trimStart();", "padded  "},
+		{"trimEnd", "\"  padded  \".trimEnd();", "  padded"},
+		{"upper", "\"héllo\".upper();", "HÉLLO"},
+		{"lower", "\"ÀB\".lower();", "àb"},
+		{"repeat", "\"ab\".repeat(3);", "ababab"},
+		{"format", "\"{} has {} items, {{{0}}}\".format(\"cart\", 3);", "cart has 3 items, {cart}"},
+		{"toNumber", "\" 42.5 \".toNumber() + 1;", 43.5},
+		{"chained", "\" Hello \".trim().lower().replace(\"l\", \"L\");", "heLLo"},
+	}
+	for _, testCase := range testCases {
+		lastValue, _, stderr := interpretSource(testCase.source)
+		assert.Empty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestStringMethodErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"repeat beyond the maximum length", "\"ab\".repeat(1000000000000);", "[line 1] Error[interpreter]: repeat: result would be longer than 1073741824 bytes\n"},
+		{"repeat length overflow", "\"ab\".repeat(4611686018427387904);", "[line 1] Error[interpreter]: repeat: result would be longer than 1073741824 bytes\n"},
+		{"unknown method", "\"abc\".reverse();", "[line 1] Error[interpreter]: undefined string method: reverse\n"},
+		{"at out of range", "\"abc\".at(3);", "[line 1] Error[interpreter]: at: index 3 out of range for string of length 3\n"},
+		{"non-integer index", "\"abc\".at(1.5);", "[line 1] Error[interpreter]: at: argument 1 must be an integer, got 1.5\n"},
+		{"wrong argument type", "\"abc\".contains(1);", "[line 1] Error[interpreter]: contains: argument 1 must be a string, got number\n"},
+		{"substring arguments", "\"abc\".substring();", "[line 1] Error[interpreter]: substring: expected 1 to 2 arguments but got 0\n"},
+		{"format missing argument", "\"{} {}\".format(1);", "[line 1] Error[interpreter]: format: placeholder at position 3 refers to argument 1 but only 1 given\n"},
+		{"format unmatched brace", "\"a}b\".format();", "[line 1] Error[interpreter]: format: unmatched '}' at position 1\n"},
+		{"toNumber", "\n\"12abc\".toNumber();", "[line 2] Error[interpreter]: toNumber: cannot parse \"12abc\" as a number\n"},
+	}
+	for _, testCase := range testCases {
+		_, _, stderr := interpretSource(testCase.source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+	}
+}
@@ -0,0 +1,83 @@
+package glox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// nativeAssert fails with a runtime error when its condition is falsy:
+// assert(condition, message?).
+func nativeAssert(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("assert", arguments, 1, 2); err != nil {
+		return nil, err
+	}
+	truthy, err := isTruthy(arguments[0])
+	if err != nil {
+		return nil, fmt.Errorf("assert: %w", err)
+	}
+	if !truthy {
+		return nil, assertionError("assertion failed", arguments, 1)
+	}
+	return nil, nil
+}
+
+// nativeAssertEqual fails when two values are not equal, comparing lists and
+// maps by their contents: assertEqual(actual, expected, message?).
+func nativeAssertEqual(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("assertEqual", arguments, 2, 3); err != nil {
+		return nil, err
+	}
+	actual, expected := arguments[0], arguments[1]
+	if !isEqual(actual, expected) {
+		return nil, assertionError(fmt.Sprintf("expected %s but got %s", quoteValue(expected), quoteValue(actual)), arguments, 2)
+	}
+	return nil, nil
+}
+
+// nativeAssertThrows calls a function of no arguments and fails unless it
+// raises a runtime error, whose message must contain the optional expected
+// text: assertThrows(function, expected?). It returns the error message.
+func nativeAssertThrows(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("assertThrows", arguments, 1, 2); err != nil {
+		return nil, err
+	}
+	expected := ""
+	if len(arguments) == 2 {
+		var err error
+		if expected, err = stringArgument("assertThrows", arguments, 1); err != nil {
+			return nil, err
+		}
+	}
+	callable, ok := arguments[0].(Callable)
+	if !ok || callable.getArity() > 0 {
+		return nil, fmt.Errorf("assertThrows: argument 1 must be a function of no arguments, got %s", typeName(arguments[0]))
+	}
+	errorReporter := inter.errorReporter
+	inter.errorReporter = NewConsoleErrorReporterWithWriter(io.Discard)
+	_, err := callable.call(inter, []interface{}{})
+	inter.errorReporter = errorReporter
+	if err == nil {
+		return nil, errors.New("assertThrows: expected an error but none was raised")
+	}
+	message := err.Error()
+	if !strings.Contains(message, expected) {
+		return nil, fmt.Errorf("assertThrows: expected an error containing %q but got %q", expected, message)
+	}
+	return message, nil
+}
+
+func assertionError(reason string, arguments []interface{}, messageIndex int) error {
+	if messageIndex < len(arguments) {
+		return fmt.Errorf("%s: %s", Stringify(arguments[messageIndex]), reason)
+	}
+	return errors.New(reason)
+}
+
+func quoteValue(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return fmt.Sprintf("%q", text)
+	}
+	return Stringify(value)
+}
@@ -0,0 +1,99 @@
+package glox
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertNatives(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"assert passes", "assert(1 == 1);", ""},
+		{"assert fails", "assert(1 == 2);", "[line 1] Error[interpreter]: assertion failed\n"},
+		{"assert message", "assert(false, \"must hold\");", "[line 1] Error[interpreter]: must hold: assertion failed\n"},
+		{"assertEqual passes", "assertEqual(list(1, \"a\"), list(1, \"a\"));", ""},
+		{"assertEqual maps", "var a = map(); a.set(\"k\", 1); var b = map(); b.set(\"k\", 1); assertEqual(a, b);", ""},
+		{"assertEqual fails", "assertEqual(\"3\", 3);", "[line 1] Error[interpreter]: expected 3 but got \"3\"\n"},
+		{"assertEqual message", "\nassertEqual(1 + 1, 3, \"sum\");", "[line 2] Error[interpreter]: sum: expected 3 but got 2\n"},
+		{"assertThrows passes", "fun f() { return 1 / nil; } assertEqual(assertThrows(f, \"operands\"), \"operator /: operands must be numbers: cannot convert to float: <nil>\");", ""},
+		{"assertThrows native", "fun f() { return math.sqrt(\"x\"); } assertThrows(f);", ""},
+		{"assertThrows fails", "fun f() { return 1; } assertThrows(f);", "[line 1] Error[interpreter]: assertThrows: expected an error but none was raised\n"},
+		{"assertThrows wrong error", "fun f() { return 1 / nil; } assertThrows(f, \"undefined\");", "[line 1] Error[interpreter]: assertThrows: expected an error containing \"undefined\" but got \"operator /: operands must be numbers: cannot convert to float: <nil>\"\n"},
+		{"assertThrows arity", "fun f(x) { return x; } assertThrows(f);", "[line 1] Error[interpreter]: assertThrows: argument 1 must be a function of no arguments, got function\n"},
+	}
+	for _, testCase := range testCases {
+		_, _, stderr := interpretSource(testCase.source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+	}
+}
+
+func TestTestRunner(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"math_test.glox": `var base = 10;
+
+fun test_add() {
+  assertEqual(base + 1, 11);
+}
+
+fun test_fails() {
+  print "debug output";
+  assertEqual(base * 2, 21);
+}
+
+fun helper() {
+  assert(false);
+}
+`,
+		filepath.Join("nested", "broken_test.glox"): "fun test_broken() {\n",
+		"ignored.glox": "fun test_ignored() { assert(false); }\n",
+	}
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(source), 0644))
+	}
+
+	var output bytes.Buffer
+	results, err := NewTestRunner(&output).RunDir(dir)
+	assert.NoError(t, err)
+	assert.False(t, AllTestsPassed(results))
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, "test_add", results[0].Name)
+		assert.True(t, results[0].Passed)
+		assert.Equal(t, 3, results[0].Line)
+		assert.Equal(t, "test_fails", results[1].Name)
+		assert.False(t, results[1].Passed)
+		assert.Equal(t, 9, results[1].Line)
+		assert.Equal(t, "expected 21 but got 20", results[1].Message)
+		assert.Equal(t, "debug output\n", results[1].Output)
+		assert.Equal(t, "", results[2].Name)
+		assert.Equal(t, filepath.Join(dir, "nested", "broken_test.glox"), results[2].File)
+		assert.False(t, results[2].Passed)
+	}
+	assert.Contains(t, output.String(), "--- PASS: test_add ("+filepath.Join(dir, "math_test.glox")+":3, ")
+	assert.Contains(t, output.String(), "--- FAIL: test_fails ("+filepath.Join(dir, "math_test.glox")+":9, ")
+	assert.Contains(t, output.String(), "    expected 21 but got 20\n    | debug output\n")
+	assert.Contains(t, output.String(), "--- FAIL: "+filepath.Join(dir, "nested", "broken_test.glox")+"\n")
+	assert.Contains(t, output.String(), "FAIL: 2 of 3 tests failed\n")
+	assert.NotContains(t, output.String(), "ignored")
+}
+
+func TestTestRunnerPasses(t *testing.T) {
+	dir := t.TempDir()
+	source := "fun test_one() { assert(true); }\nfun test_two() { assertEqual(\"a\" + \"b\", \"ab\"); }\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ok_test.glox"), []byte(source), 0644))
+	var output bytes.Buffer
+	results, err := NewTestRunner(&output).RunDir(dir)
+	assert.NoError(t, err)
+	assert.True(t, AllTestsPassed(results))
+	assert.Len(t, results, 2)
+	assert.Contains(t, output.String(), "PASS: 2 tests\n")
+}
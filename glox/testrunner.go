@@ -0,0 +1,211 @@
+package glox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const TEST_FILE_SUFFIX = "_test.glox"
+const TEST_FUNCTION_PREFIX = "test_"
+
+// TestResult is the outcome of a single test function. Name is empty when
+// the whole file failed to load, for instance because of a parse error.
+type TestResult struct {
+	File     string
+	Name     string
+	Line     int
+	Passed   bool
+	Message  string
+	Output   string
+	Duration time.Duration
+}
+
+// TestRunner discovers and runs test functions, named test_*, declared at
+// the top level of *_test.glox files. Each file runs in its own interpreter
+// in reproducible mode, after executing its top-level statements.
+type TestRunner struct {
+	writer io.Writer
+	// coverage, when set, collects the coverage of the test files.
+	coverage *Coverage
+}
+
+func NewTestRunner(writer io.Writer) *TestRunner {
+	return &TestRunner{writer: writer}
+}
+
+// SetCoverage makes the runner record the coverage of each test file in
+// coverage.
+func (r *TestRunner) SetCoverage(coverage *Coverage) {
+	r.coverage = coverage
+}
+
+// FindTestFiles returns the test files under dir, sorted by path.
+func FindTestFiles(dir string) ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), TEST_FILE_SUFFIX) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// RunDir runs every test file under dir and reports each test and a final
+// summary to the runner's writer.
+func (r *TestRunner) RunDir(dir string) ([]TestResult, error) {
+	files, err := FindTestFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	results := []TestResult{}
+	for _, file := range files {
+		fileResults, err := r.RunFile(file)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, fileResults...)
+	}
+	r.reportSummary(results)
+	return results, nil
+}
+
+// RunFile runs the tests of a single file, reporting each of them.
+func (r *TestRunner) RunFile(path string) ([]TestResult, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var output, errorOutput bytes.Buffer
+	errorReporter := NewConsoleErrorReporterWithWriter(&errorOutput)
+	interpreter := NewInterpreterWithStreams(errorReporter, strings.NewReader(""), &output, &output)
+	interpreter.SetReproducible(true)
+	scanner := NewScanner(string(source), errorReporter)
+	parser := NewParser(scanner.ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	if !errorReporter.HasError() {
+		resolver := NewResolver(&interpreter)
+		resolver.ResolveStatements(statements)
+		if !errorReporter.HasError() {
+			if r.coverage != nil {
+				interpreter.SetCoverage(r.coverage.AddFile(path, string(source), statements))
+			}
+			interpreter.Interpret(statements)
+		}
+	}
+	if errorReporter.HasError() {
+		result := TestResult{
+			File:    path,
+			Message: strings.TrimRight(errorOutput.String(), "\n"),
+			Output:  output.String(),
+		}
+		r.report(result)
+		return []TestResult{result}, nil
+	}
+
+	results := []TestResult{}
+	for _, stmt := range statements {
+		function, ok := stmt.(FunctionStmt)
+		if !ok || !strings.HasPrefix(function.Name.Lexeme, TEST_FUNCTION_PREFIX) {
+			continue
+		}
+		output.Reset()
+		result := interpreter.runTest(function)
+		result.File = path
+		result.Output = output.String()
+		r.report(result)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (inter *Interpreter) runTest(function FunctionStmt) TestResult {
+	result := TestResult{
+		Name: function.Name.Lexeme,
+		Line: function.Name.Line,
+	}
+	if len(function.Params) > 0 {
+		result.Message = fmt.Sprintf("test functions take no arguments, %s takes %d", function.Name.Lexeme, len(function.Params))
+		return result
+	}
+	callee, err := inter.globals.Get(function.Name.Lexeme)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	start := time.Now()
+	inter.lock.Lock()
+	_, err = inter.callValue(callee, []interface{}{})
+	inter.lock.Unlock()
+	result.Duration = time.Since(start)
+	if err != nil {
+		var runtimeErr RuntimeError
+		if errors.As(err, &runtimeErr) {
+			result.Line = runtimeErr.Line
+		}
+		result.Message = err.Error()
+		return result
+	}
+	result.Passed = true
+	return result
+}
+
+func (r *TestRunner) report(result TestResult) {
+	if result.Name == "" {
+		fmt.Fprintf(r.writer, "--- FAIL: %s\n", result.File)
+	} else if result.Passed {
+		fmt.Fprintf(r.writer, "--- PASS: %s (%s:%d, %.2fs)\n", result.Name, result.File, result.Line, result.Duration.Seconds())
+	} else {
+		fmt.Fprintf(r.writer, "--- FAIL: %s (%s:%d, %.2fs)\n", result.Name, result.File, result.Line, result.Duration.Seconds())
+	}
+	if result.Passed {
+		return
+	}
+	for _, line := range strings.Split(result.Message, "\n") {
+		fmt.Fprintf(r.writer, "    %s\n", line)
+	}
+	if len(result.Output) > 0 {
+		for _, line := range strings.Split(strings.TrimRight(result.Output, "\n"), "\n") {
+			fmt.Fprintf(r.writer, "    | %s\n", line)
+		}
+	}
+}
+
+func (r *TestRunner) reportSummary(results []TestResult) {
+	failed := 0
+	for _, result := range results {
+		if !result.Passed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(r.writer, "FAIL: %d of %d tests failed\n", failed, len(results))
+	} else {
+		fmt.Fprintf(r.writer, "PASS: %d tests\n", len(results))
+	}
+}
+
+// AllTestsPassed reports whether all results passed.
+func AllTestsPassed(results []TestResult) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,338 @@
+package glox
+
+import (
+	"fmt"
+	"math"
+	"time"
+	// embedded so time zones resolve even where the host has no tz database.
+	_ "time/tzdata"
+)
+
+const TIME_LAYOUT_ISO = "2006-01-02T15:04:05.000Z07:00"
+
+// TimeValue is an instant in time along with the zone it is displayed in.
+// Durations are plain numbers of seconds.
+type TimeValue struct {
+	time time.Time
+}
+
+func NewTimeValue(t time.Time) *TimeValue {
+	return &TimeValue{
+		time: t,
+	}
+}
+
+// monotonicStart is the reference point for time.monotonic(). Differences
+// between time.Time values use the monotonic clock, so they are unaffected
+// by changes to the wall clock.
+var monotonicStart = time.Now()
+
+func newTimeModule() *NativeModule {
+	return NewNativeModule("time", map[string]interface{}{
+		"ISO":            TIME_LAYOUT_ISO,
+		"DATE":           "2006-01-02",
+		"DATETIME":       "2006-01-02 15:04:05",
+		"RFC1123":        time.RFC1123,
+		"now":            NewNativeCallable("now", 0, timeNow),
+		"monotonic":      NewNativeCallable("monotonic", 0, timeMonotonic),
+		"unix":           NewNativeCallable("unix", -1, timeUnix),
+		"date":           NewNativeCallable("date", -1, timeDate),
+		"parse":          NewNativeCallable("parse", -1, timeParse),
+		"sleep":          NewNativeCallable("sleep", 1, timeSleep),
+		"duration":       NewNativeCallable("duration", 1, timeDuration),
+		"formatDuration": NewNativeCallable("formatDuration", 1, timeFormatDuration),
+	})
+}
+
+func timeNow(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return NewTimeValue(time.Now()), nil
+}
+
+// timeMonotonic returns seconds elapsed on a monotonic clock, with
+// nanosecond resolution. Only differences between two readings are useful.
+func timeMonotonic(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return time.Since(monotonicStart).Seconds(), nil
+}
+
+// timeUnix converts Unix seconds, possibly fractional, into a time in the
+// given zone, UTC by default.
+func timeUnix(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("unix", arguments, 1, 2); err != nil {
+		return nil, err
+	}
+	seconds, err := numberArgument("unix", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	location, err := locationArgument("unix", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	// float64 seconds around the current epoch are only precise to about a
+	// microsecond, so finer digits are noise.
+	whole, fraction := math.Modf(seconds)
+	nanoseconds := int64(math.Round(fraction*1e6)) * int64(time.Microsecond)
+	return NewTimeValue(time.Unix(int64(whole), nanoseconds).In(location)), nil
+}
+
+// timeDate builds a time from its components:
+// time.date(year, month, day, hour?, minute?, second?, millisecond?, zone?).
+func timeDate(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("date", arguments, 3, 8); err != nil {
+		return nil, err
+	}
+	components := []int{0, 0, 0, 0, 0, 0, 0}
+	count := len(arguments)
+	if _, ok := arguments[count-1].(string); ok {
+		count--
+	} else if count > len(components) {
+		return nil, fmt.Errorf("date: expected 3 to %v arguments without a zone but got %v", len(components), count)
+	}
+	for i := 0; i < count; i++ {
+		component, err := intArgument("date", arguments, i)
+		if err != nil {
+			return nil, err
+		}
+		components[i] = component
+	}
+	location, err := locationArgument("date", arguments, count)
+	if err != nil {
+		return nil, err
+	}
+	return NewTimeValue(time.Date(components[0], time.Month(components[1]), components[2],
+		components[3], components[4], components[5], components[6]*int(time.Millisecond), location)), nil
+}
+
+// timeParse parses text with a Go reference layout, such as time.ISO or
+// "02/01/2006 15:04". Times without a zone in the text are taken to be in
+// the given zone, UTC by default.
+func timeParse(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if err := checkArgumentCount("parse", arguments, 2, 3); err != nil {
+		return nil, err
+	}
+	layout, err := stringArgument("parse", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	text, err := stringArgument("parse", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	location, err := locationArgument("parse", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := time.ParseInLocation(layout, text, location)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	return NewTimeValue(parsed), nil
+}
+
+func timeSleep(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	seconds, err := numberArgument("sleep", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	if seconds < 0 {
+		return nil, fmt.Errorf("sleep: duration must not be negative, got %s", formatNumber(seconds))
+	}
+	inter.withoutLock(func() {
+		time.Sleep(secondsToDuration(seconds))
+	})
+	return nil, nil
+}
+
+// timeDuration parses durations like "1h30m" or "250ms" into seconds.
+func timeDuration(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	text, err := stringArgument("duration", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return nil, fmt.Errorf("duration: %w", err)
+	}
+	return duration.Seconds(), nil
+}
+
+func timeFormatDuration(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+	seconds, err := numberArgument("formatDuration", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return secondsToDuration(seconds).String(), nil
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Round(seconds * float64(time.Second)))
+}
+
+// locationArgument loads the IANA zone named by an optional argument, such
+// as "Europe/Madrid", "Local" or "UTC". A missing argument means UTC.
+func locationArgument(name string, arguments []interface{}, index int) (*time.Location, error) {
+	if index >= len(arguments) {
+		return time.UTC, nil
+	}
+	zone, err := stringArgument(name, arguments, index)
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unknown time zone %q", name, zone)
+	}
+	return location, nil
+}
+
+func timeArgument(name string, arguments []interface{}, index int) (*TimeValue, error) {
+	value, ok := arguments[index].(*TimeValue)
+	if !ok {
+		return nil, fmt.Errorf("%s: argument %d must be a time, got %s", name, index+1, typeName(arguments[index]))
+	}
+	return value, nil
+}
+
+type timeMethod struct {
+	arity    int
+	function func(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error)
+}
+
+var timeMethods = map[string]timeMethod{
+	"unix":        {0, timeUnixMethod},
+	"unixMillis":  {0, timeUnixMillis},
+	"format":      {1, timeFormat},
+	"year":        {0, timeComponent(func(t time.Time) int { return t.Year() })},
+	"month":       {0, timeComponent(func(t time.Time) int { return int(t.Month()) })},
+	"day":         {0, timeComponent(func(t time.Time) int { return t.Day() })},
+	"hour":        {0, timeComponent(func(t time.Time) int { return t.Hour() })},
+	"minute":      {0, timeComponent(func(t time.Time) int { return t.Minute() })},
+	"second":      {0, timeComponent(func(t time.Time) int { return t.Second() })},
+	"millisecond": {0, timeComponent(func(t time.Time) int { return t.Nanosecond() / int(time.Millisecond) })},
+	"weekday":     {0, timeComponent(func(t time.Time) int { return int(t.Weekday()) })},
+	"yearDay":     {0, timeComponent(func(t time.Time) int { return t.YearDay() })},
+	"zone":        {0, timeZone},
+	"in":          {1, timeIn},
+	"utc":         {0, timeUTC},
+	"add":         {1, timeAdd},
+	"addDate":     {3, timeAddDate},
+	"sub":         {1, timeSub},
+	"before":      {1, timeBefore},
+	"after":       {1, timeAfter},
+	"equal":       {1, timeEqual},
+	"startOfDay":  {0, timeStartOfDay},
+}
+
+func getTimeMethod(receiver *TimeValue, name string) (interface{}, error) {
+	method, ok := timeMethods[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined time method: %s", name)
+	}
+	return NewNativeCallable(name, method.arity, func(inter *Interpreter, arguments []interface{}) (interface{}, error) {
+		return method.function(inter, receiver, arguments)
+	}), nil
+}
+
+func timeComponent(component func(t time.Time) int) func(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	return func(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+		return int64(component(receiver.time)), nil
+	}
+}
+
+func timeUnixMethod(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	return float64(receiver.time.Unix()) + float64(receiver.time.Nanosecond())/1e9, nil
+}
+
+func timeUnixMillis(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	return receiver.time.UnixNano() / int64(time.Millisecond), nil
+}
+
+func timeFormat(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	layout, err := stringArgument("format", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return receiver.time.Format(layout), nil
+}
+
+func timeZone(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	return receiver.time.Location().String(), nil
+}
+
+func timeIn(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	location, err := locationArgument("in", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return NewTimeValue(receiver.time.In(location)), nil
+}
+
+func timeUTC(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	return NewTimeValue(receiver.time.UTC()), nil
+}
+
+func timeAdd(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	seconds, err := numberArgument("add", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return NewTimeValue(receiver.time.Add(secondsToDuration(seconds))), nil
+}
+
+// timeAddDate adds calendar years, months and days, normalizing overflows
+// the same way as time.Date: October 31 plus one month is December 1.
+func timeAddDate(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	years, err := intArgument("addDate", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	months, err := intArgument("addDate", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	days, err := intArgument("addDate", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+	return NewTimeValue(receiver.time.AddDate(years, months, days)), nil
+}
+
+// timeSub returns the seconds elapsed from another time to the receiver.
+func timeSub(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	other, err := timeArgument("sub", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return receiver.time.Sub(other.time).Seconds(), nil
+}
+
+func timeBefore(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	other, err := timeArgument("before", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return receiver.time.Before(other.time), nil
+}
+
+func timeAfter(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	other, err := timeArgument("after", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return receiver.time.After(other.time), nil
+}
+
+func timeEqual(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	other, err := timeArgument("equal", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return receiver.time.Equal(other.time), nil
+}
+
+// timeStartOfDay returns midnight of the receiver's day in its own zone,
+// which is handy to bucket events by day.
+func timeStartOfDay(inter *Interpreter, receiver *TimeValue, arguments []interface{}) (interface{}, error) {
+	year, month, day := receiver.time.Date()
+	return NewTimeValue(time.Date(year, month, day, 0, 0, 0, 0, receiver.time.Location())), nil
+}
@@ -0,0 +1,60 @@
+package glox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeModule(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedValue interface{}
+	}{
+		{"clock is fractional seconds", "var c = clock(); c > 1600000000 and c != math.floor(c);", true},
+		{"monotonic", "var start = time.monotonic(); time.sleep(0.01); var elapsed = time.monotonic() - start; elapsed >= 0.01 and elapsed < 1;", true},
+		{"now", "time.now().unixMillis() - clock() * 1000 < 1000;", true},
+		{"unix", "str(time.unix(1700000000.123));", "2023-11-14T22:13:20.123Z"},
+		{"unix with zone", "time.unix(1700000000, \"America/New_York\").format(time.DATETIME + \" MST\");", "2023-11-14 17:13:20 EST"},
+		{"date", "str(time.date(2024, 2, 29, 13, 45, 10, 250));", "2024-02-29T13:45:10.250Z"},
+		{"date with zone", "var d = time.date(2024, 7, 1, \"Europe/Madrid\"); \"${d} ${d.zone()}\";", "2024-07-01T00:00:00.000+02:00 Europe/Madrid"},
+		{"components", "var d = time.date(2024, 3, 5, 6, 7, 8, 9); \"${d.year()}-${d.month()}-${d.day()} ${d.hour()}:${d.minute()}:${d.second()}.${d.millisecond()} ${d.weekday()} ${d.yearDay()}\";", "2024-3-5 6:7:8.9 2 65"},
+		{"parse iso", "time.parse(time.ISO, \"2024-01-02T03:04:05.678+01:00\").unix();", 1704161045.678},
+		{"parse in zone", "str(time.parse(time.DATE, \"2024-01-15\", \"Asia/Tokyo\").utc());", "2024-01-14T15:00:00.000Z"},
+		{"format", "time.date(2024, 12, 25).format(\"Mon 02 Jan 2006\");", "Wed 25 Dec 2024"},
+		{"in", "str(time.date(2024, 1, 1, 12, 0, 0).in(\"Asia/Kolkata\"));", "2024-01-01T17:30:00.000+05:30"},
+		{"add", "str(time.date(2024, 1, 1).add(time.duration(\"36h\")));", "2024-01-02T12:00:00.000Z"},
+		{"addDate", "str(time.date(2024, 1, 31).addDate(0, 1, 0));", "2024-03-02T00:00:00.000Z"},
+		{"sub", "time.date(2024, 1, 2).sub(time.date(2024, 1, 1));", 86400.0},
+		{"comparisons", "var a = time.date(2024, 1, 1); var b = a.add(1); a.before(b) and b.after(a) and a.equal(time.unix(a.unix(), \"Europe/Paris\"));", true},
+		{"startOfDay buckets by day", "var t = time.parse(time.DATETIME, \"2024-05-06 23:59:59\", \"America/Chicago\"); str(t.startOfDay());", "2024-05-06T00:00:00.000-05:00"},
+		{"duration", "time.duration(\"1h30m250ms\");", 5400.25},
+		{"formatDuration", "time.formatDuration(5400.25);", "1h30m0.25s"},
+	}
+	for _, testCase := range testCases {
+		lastValue, _, stderr := interpretSource(testCase.source)
+		assert.Empty(t, stderr, testCase.name)
+		assert.Equal(t, testCase.expectedValue, lastValue, testCase.name)
+	}
+}
+
+func TestTimeModuleErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"unknown zone", "time.unix(0, \"Mars/Olympus\");", "[line 1] Error[interpreter]: unix: unknown time zone \"Mars/Olympus\"\n"},
+		{"bad parse", "time.parse(time.DATE, \"2024-13-01\");", "[line 1] Error[interpreter]: parse: parsing time \"2024-13-01\": month out of range\n"},
+		{"bad duration", "time.duration(\"5 minutes\");", "[line 1] Error[interpreter]: duration: time: unknown unit \" minutes\" in duration \"5 minutes\"\n"},
+		{"negative sleep", "time.sleep(-1);", "[line 1] Error[interpreter]: sleep: duration must not be negative, got -1\n"},
+		{"sub non-time", "time.now().sub(1);", "[line 1] Error[interpreter]: sub: argument 1 must be a time, got number\n"},
+		{"date arguments", "time.date(2024);", "[line 1] Error[interpreter]: date: expected 3 to 8 arguments but got 1\n"},
+		{"date arguments without a zone", "time.date(2020, 1, 1, 0, 0, 0, 0, 0);", "[line 1] Error[interpreter]: date: expected 3 to 7 arguments without a zone but got 8\n"},
+	}
+	for _, testCase := range testCases {
+		_, _, stderr := interpretSource(testCase.source)
+		assert.Equal(t, testCase.expectedError, stderr, testCase.name)
+	}
+}
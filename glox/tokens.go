@@ -21,6 +21,7 @@ const (
 	TOKEN_STAR
 	TOKEN_QUESTION
 	TOKEN_COLON
+	TOKEN_PERCENT
 
 	// One or two character tokens.
 	TOKEN_BANG
@@ -31,11 +32,14 @@ const (
 	TOKEN_GREATER_EQUAL
 	TOKEN_LESS
 	TOKEN_LESS_EQUAL
+	TOKEN_STAR_STAR
+	TOKEN_TILDE_SLASH
 
 	// Literals.
 	TOKEN_IDENTIFIER
 	TOKEN_STRING
 	TOKEN_NUMBER
+	TOKEN_INTERPOLATION
 
 	// Keywords.
 	TOKEN_AND
@@ -57,6 +61,9 @@ const (
 	TOKEN_BREAK
 	TOKEN_CONTINUE
 
+	// Only produced by scanners created with NewScannerWithComments.
+	TOKEN_COMMENT
+
 	TOKEN_EOF
 )
 
@@ -64,7 +71,12 @@ type Token struct {
 	Type    int
 	Lexeme  string
 	Literal interface{}
-	Line    int
+	// Line is the line where the token ends.
+	Line int
+	// Column is the 1-based column, in runes, where the token starts. It is
+	// only set by scanners created with NewScannerWithColumns, and is 0
+	// otherwise.
+	Column int
 }
 
 func NewToken(tokenType int, lexeme string, literal interface{}, line int) Token {
@@ -92,6 +104,8 @@ func TokenTypeToString(tokenType int) string {
 		return "}"
 	case TOKEN_COMMA:
 		return ","
+	case TOKEN_DOT:
+		return "."
 	case TOKEN_MINUS:
 		return "-"
 	case TOKEN_PLUS:
@@ -104,6 +118,12 @@ func TokenTypeToString(tokenType int) string {
 		return "?"
 	case TOKEN_COLON:
 		return ":"
+	case TOKEN_PERCENT:
+		return "%"
+	case TOKEN_STAR_STAR:
+		return "**"
+	case TOKEN_TILDE_SLASH:
+		return "~/"
 	case TOKEN_EOF:
 		return "EOF"
 	case TOKEN_BANG_EQUAL:
@@ -131,6 +151,8 @@ func TokenTypeToString(tokenType int) string {
 		return "TOKEN_STRING"
 	case TOKEN_NUMBER:
 		return "TOKEN_NUMBER"
+	case TOKEN_INTERPOLATION:
+		return "TOKEN_INTERPOLATION"
 
 	// Keywords.
 	case TOKEN_AND:
@@ -169,6 +191,8 @@ func TokenTypeToString(tokenType int) string {
 		return "TOKEN_BREAK"
 	case TOKEN_CONTINUE:
 		return "TOKEN_CONTINUE"
+	case TOKEN_COMMENT:
+		return "TOKEN_COMMENT"
 	default:
 		return "N/A"
 	}
@@ -0,0 +1,157 @@
+package glox
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ListValue is the runtime representation of a Lox list. Lists are shared
+// by reference, so they are always handled through a pointer.
+type ListValue struct {
+	Elements []interface{}
+}
+
+func NewListValue(elements []interface{}) *ListValue {
+	return &ListValue{
+		Elements: elements,
+	}
+}
+
+// MapValue is the runtime representation of a Lox map. Keys keep their
+// insertion order so that maps print and serialize deterministically.
+type MapValue struct {
+	keys   []interface{}
+	values map[interface{}]interface{}
+}
+
+func NewMapValue() *MapValue {
+	return &MapValue{
+		keys:   []interface{}{},
+		values: map[interface{}]interface{}{},
+	}
+}
+
+func (m *MapValue) Get(key interface{}) (interface{}, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+func (m *MapValue) Set(key interface{}, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *MapValue) Delete(key interface{}) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+func (m *MapValue) Keys() []interface{} {
+	return append([]interface{}{}, m.keys...)
+}
+
+func (m *MapValue) Len() int {
+	return len(m.keys)
+}
+
+// Stringify returns the canonical text form of a runtime value, as used by
+// print, string concatenation and str().
+func Stringify(value interface{}) string {
+	var builder strings.Builder
+	writeValue(&builder, value, map[interface{}]bool{}, false)
+	return builder.String()
+}
+
+func writeValue(builder *strings.Builder, value interface{}, visiting map[interface{}]bool, quoteStrings bool) {
+	switch value := value.(type) {
+	case nil:
+		builder.WriteString("nil")
+	case bool:
+		builder.WriteString(strconv.FormatBool(value))
+	case float64:
+		builder.WriteString(formatNumber(value))
+	case int64:
+		builder.WriteString(strconv.FormatInt(value, 10))
+	case string:
+		if quoteStrings {
+			builder.WriteString(strconv.Quote(value))
+		} else {
+			builder.WriteString(value)
+		}
+	case FunctionCallable:
+		builder.WriteString("<fn " + value.declaration.Name.Lexeme + ">")
+	case Callable:
+		builder.WriteString("<native fn>")
+	case *NativeModule:
+		builder.WriteString("<module " + value.name + ">")
+	case *RegexValue:
+		builder.WriteString("<regex " + value.regexp.String() + ">")
+	case *TimeValue:
+		builder.WriteString(value.time.Format(TIME_LAYOUT_ISO))
+	case *HttpServerValue:
+		builder.WriteString("<server " + value.listener.Addr().String() + ">")
+	case *ListenerValue:
+		builder.WriteString("<listener " + value.listener.Addr().String() + ">")
+	case *ConnectionValue:
+		builder.WriteString("<connection " + value.conn.RemoteAddr().String() + ">")
+	case *ListValue:
+		if visiting[value] {
+			builder.WriteString("[...]")
+			return
+		}
+		visiting[value] = true
+		builder.WriteString("[")
+		for i, element := range value.Elements {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			writeValue(builder, element, visiting, true)
+		}
+		builder.WriteString("]")
+		delete(visiting, value)
+	case *MapValue:
+		if visiting[value] {
+			builder.WriteString("{...}")
+			return
+		}
+		visiting[value] = true
+		builder.WriteString("{")
+		for i, key := range value.keys {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			writeValue(builder, key, visiting, true)
+			builder.WriteString(": ")
+			writeValue(builder, value.values[key], visiting, true)
+		}
+		builder.WriteString("}")
+		delete(visiting, value)
+	default:
+		builder.WriteString("<unknown>")
+	}
+}
+
+func formatNumber(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "nan"
+	case math.IsInf(value, 1):
+		return "inf"
+	case math.IsInf(value, -1):
+		return "-inf"
+	case math.Abs(value) >= 1e21:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
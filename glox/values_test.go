@@ -0,0 +1,46 @@
+package glox
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringify(t *testing.T) {
+	cyclicList := NewListValue([]interface{}{1.0})
+	cyclicList.Elements = append(cyclicList.Elements, cyclicList)
+	cyclicMap := NewMapValue()
+	cyclicMap.Set("self", cyclicMap)
+	sharedList := NewListValue([]interface{}{"x"})
+	nestedMap := NewMapValue()
+	nestedMap.Set("name", "glox")
+	nestedMap.Set(2.0, NewListValue([]interface{}{true, nil}))
+
+	testCases := []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{"nil", nil, "nil"},
+		{"true", true, "true"},
+		{"integral number", 4181.0, "4181"},
+		{"negative integral number", -3.0, "-3"},
+		{"fractional number", 2.5, "2.5"},
+		{"huge number", 1e21, "1e+21"},
+		{"nan", math.NaN(), "nan"},
+		{"infinity", math.Inf(-1), "-inf"},
+		{"int64", int64(1633000000), "1633000000"},
+		{"string", "hello", "hello"},
+		{"function", NewFunctionCallable(NewFunctionStmt(NewToken(TOKEN_IDENTIFIER, "fib", "fib", 1), []Token{}, []Stmt{}), nil), "<fn fib>"},
+		{"native function", NewClockCallable(), "<native fn>"},
+		{"list", NewListValue([]interface{}{1.0, "two", nil}), "[1, \"two\", nil]"},
+		{"map", nestedMap, "{\"name\": \"glox\", 2: [true, nil]}"},
+		{"cyclic list", cyclicList, "[1, [...]]"},
+		{"cyclic map", cyclicMap, "{\"self\": {...}}"},
+		{"shared list", NewListValue([]interface{}{sharedList, sharedList}), "[[\"x\"], [\"x\"]]"},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, Stringify(testCase.value), testCase.name)
+	}
+}
@@ -0,0 +1,306 @@
+package glox
+
+/*
+ * Node is any statement or expression of an AST. Use a type switch on the
+ * node types to tell them apart.
+ */
+type Node interface {
+	accept(visitor Visitor) (interface{}, error)
+	getLine() int
+}
+
+// Line returns the line a node starts at in its source.
+func Line(node Node) int {
+	return node.getLine()
+}
+
+// Children returns the statements and expressions directly under a node, in
+// source order, leaving out missing optional ones.
+func Children(node Node) []Node {
+	children := []Node{}
+	add := func(nodes ...Node) {
+		for _, node := range nodes {
+			if node != nil {
+				children = append(children, node)
+			}
+		}
+	}
+	switch node := node.(type) {
+	case BlockStmt:
+		for _, stmt := range node.Statements {
+			add(stmt)
+		}
+	case ExpressionStmt:
+		add(node.Expression)
+	case PrintStmt:
+		add(node.Print)
+	case VarStmt:
+		add(node.Initializer)
+	case IfStmt:
+		add(node.Condition, node.ThenBranch, node.ElseBranch)
+	case WhileStmt:
+		add(node.Condition, node.Body)
+	case FunctionStmt:
+		for _, stmt := range node.Body {
+			add(stmt)
+		}
+	case ReturnStmt:
+		add(node.Value)
+	case BinaryExpr:
+		add(node.Left, node.Right)
+	case ConditionalExpr:
+		add(node.Condition, node.Left, node.Right)
+	case GroupingExpr:
+		add(node.Expression)
+	case LogicalExpr:
+		add(node.Left, node.Right)
+	case UnaryExpr:
+		add(node.Right)
+	case AssignExpr:
+		add(node.Value)
+	case CallExpr:
+		add(node.Callee)
+		for _, argument := range node.Arguments {
+			add(argument)
+		}
+	case GetExpr:
+		add(node.Object)
+	}
+	return children
+}
+
+/*
+ * NodeVisitor is called by Walk for each node. Visit returns the visitor for
+ * the children of the node, or nil to skip them, and is called again with a
+ * nil node once they are done.
+ */
+type NodeVisitor interface {
+	Visit(node Node) NodeVisitor
+}
+
+// Walk traverses an AST in depth-first order, starting with visitor.Visit(node).
+func Walk(visitor NodeVisitor, node Node) {
+	if visitor = visitor.Visit(node); visitor == nil {
+		return
+	}
+	for _, child := range Children(node) {
+		Walk(visitor, child)
+	}
+	visitor.Visit(nil)
+}
+
+// WalkStatements walks each statement of a program in turn.
+func WalkStatements(visitor NodeVisitor, statements []Stmt) {
+	for _, stmt := range statements {
+		Walk(visitor, stmt)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) NodeVisitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order, calling f for each node and
+// with nil once its children are done. Children are skipped when f returns
+// false.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// InspectStatements inspects each statement of a program in turn.
+func InspectStatements(statements []Stmt, f func(Node) bool) {
+	WalkStatements(inspector(f), statements)
+}
+
+/*
+ * Rewrite returns a copy of an AST where every node has been replaced by the
+ * result of f, called bottom-up with the node already holding the rewritten
+ * children. The original tree is left untouched, so f must return new nodes
+ * rather than change the ones it is given.
+ *
+ * Returning nil removes a statement from blocks and function bodies, and an
+ * else branch from its if. Anywhere else a node must be replaced by another
+ * of the same kind: a statement by a statement, an expression by an
+ * expression.
+ */
+func Rewrite(node Node, f func(Node) Node) Node {
+	switch node := node.(type) {
+	case BlockStmt:
+		return f(NewBlockStmt(RewriteStatements(node.Statements, f)))
+	case ExpressionStmt:
+		return f(NewExpressionStmt(rewriteExpr(node.Expression, f)))
+	case PrintStmt:
+		return f(NewPrintStmt(rewriteExpr(node.Print, f)))
+	case VarStmt:
+		return f(NewVarStmt(node.Name, rewriteExpr(node.Initializer, f)))
+	case IfStmt:
+		return f(NewIfStmt(rewriteExpr(node.Condition, f), rewriteStmt(node.ThenBranch, f), rewriteStmt(node.ElseBranch, f)))
+	case WhileStmt:
+		return f(NewWhileStmt(rewriteExpr(node.Condition, f), rewriteStmt(node.Body, f)))
+	case FunctionStmt:
+		return f(NewFunctionStmt(node.Name, node.Params, RewriteStatements(node.Body, f)))
+	case ReturnStmt:
+		return f(NewReturnStmt(node.Keyword, rewriteExpr(node.Value, f)))
+	case BinaryExpr:
+		return f(NewBinaryExpr(rewriteExpr(node.Left, f), node.Operator, rewriteExpr(node.Right, f)))
+	case ConditionalExpr:
+		return f(NewConditionalExpr(rewriteExpr(node.Condition, f), rewriteExpr(node.Left, f), rewriteExpr(node.Right, f)))
+	case GroupingExpr:
+		return f(NewGroupingExpr(rewriteExpr(node.Expression, f)))
+	case LogicalExpr:
+		return f(NewLogicalExpr(rewriteExpr(node.Left, f), node.Operator, rewriteExpr(node.Right, f)))
+	case UnaryExpr:
+		return f(NewUnaryExpr(node.Operator, rewriteExpr(node.Right, f)))
+	case AssignExpr:
+		// the copy keeps the binding of the node, and so its resolution.
+		node.Value = rewriteExpr(node.Value, f)
+		return f(node)
+	case CallExpr:
+		arguments := make([]Expr, 0, len(node.Arguments))
+		for _, argument := range node.Arguments {
+			arguments = append(arguments, rewriteExpr(argument, f))
+		}
+		return f(NewCallExpr(rewriteExpr(node.Callee, f), node.Paren, arguments))
+	case GetExpr:
+		return f(NewGetExpr(rewriteExpr(node.Object, f), node.Name))
+	}
+	return f(node)
+}
+
+// RewriteStatements rewrites each statement of a program, dropping those f
+// replaces with nil.
+func RewriteStatements(statements []Stmt, f func(Node) Node) []Stmt {
+	rewritten := make([]Stmt, 0, len(statements))
+	for _, stmt := range statements {
+		if stmt := rewriteStmt(stmt, f); stmt != nil {
+			rewritten = append(rewritten, stmt)
+		}
+	}
+	return rewritten
+}
+
+func rewriteStmt(stmt Stmt, f func(Node) Node) Stmt {
+	if stmt == nil {
+		return nil
+	}
+	return Rewrite(stmt, f)
+}
+
+func rewriteExpr(expr Expr, f func(Node) Node) Expr {
+	if expr == nil {
+		return nil
+	}
+	return Rewrite(expr, f)
+}
+
+/*
+ * BaseVisitor is a NodeVisitor for passes that only handle some kinds of
+ * nodes. A pass embeds it, sets Self to itself and has a method for each
+ * kind it handles, named after the node:
+ *
+ *   func (c *counter) VisitVariableExpr(expr glox.VariableExpr) bool
+ *
+ * The result tells whether to visit the children of the node, those of the
+ * nodes the pass does not handle are always visited.
+ */
+type BaseVisitor struct {
+	Self interface{}
+}
+
+func (b BaseVisitor) Visit(node Node) NodeVisitor {
+	if node == nil || !b.visit(node) {
+		return nil
+	}
+	return b
+}
+
+func (b BaseVisitor) visit(node Node) bool {
+	switch node := node.(type) {
+	case BlockStmt:
+		if visitor, ok := b.Self.(interface{ VisitBlockStmt(BlockStmt) bool }); ok {
+			return visitor.VisitBlockStmt(node)
+		}
+	case ExpressionStmt:
+		if visitor, ok := b.Self.(interface{ VisitExpressionStmt(ExpressionStmt) bool }); ok {
+			return visitor.VisitExpressionStmt(node)
+		}
+	case PrintStmt:
+		if visitor, ok := b.Self.(interface{ VisitPrintStmt(PrintStmt) bool }); ok {
+			return visitor.VisitPrintStmt(node)
+		}
+	case VarStmt:
+		if visitor, ok := b.Self.(interface{ VisitVarStmt(VarStmt) bool }); ok {
+			return visitor.VisitVarStmt(node)
+		}
+	case IfStmt:
+		if visitor, ok := b.Self.(interface{ VisitIfStmt(IfStmt) bool }); ok {
+			return visitor.VisitIfStmt(node)
+		}
+	case WhileStmt:
+		if visitor, ok := b.Self.(interface{ VisitWhileStmt(WhileStmt) bool }); ok {
+			return visitor.VisitWhileStmt(node)
+		}
+	case BreakStmt:
+		if visitor, ok := b.Self.(interface{ VisitBreakStmt(BreakStmt) bool }); ok {
+			return visitor.VisitBreakStmt(node)
+		}
+	case ContinueStmt:
+		if visitor, ok := b.Self.(interface{ VisitContinueStmt(ContinueStmt) bool }); ok {
+			return visitor.VisitContinueStmt(node)
+		}
+	case FunctionStmt:
+		if visitor, ok := b.Self.(interface{ VisitFunctionStmt(FunctionStmt) bool }); ok {
+			return visitor.VisitFunctionStmt(node)
+		}
+	case ReturnStmt:
+		if visitor, ok := b.Self.(interface{ VisitReturnStmt(ReturnStmt) bool }); ok {
+			return visitor.VisitReturnStmt(node)
+		}
+	case BinaryExpr:
+		if visitor, ok := b.Self.(interface{ VisitBinaryExpr(BinaryExpr) bool }); ok {
+			return visitor.VisitBinaryExpr(node)
+		}
+	case ConditionalExpr:
+		if visitor, ok := b.Self.(interface{ VisitConditionalExpr(ConditionalExpr) bool }); ok {
+			return visitor.VisitConditionalExpr(node)
+		}
+	case GroupingExpr:
+		if visitor, ok := b.Self.(interface{ VisitGroupingExpr(GroupingExpr) bool }); ok {
+			return visitor.VisitGroupingExpr(node)
+		}
+	case LiteralExpr:
+		if visitor, ok := b.Self.(interface{ VisitLiteralExpr(LiteralExpr) bool }); ok {
+			return visitor.VisitLiteralExpr(node)
+		}
+	case LogicalExpr:
+		if visitor, ok := b.Self.(interface{ VisitLogicalExpr(LogicalExpr) bool }); ok {
+			return visitor.VisitLogicalExpr(node)
+		}
+	case UnaryExpr:
+		if visitor, ok := b.Self.(interface{ VisitUnaryExpr(UnaryExpr) bool }); ok {
+			return visitor.VisitUnaryExpr(node)
+		}
+	case VariableExpr:
+		if visitor, ok := b.Self.(interface{ VisitVariableExpr(VariableExpr) bool }); ok {
+			return visitor.VisitVariableExpr(node)
+		}
+	case AssignExpr:
+		if visitor, ok := b.Self.(interface{ VisitAssignExpr(AssignExpr) bool }); ok {
+			return visitor.VisitAssignExpr(node)
+		}
+	case CallExpr:
+		if visitor, ok := b.Self.(interface{ VisitCallExpr(CallExpr) bool }); ok {
+			return visitor.VisitCallExpr(node)
+		}
+	case GetExpr:
+		if visitor, ok := b.Self.(interface{ VisitGetExpr(GetExpr) bool }); ok {
+			return visitor.VisitGetExpr(node)
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package glox_test
+
+import (
+	"testing"
+
+	"github.com/mbassale/glox/glox"
+	"github.com/stretchr/testify/assert"
+)
+
+// variableCounter counts the variables read, handling only variables and
+// functions on top of glox.BaseVisitor, as a pass outside of glox would.
+type variableCounter struct {
+	glox.BaseVisitor
+	intoFunctions bool
+	count         int
+}
+
+func newVariableCounter(intoFunctions bool) *variableCounter {
+	counter := &variableCounter{intoFunctions: intoFunctions}
+	counter.Self = counter
+	return counter
+}
+
+func (c *variableCounter) VisitVariableExpr(expr glox.VariableExpr) bool {
+	c.count++
+	return true
+}
+
+func (c *variableCounter) VisitFunctionStmt(stmt glox.FunctionStmt) bool {
+	return c.intoFunctions
+}
+
+func TestBaseVisitor(t *testing.T) {
+	source := `var a = 1;
+fun f(x) {
+  if (x > a) return -x; else print x;
+}
+while (a < 3) a = f(a) + (2);
+`
+	errorReporter := glox.NewConsoleErrorReporter()
+	parser := glox.NewParser(glox.NewScanner(source, errorReporter).ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	assert.False(t, errorReporter.HasError())
+
+	testCases := []struct {
+		name          string
+		intoFunctions bool
+		expectedCount int
+	}{
+		{"every variable", true, 7},
+		{"skipping functions", false, 3},
+	}
+	for _, testCase := range testCases {
+		counter := newVariableCounter(testCase.intoFunctions)
+		glox.WalkStatements(counter, statements)
+		assert.Equal(t, testCase.expectedCount, counter.count, testCase.name)
+	}
+}
@@ -0,0 +1,154 @@
+package glox
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const walkTestSource = `var a = 1;
+fun f(x) {
+  if (x > a) return -x; else print x;
+}
+while (a < 3) a = f(a) + (2);
+`
+
+// nodeName returns the type of a node without its Stmt or Expr suffix.
+func nodeName(node Node) string {
+	name := fmt.Sprintf("%T", node)
+	name = strings.TrimPrefix(name, "glox.")
+	name = strings.TrimSuffix(name, "Stmt")
+	return strings.TrimSuffix(name, "Expr")
+}
+
+type depthVisitor struct {
+	depth int
+	lines *[]string
+}
+
+func (v depthVisitor) Visit(node Node) NodeVisitor {
+	if node == nil {
+		*v.lines = append(*v.lines, strings.Repeat("  ", v.depth-1)+"end")
+		return nil
+	}
+	*v.lines = append(*v.lines, strings.Repeat("  ", v.depth)+nodeName(node))
+	if _, ok := node.(FunctionStmt); ok {
+		return nil
+	}
+	return depthVisitor{depth: v.depth + 1, lines: v.lines}
+}
+
+func TestWalk(t *testing.T) {
+	lines := []string{}
+	WalkStatements(depthVisitor{lines: &lines}, parseSource(t, walkTestSource))
+	assert.Equal(t, []string{
+		"Var",
+		"  Literal",
+		"  end",
+		"end",
+		"Function",
+		"While",
+		"  Binary",
+		"    Variable",
+		"    end",
+		"    Literal",
+		"    end",
+		"  end",
+		"  Expression",
+		"    Assign",
+		"      Binary",
+		"        Call",
+		"          Variable",
+		"          end",
+		"          Variable",
+		"          end",
+		"        end",
+		"        Grouping",
+		"          Literal",
+		"          end",
+		"        end",
+		"      end",
+		"    end",
+		"  end",
+		"end",
+	}, lines)
+}
+
+func TestInspect(t *testing.T) {
+	names := []string{}
+	InspectStatements(parseSource(t, walkTestSource), func(node Node) bool {
+		if node == nil {
+			return false
+		}
+		names = append(names, fmt.Sprintf("%s@%d", nodeName(node), Line(node)))
+		_, isWhile := node.(WhileStmt)
+		return !isWhile
+	})
+	assert.Equal(t, []string{
+		"Var@1", "Literal@1",
+		"Function@2", "If@3", "Binary@3", "Variable@3", "Variable@3",
+		"Return@3", "Unary@3", "Variable@3", "Print@3", "Variable@3",
+		"While@5",
+	}, names)
+}
+
+func TestChildren(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     Node
+		children []Node
+	}{
+		{"leaf", NewLiteralExpr(1.0, 1), []Node{}},
+		{"missing initializer", NewVarStmt(NewToken(TOKEN_IDENTIFIER, "a", "a", 1), nil), []Node{}},
+		{"missing else", NewIfStmt(variable("a"), NewPrintStmt(variable("b")), nil),
+			[]Node{variable("a"), NewPrintStmt(variable("b"))}},
+		{"call", NewCallExpr(variable("f"), NewToken(TOKEN_RIGHT_PAREN, ")", nil, 1), []Expr{variable("a"), variable("b")}),
+			[]Node{variable("f"), variable("a"), variable("b")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.children, Children(tt.node))
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	statements := parseSource(t, walkTestSource)
+	original := SourcePrinter{}.Print(statements)
+	rewritten := RewriteStatements(statements, func(node Node) Node {
+		switch node := node.(type) {
+		case VariableExpr:
+			name := strings.ToUpper(node.Name.Lexeme)
+			return NewVariableExpr(NewToken(TOKEN_IDENTIFIER, name, name, node.Name.Line))
+		case GroupingExpr:
+			return node.Expression
+		case PrintStmt:
+			return nil
+		case IfStmt:
+			if node.ElseBranch == nil {
+				return node.ThenBranch
+			}
+		}
+		return node
+	})
+	assert.Equal(t, original, SourcePrinter{}.Print(statements))
+	assert.Equal(t, `var a = 1;
+fun f(x) {
+  return -X;
+}
+while (A < 3) a = F(A) + 2;
+`, SourcePrinter{}.Print(rewritten))
+}
+
+func TestRewriteRemovesStatements(t *testing.T) {
+	statements := parseSource(t, "print 1;\n{\n  print 2;\n  var a;\n}\nif (true) print 3; else print 4;\n")
+	rewritten := RewriteStatements(statements, func(node Node) Node {
+		if stmt, ok := node.(PrintStmt); ok && stmt.Print.(LiteralExpr).Value != int64(3) {
+			return nil
+		}
+		return node
+	})
+	assert.Equal(t, "{\n  var a;\n}\nif (true) print 3;\n", SourcePrinter{}.Print(rewritten))
+}
@@ -2,13 +2,20 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/mbassale/glox/glox"
 )
 
+const EXIT_TEST_FAILURE = 1
 const EXIT_BAD_ARGS = 64
 const EXIT_ERROR = 65
 const EXIT_RUNTIME_ERROR = 70
@@ -16,14 +23,73 @@ const EXIT_RUNTIME_ERROR = 70
 var hadError bool = false
 var hadRuntimeError bool = false
 
-func runFile(path string) {
+// profileOptions tell how to report the profile of a script run with
+// --profile.
+type profileOptions struct {
+	output string
+	format string
+	sortBy string
+}
+
+// coverageOptions tell how to report the coverage of a script run with
+// --coverage, or of tests, and the percentages below which it fails.
+type coverageOptions struct {
+	output      string
+	format      string
+	minLines    float64
+	minBranches float64
+}
+
+// addCoverageFlags defines the coverage flags of a command.
+func addCoverageFlags(flags *flag.FlagSet) func() *coverageOptions {
+	coverage := flags.Bool("coverage", false, "report line and branch coverage to stderr unless --coverage-output is given")
+	output := flags.String("coverage-output", "", "write the coverage to a file")
+	format := flags.String("coverage-format", glox.COVERAGE_FORMAT_TEXT, "coverage format: text, html or lcov")
+	minLines := flags.Float64("coverage-min-lines", 0, "fail if less than this percentage of lines ran")
+	minBranches := flags.Float64("coverage-min-branches", 0, "fail if less than this percentage of branches were taken")
+	return func() *coverageOptions {
+		switch *format {
+		case glox.COVERAGE_FORMAT_TEXT, glox.COVERAGE_FORMAT_HTML, glox.COVERAGE_FORMAT_LCOV:
+		default:
+			usage()
+		}
+		if !*coverage {
+			return nil
+		}
+		return &coverageOptions{output: *output, format: *format, minLines: *minLines, minBranches: *minBranches}
+	}
+}
+
+func runFile(path string, optimize bool, profile *profileOptions, coverageOpts *coverageOptions) {
 	contents, err := ioutil.ReadFile(path)
 	if err != nil {
 		panic(err)
 	}
 	var errorReporter glox.ErrorReporter = glox.NewConsoleErrorReporter()
 	var interpreter glox.Interpreter = glox.NewInterpreter(errorReporter)
-	run(string(contents), &interpreter, errorReporter)
+	var beforeInterpret func(statements []glox.Stmt)
+	var coverage *glox.Coverage
+	if coverageOpts != nil {
+		coverage = glox.NewCoverage()
+		beforeInterpret = func(statements []glox.Stmt) {
+			interpreter.SetCoverage(coverage.AddFile(path, string(contents), statements))
+		}
+	}
+	if profile != nil {
+		profiler := glox.NewProfiler(path)
+		interpreter.SetProfiler(profiler)
+		run(string(contents), &interpreter, errorReporter, optimize, beforeInterpret)
+		profiler.Stop()
+		if err := writeProfile(profiler, profile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(EXIT_ERROR)
+		}
+	} else {
+		run(string(contents), &interpreter, errorReporter, optimize, beforeInterpret)
+	}
+	if coverage != nil && !hadError {
+		checkCoverage(coverage, coverageOpts)
+	}
 	if hadError {
 		os.Exit(EXIT_ERROR)
 	}
@@ -32,6 +98,72 @@ func runFile(path string) {
 	}
 }
 
+// writeProfile reports a profile to stderr, or to the output file.
+func writeProfile(profiler *glox.Profiler, profile *profileOptions) error {
+	var writer io.Writer = os.Stderr
+	if profile.output != "" {
+		file, err := os.Create(profile.output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+	switch profile.format {
+	case glox.PROFILE_FORMAT_FOLDED:
+		profiler.WriteFolded(writer)
+	case glox.PROFILE_FORMAT_PPROF:
+		return profiler.WritePprof(writer)
+	default:
+		profiler.WriteReport(writer, profile.sortBy)
+	}
+	return nil
+}
+
+// checkCoverage reports coverage, and exits with an error if it is below
+// the minimum percentages.
+func checkCoverage(coverage *glox.Coverage, options *coverageOptions) {
+	if err := writeCoverage(coverage, options); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(EXIT_ERROR)
+	}
+	summary := coverage.Summary()
+	failed := false
+	if summary.LinePercent() < options.minLines {
+		fmt.Fprintf(os.Stderr, "line coverage %.1f%% is below %.1f%%\n", summary.LinePercent(), options.minLines)
+		failed = true
+	}
+	if summary.BranchPercent() < options.minBranches {
+		fmt.Fprintf(os.Stderr, "branch coverage %.1f%% is below %.1f%%\n", summary.BranchPercent(), options.minBranches)
+		failed = true
+	}
+	if failed {
+		os.Exit(EXIT_TEST_FAILURE)
+	}
+}
+
+// writeCoverage reports coverage to stderr, or to the output file.
+func writeCoverage(coverage *glox.Coverage, options *coverageOptions) error {
+	var writer io.Writer = os.Stderr
+	if options.output != "" {
+		file, err := os.Create(options.output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+	switch options.format {
+	case glox.COVERAGE_FORMAT_HTML:
+		coverage.WriteHtml(writer)
+	case glox.COVERAGE_FORMAT_LCOV:
+		coverage.WriteLcov(writer)
+	default:
+		coverage.WriteReport(writer)
+	}
+	return nil
+}
+
 func runPrompt() {
 	scanner := bufio.NewScanner(os.Stdin)
 	var errorReporter glox.ErrorReporter = glox.NewConsoleErrorReporter()
@@ -42,12 +174,12 @@ func runPrompt() {
 			break
 		}
 		line := scanner.Text()
-		run(line, &interpreter, errorReporter)
+		run(line, &interpreter, errorReporter, false, nil)
 		errorReporter.ClearError()
 	}
 }
 
-func run(source string, interpreter *glox.Interpreter, errorReporter glox.ErrorReporter) {
+func run(source string, interpreter *glox.Interpreter, errorReporter glox.ErrorReporter, optimize bool, beforeInterpret func(statements []glox.Stmt)) {
 	scanner := glox.NewScanner(source, errorReporter)
 	if (errorReporter).HasError() {
 		hadError = true
@@ -68,22 +200,294 @@ func run(source string, interpreter *glox.Interpreter, errorReporter glox.ErrorR
 
 	resolver := glox.NewResolver(interpreter)
 	resolver.ResolveStatements(statements)
+	if errorReporter.HasError() {
+		hadError = true
+		return
+	}
 
+	if optimize {
+		statements = glox.NewOptimizer().Optimize(statements)
+	}
+	if beforeInterpret != nil {
+		beforeInterpret(statements)
+	}
 	lastValue, _ := interpreter.Interpret(statements)
-	fmt.Printf("=%v\n", lastValue)
+	fmt.Printf("=%s\n", glox.Stringify(lastValue))
 	if errorReporter.HasError() {
 		hadRuntimeError = true
 		return
 	}
 }
 
-func main() {
-	if len(os.Args) > 2 {
-		fmt.Println("Usage: glox [script]")
+// runTests runs the tests under a directory, the current one by default,
+// reporting their coverage if asked to.
+func runTests(args []string) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	coverageOpts := addCoverageFlags(flags)
+	flags.Parse(args)
+	options := coverageOpts()
+	if flags.NArg() > 1 {
+		usage()
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	runner := glox.NewTestRunner(os.Stdout)
+	var coverage *glox.Coverage
+	if options != nil {
+		coverage = glox.NewCoverage()
+		runner.SetCoverage(coverage)
+	}
+	results, err := runner.RunDir(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(EXIT_BAD_ARGS)
+	}
+	if coverage != nil {
+		checkCoverage(coverage, options)
+	}
+	if !glox.AllTestsPassed(results) {
+		os.Exit(EXIT_TEST_FAILURE)
+	}
+}
+
+// runFmt formats the given files, and the .glox files under the given
+// directories, in place. With --check it only lists the files that are not
+// formatted and fails if there are any. Without paths it formats stdin.
+func runFmt(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	check := flags.Bool("check", false, "list unformatted files and exit with an error if there are any, without changing them")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(EXIT_BAD_ARGS)
+		}
+		formatted, err := glox.Format(string(source))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(EXIT_ERROR)
+		}
+		if *check {
+			if formatted != string(source) {
+				fmt.Println("<stdin>")
+				os.Exit(EXIT_TEST_FAILURE)
+			}
+			return
+		}
+		fmt.Print(formatted)
+		return
+	}
+
+	paths, err := sourceFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(EXIT_BAD_ARGS)
-	} else if len(os.Args) == 2 {
-		runFile(os.Args[1])
+	}
+	failed := false
+	unformatted := false
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+		formatted, err := glox.Format(string(source))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s:\n%s\n", path, err)
+			failed = true
+			continue
+		}
+		if formatted == string(source) {
+			continue
+		}
+		fmt.Println(path)
+		unformatted = true
+		if !*check {
+			if err := os.WriteFile(path, []byte(formatted), 0644); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed = true
+			}
+		}
+	}
+	if failed {
+		os.Exit(EXIT_ERROR)
+	}
+	if *check && unformatted {
+		os.Exit(EXIT_TEST_FAILURE)
+	}
+}
+
+// runAst prints the syntax tree of a script as JSON, with the columns of its
+// tokens.
+func runAst(path string) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(EXIT_BAD_ARGS)
+	}
+	errorReporter := glox.NewConsoleErrorReporter()
+	scanner := glox.NewScannerWithColumns(string(contents), errorReporter)
+	parser := glox.NewParser(scanner.ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	if errorReporter.HasError() {
+		os.Exit(EXIT_ERROR)
+	}
+	document, err := glox.AstToJson(statements)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(EXIT_ERROR)
+	}
+	fmt.Println(string(document))
+}
+
+// runLsp serves the Language Server Protocol over stdin and stdout.
+func runLsp() {
+	server := glox.NewLspServer(os.Stdin, os.Stdout)
+	if err := server.Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(EXIT_ERROR)
+	}
+}
+
+// runDebug runs a script in the terminal debugger, paused before its first
+// statement, or with --dap serves the Debug Adapter Protocol over stdin and
+// stdout.
+func runDebug(args []string) {
+	flags := flag.NewFlagSet("debug", flag.ExitOnError)
+	dap := flags.Bool("dap", false, "serve the Debug Adapter Protocol over stdin and stdout")
+	flags.Parse(args)
+
+	if *dap {
+		if flags.NArg() != 0 {
+			usage()
+		}
+		if err := glox.NewDapServer(os.Stdin, os.Stdout).Serve(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(EXIT_ERROR)
+		}
+		return
+	}
+	if flags.NArg() != 1 {
+		usage()
+	}
+	contents, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(EXIT_BAD_ARGS)
+	}
+	// the program and the console share stdin.
+	stdin := bufio.NewReader(os.Stdin)
+	errorReporter := glox.NewConsoleErrorReporter()
+	interpreter := glox.NewInterpreterWithStreams(errorReporter, stdin, os.Stdout, os.Stderr)
+	debugger := glox.NewDebugger(glox.NewDebugConsole(stdin, os.Stdout, string(contents)))
+	debugger.SetStopOnEntry(true)
+	interpreter.SetDebugger(debugger)
+
+	scanner := glox.NewScanner(string(contents), errorReporter)
+	parser := glox.NewParser(scanner.ScanTokens(), errorReporter)
+	statements := parser.Parse()
+	if !errorReporter.HasError() {
+		resolver := glox.NewResolver(&interpreter)
+		resolver.ResolveStatements(statements)
+	}
+	if errorReporter.HasError() {
+		os.Exit(EXIT_ERROR)
+	}
+	if _, err := interpreter.Interpret(statements); err != nil && !errors.Is(err, glox.ErrDebuggerQuit) {
+		os.Exit(EXIT_RUNTIME_ERROR)
+	}
+}
+
+// sourceFiles expands directories into the .glox files they contain.
+func sourceFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && strings.HasSuffix(file, ".glox") {
+				files = append(files, file)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// runScript runs a script, optimizing, profiling it or measuring its
+// coverage if asked to, or the prompt when there is none.
+func runScript(args []string) {
+	flags := flag.NewFlagSet("glox", flag.ExitOnError)
+	optimize := flags.Bool("optimize", false, "fold constants and drop dead code before running the script")
+	profile := flags.Bool("profile", false, "profile the script, reporting to stderr unless --profile-output is given")
+	output := flags.String("profile-output", "", "write the profile to a file")
+	format := flags.String("profile-format", glox.PROFILE_FORMAT_TEXT, "profile format: text, folded or pprof")
+	sortBy := flags.String("profile-sort", glox.PROFILE_SORT_SELF, "sort the profile report by self or total time")
+	coverageOpts := addCoverageFlags(flags)
+	flags.Parse(args)
+	coverage := coverageOpts()
+
+	switch *format {
+	case glox.PROFILE_FORMAT_TEXT, glox.PROFILE_FORMAT_FOLDED, glox.PROFILE_FORMAT_PPROF:
+	default:
+		usage()
+	}
+	if *sortBy != glox.PROFILE_SORT_SELF && *sortBy != glox.PROFILE_SORT_TOTAL {
+		usage()
+	}
+	if flags.NArg() > 1 || ((*optimize || *profile || coverage != nil) && flags.NArg() == 0) {
+		usage()
+	} else if flags.NArg() == 1 {
+		var options *profileOptions
+		if *profile {
+			options = &profileOptions{output: *output, format: *format, sortBy: *sortBy}
+		}
+		runFile(flags.Arg(0), *optimize, options, coverage)
 	} else {
 		runPrompt()
 	}
 }
+
+func usage() {
+	fmt.Println("Usage: glox [--optimize] [--profile [--profile-output file] [--profile-format text|folded|pprof] [--profile-sort self|total]]")
+	fmt.Println("            [coverage options] [script]")
+	fmt.Println("       glox test [coverage options] [dir]")
+	fmt.Println("       glox fmt [--check] [path ...]")
+	fmt.Println("       glox ast script")
+	fmt.Println("       glox lsp")
+	fmt.Println("       glox debug script | glox debug --dap")
+	fmt.Println("Coverage options: --coverage [--coverage-output file] [--coverage-format text|html|lcov]")
+	fmt.Println("                  [--coverage-min-lines percent] [--coverage-min-branches percent]")
+	os.Exit(EXIT_BAD_ARGS)
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "test" {
+		runTests(os.Args[2:])
+	} else if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		runFmt(os.Args[2:])
+	} else if len(os.Args) > 1 && os.Args[1] == "debug" {
+		runDebug(os.Args[2:])
+	} else if len(os.Args) == 3 && os.Args[1] == "ast" {
+		runAst(os.Args[2])
+	} else if len(os.Args) == 2 && os.Args[1] == "lsp" {
+		runLsp()
+	} else {
+		runScript(os.Args[1:])
+	}
+}